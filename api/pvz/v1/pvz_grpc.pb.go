@@ -19,7 +19,8 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	PVZService_GetPVZList_FullMethodName = "/pvz.v1.PVZService/GetPVZList"
+	PVZService_GetPVZList_FullMethodName    = "/pvz.v1.PVZService/GetPVZList"
+	PVZService_GetNearbyPVZs_FullMethodName = "/pvz.v1.PVZService/GetNearbyPVZs"
 )
 
 // PVZServiceClient is the client API for PVZService service.
@@ -27,6 +28,8 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type PVZServiceClient interface {
 	GetPVZList(ctx context.Context, in *GetPVZListRequest, opts ...grpc.CallOption) (*GetPVZListResponse, error)
+	// PVZs closest to a point, nearest first. PVZs without coordinates are never found.
+	GetNearbyPVZs(ctx context.Context, in *GetNearbyPVZsRequest, opts ...grpc.CallOption) (*GetNearbyPVZsResponse, error)
 }
 
 type pVZServiceClient struct {
@@ -46,11 +49,22 @@ func (c *pVZServiceClient) GetPVZList(ctx context.Context, in *GetPVZListRequest
 	return out, nil
 }
 
+func (c *pVZServiceClient) GetNearbyPVZs(ctx context.Context, in *GetNearbyPVZsRequest, opts ...grpc.CallOption) (*GetNearbyPVZsResponse, error) {
+	out := new(GetNearbyPVZsResponse)
+	err := c.cc.Invoke(ctx, PVZService_GetNearbyPVZs_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PVZServiceServer is the server API for PVZService service.
 // All implementations must embed UnimplementedPVZServiceServer
 // for forward compatibility
 type PVZServiceServer interface {
 	GetPVZList(context.Context, *GetPVZListRequest) (*GetPVZListResponse, error)
+	// PVZs closest to a point, nearest first. PVZs without coordinates are never found.
+	GetNearbyPVZs(context.Context, *GetNearbyPVZsRequest) (*GetNearbyPVZsResponse, error)
 	mustEmbedUnimplementedPVZServiceServer()
 }
 
@@ -61,6 +75,9 @@ type UnimplementedPVZServiceServer struct {
 func (UnimplementedPVZServiceServer) GetPVZList(context.Context, *GetPVZListRequest) (*GetPVZListResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetPVZList not implemented")
 }
+func (UnimplementedPVZServiceServer) GetNearbyPVZs(context.Context, *GetNearbyPVZsRequest) (*GetNearbyPVZsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNearbyPVZs not implemented")
+}
 func (UnimplementedPVZServiceServer) mustEmbedUnimplementedPVZServiceServer() {}
 
 // UnsafePVZServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -92,6 +109,24 @@ func _PVZService_GetPVZList_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PVZService_GetNearbyPVZs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNearbyPVZsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PVZServiceServer).GetNearbyPVZs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PVZService_GetNearbyPVZs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PVZServiceServer).GetNearbyPVZs(ctx, req.(*GetNearbyPVZsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PVZService_ServiceDesc is the grpc.ServiceDesc for PVZService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -103,6 +138,10 @@ var PVZService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetPVZList",
 			Handler:    _PVZService_GetPVZList_Handler,
 		},
+		{
+			MethodName: "GetNearbyPVZs",
+			Handler:    _PVZService_GetNearbyPVZs_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/pvz/v1/pvz.proto",
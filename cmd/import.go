@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mi4r/avito-pvz/internal/config"
+	"github.com/mi4r/avito-pvz/internal/pvzimport"
+	"github.com/mi4r/avito-pvz/internal/storage"
+)
+
+// importPVZs runs the import-pvz subcommand:
+//
+//	go run ./cmd import-pvz [-dry-run] [-partial] [-format csv|json] file
+//
+// It prints the report as JSON and returns the exit code, 1 if any row
+// failed.
+func importPVZs(args []string) int {
+	fs := flag.NewFlagSet("import-pvz", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "check the file without storing anything")
+	partial := fs.Bool("partial", false, "store the valid rows even if others fail")
+	format := fs.String("format", "", "csv or json, by default taken from the file extension")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: import-pvz [-dry-run] [-partial] [-format csv|json] file")
+		return 2
+	}
+
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		log.Printf("Failed to open import file: %v", err)
+		return 1
+	}
+	defer file.Close()
+
+	rows, err := pvzimport.Parse(*format, file)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	cfg := config.NewConfig()
+	db, err := sql.Open("postgres", cfg.GetDSN())
+	if err != nil {
+		log.Printf("Failed to connect to database: %v", err)
+		return 1
+	}
+	defer db.Close()
+	store := storage.NewPostgresStorage(db)
+
+	ctx := context.Background()
+	report, err := store.ImportPVZs(ctx, rows, storage.ImportOptions{DryRun: *dryRun, Partial: *partial})
+	if err != nil {
+		log.Printf("Failed to import PVZs: %v", err)
+		return 1
+	}
+
+	if report.Committed {
+		var entries []storage.AuditEntry
+		for _, row := range report.Rows {
+			if row.PVZ == nil {
+				continue
+			}
+			after, _ := json.Marshal(row.PVZ)
+			entries = append(entries, storage.AuditEntry{
+				ActorRole:  "cli",
+				Action:     "pvz.import",
+				EntityType: "pvz",
+				EntityID:   row.PVZ.ID.String(),
+				After:      after,
+			})
+		}
+		if len(entries) > 0 {
+			if _, err := store.AppendAuditBatch(ctx, entries); err != nil {
+				log.Printf("Failed to audit imported PVZs: %v", err)
+			}
+		}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(report)
+	if report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -3,25 +3,210 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Runtime modes. Dev is the default; prod disables /dummyLogin and
+// staging limits it to DummyLoginAllowlist.
+const (
+	ModeDev     = "dev"
+	ModeStaging = "staging"
+	ModeProd    = "prod"
+)
+
+// Notifiers. The file notifier writes messages to NotifyFile or stdout
+// instead of sending them.
+const (
+	NotifierFile = "file"
+	NotifierSMTP = "smtp"
 )
 
 type Config struct {
+	Mode string
+	// DummyLoginAllowlist lists IPs and CIDRs that may use /dummyLogin in staging
+	DummyLoginAllowlist []string
+
 	DBUser     string
 	DBPassword string
 	DBHost     string
 	DBName     string
 	DBPort     string
 	// DBPath     string
+
+	// JWTKeys is a list of "kid:alg:source" key specs, see token.ParseKey
+	JWTKeys         []string
+	JWTSigningKeyID string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	LoginMaxFailures     int
+	LoginMaxIPFailures   int
+	LoginBackoffBase     time.Duration
+	LoginLockoutDuration time.Duration
+	LoginFailureWindow   time.Duration
+
+	PasswordMinLength     int
+	PasswordMaxLength     int
+	PasswordRequireUpper  bool
+	PasswordRequireLower  bool
+	PasswordRequireDigit  bool
+	PasswordRequireSymbol bool
+	InviteTTL             time.Duration
+	PasswordResetTTL      time.Duration
+	// PasswordResetURL is the reset page, the token is added as ?token=
+	PasswordResetURL string
+	// MFAIssuer is the account issuer shown in authenticator apps
+	MFAIssuer string
+
+	// PasswordHashAlgorithm is argon2id or bcrypt, see password.Hasher
+	PasswordHashAlgorithm    string
+	PasswordBcryptCost       int
+	PasswordArgon2Memory     int
+	PasswordArgon2Iterations int
+	PasswordArgon2Threads    int
+
+	// OIDCIssuer enables single sign-on when set
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
+	OIDCScopes       []string
+	OIDCGroupsClaim  string
+	// OIDCGroupRoles is a list of "group:role" pairs, the first match wins
+	OIDCGroupRoles []string
+	OIDCStateTTL   time.Duration
+
+	Notifier     string
+	NotifyFile   string
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
 }
 
 func NewConfig() Config {
 	return Config{
+		// An unset APP_MODE must not expose /dummyLogin, so the default
+		// is the strictest mode
+		Mode:                strings.ToLower(getEnv("APP_MODE", ModeProd)),
+		DummyLoginAllowlist: splitList(os.Getenv("DUMMY_LOGIN_ALLOWLIST")),
+
 		DBUser:     os.Getenv("DATABASE_USER"),
 		DBPassword: os.Getenv("DATABASE_PASSWORD"),
 		DBHost:     os.Getenv("DATABASE_HOST"),
 		DBName:     os.Getenv("DATABASE_NAME"),
 		DBPort:     os.Getenv("DATABASE_PORT"),
+
+		JWTKeys:         splitList(os.Getenv("JWT_KEYS")),
+		JWTSigningKeyID: os.Getenv("JWT_SIGNING_KEY_ID"),
+		AccessTokenTTL:  getDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		LoginMaxFailures:     getInt("LOGIN_MAX_FAILURES", 5),
+		LoginMaxIPFailures:   getInt("LOGIN_MAX_IP_FAILURES", 20),
+		LoginBackoffBase:     getDuration("LOGIN_BACKOFF_BASE", time.Second),
+		LoginLockoutDuration: getDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		LoginFailureWindow:   getDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+
+		PasswordMinLength:     getInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordMaxLength:     getInt("PASSWORD_MAX_LENGTH", 72),
+		PasswordRequireUpper:  getBool("PASSWORD_REQUIRE_UPPER", false),
+		PasswordRequireLower:  getBool("PASSWORD_REQUIRE_LOWER", false),
+		PasswordRequireDigit:  getBool("PASSWORD_REQUIRE_DIGIT", true),
+		PasswordRequireSymbol: getBool("PASSWORD_REQUIRE_SYMBOL", false),
+		InviteTTL:             getDuration("INVITE_TTL", 72*time.Hour),
+		PasswordResetTTL:      getDuration("PASSWORD_RESET_TTL", time.Hour),
+		PasswordResetURL:      os.Getenv("PASSWORD_RESET_URL"),
+		MFAIssuer:             getEnv("MFA_ISSUER", "avito-pvz"),
+
+		PasswordHashAlgorithm:    strings.ToLower(getEnv("PASSWORD_HASH_ALGORITHM", "argon2id")),
+		PasswordBcryptCost:       getInt("PASSWORD_BCRYPT_COST", 10),
+		PasswordArgon2Memory:     getInt("PASSWORD_ARGON2_MEMORY", 64*1024),
+		PasswordArgon2Iterations: getInt("PASSWORD_ARGON2_ITERATIONS", 3),
+		PasswordArgon2Threads:    getInt("PASSWORD_ARGON2_THREADS", 2),
+
+		OIDCIssuer:       os.Getenv("OIDC_ISSUER"),
+		OIDCClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		OIDCClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		OIDCScopes:       splitList(getEnv("OIDC_SCOPES", "email,profile")),
+		OIDCGroupsClaim:  getEnv("OIDC_GROUPS_CLAIM", "groups"),
+		OIDCGroupRoles:   splitList(os.Getenv("OIDC_GROUP_ROLES")),
+		OIDCStateTTL:     getDuration("OIDC_STATE_TTL", 10*time.Minute),
+
+		Notifier:     strings.ToLower(getEnv("NOTIFIER", NotifierFile)),
+		NotifyFile:   os.Getenv("NOTIFY_FILE"),
+		SMTPAddr:     os.Getenv("SMTP_ADDR"),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:     os.Getenv("SMTP_FROM"),
+	}
+}
+
+func getInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// Validate reports settings that would make the service run in an
+// unintended mode.
+func (c *Config) Validate() error {
+	switch c.Mode {
+	case ModeDev, ModeStaging, ModeProd:
+	default:
+		return fmt.Errorf("unknown APP_MODE %q, expected %s, %s or %s", c.Mode, ModeDev, ModeStaging, ModeProd)
+	}
+	if c.OIDCIssuer != "" && (c.OIDCClientID == "" || c.OIDCRedirectURL == "" || len(c.OIDCGroupRoles) == 0) {
+		return fmt.Errorf("OIDC_ISSUER requires OIDC_CLIENT_ID, OIDC_REDIRECT_URL and OIDC_GROUP_ROLES")
+	}
+	switch c.Notifier {
+	case "", NotifierFile:
+	case NotifierSMTP:
+		if c.SMTPAddr == "" || c.SMTPFrom == "" {
+			return fmt.Errorf("NOTIFIER=smtp requires SMTP_ADDR and SMTP_FROM")
+		}
+	default:
+		return fmt.Errorf("unknown NOTIFIER %q, expected %s or %s", c.Notifier, NotifierFile, NotifierSMTP)
+	}
+	return nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+func getBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
 
 func (c *Config) GetDSN() string {
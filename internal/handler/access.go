@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/rbac"
+)
+
+// principal returns the caller as loaded by the auth middleware. Requests
+// that didn't pass it get a zero Principal without any permissions.
+func principal(r *http.Request) rbac.Principal {
+	p, _ := rbac.PrincipalFromContext(r.Context())
+	return p
+}
+
+// actorID returns the user a write is attributed to, or nil for dummy
+// tokens and API keys.
+func actorID(r *http.Request) *uuid.UUID {
+	p := principal(r)
+	if !p.IsUser() {
+		return nil
+	}
+	return &p.UserID
+}
+
+// assignedPVZs returns the PVZs the caller is assigned to.
+func assignedPVZs(r *http.Request) []uuid.UUID {
+	pvzIDs := principal(r).PVZIDs
+	if pvzIDs == nil {
+		return []uuid.UUID{}
+	}
+	return pvzIDs
+}
+
+// pvzAccessible reports whether the caller may work with the PVZ.
+// Callers without pvz:access_all are limited to the PVZs they are
+// assigned to.
+func pvzAccessible(r *http.Request, pvzID uuid.UUID) bool {
+	if principal(r).HasPermission(rbac.PVZAccessAll) {
+		return true
+	}
+	return slices.Contains(assignedPVZs(r), pvzID)
+}
@@ -0,0 +1,302 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/lockout"
+	"github.com/mi4r/avito-pvz/internal/password"
+	"github.com/mi4r/avito-pvz/internal/storage"
+)
+
+func ListUsers(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		page, _ := strconv.Atoi(query.Get("page"))
+		if page < 1 {
+			page = 1
+		}
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		if limit < 1 || limit > 100 {
+			limit = 20
+		}
+
+		filter := storage.UserFilter{
+			Query: query.Get("q"),
+			Role:  query.Get("role"),
+			Page:  page,
+			Limit: limit,
+		}
+		if v := query.Get("disabled"); v != "" {
+			disabled, err := strconv.ParseBool(v)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, "invalid disabled filter")
+				return
+			}
+			filter.Disabled = &disabled
+		}
+
+		users, err := db.ListUsers(r.Context(), filter)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to list users")
+			return
+		}
+		respondJSON(w, http.StatusOK, users)
+	}
+}
+
+// CreateUser lets admins create accounts of any role, including the ones
+// that can't self-register.
+func CreateUser(db storage.Storage, policy password.Policy, hasher password.Hasher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Email    string `json:"email"`
+			Password string `json:"password"`
+			Role     string `json:"role"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Role == "" {
+			respondError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+
+		email, hash, ok := prepareCredentials(w, req.Email, req.Password, policy, hasher)
+		if !ok {
+			return
+		}
+
+		user, err := db.CreateUser(r.Context(), email, hash, req.Role)
+		if err != nil {
+			respondCreateUserError(w, err)
+			return
+		}
+		recordAudit(r, db, "user.create", auditUser, user.ID.String(), nil, user)
+		respondJSON(w, http.StatusCreated, user)
+	}
+}
+
+func GetUser(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(chi.URLParam(r, "userId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid user id")
+			return
+		}
+
+		user, err := db.GetUserByID(r.Context(), userID)
+		if err != nil {
+			respondUserError(w, err, "failed to get user")
+			return
+		}
+		respondJSON(w, http.StatusOK, user)
+	}
+}
+
+func UpdateUserRole(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := targetUser(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Role string `json:"role"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Role == "" {
+			respondError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+
+		before, ok := auditedUser(w, r, db, userID, "failed to update role")
+		if !ok {
+			return
+		}
+		if err := db.UpdateUserRole(r.Context(), userID, req.Role); err != nil {
+			if errors.Is(err, storage.ErrInvalidRole) {
+				respondError(w, http.StatusBadRequest, "invalid role")
+				return
+			}
+			respondUserError(w, err, "failed to update role")
+			return
+		}
+		after := before
+		after.Role = req.Role
+		recordAudit(r, db, "user.update_role", auditUser, userID.String(), before, after)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// DisableUser blocks the account and revokes its refresh tokens in the
+// same transaction. Access tokens stop working too, because the auth
+// middleware checks the account.
+func DisableUser(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := targetUser(w, r)
+		if !ok {
+			return
+		}
+
+		before, ok := auditedUser(w, r, db, userID, "failed to disable user")
+		if !ok {
+			return
+		}
+		if err := db.SetUserDisabled(r.Context(), userID, true); err != nil {
+			respondUserError(w, err, "failed to disable user")
+			return
+		}
+		after := before
+		now := time.Now()
+		after.DisabledAt = &now
+		recordAudit(r, db, "user.disable", auditUser, userID.String(), before, after)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func EnableUser(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := targetUser(w, r)
+		if !ok {
+			return
+		}
+
+		before, ok := auditedUser(w, r, db, userID, "failed to enable user")
+		if !ok {
+			return
+		}
+		if err := db.SetUserDisabled(r.Context(), userID, false); err != nil {
+			respondUserError(w, err, "failed to enable user")
+			return
+		}
+		after := before
+		after.DisabledAt = nil
+		recordAudit(r, db, "user.enable", auditUser, userID.String(), before, after)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ForcePasswordReset makes the next login fail until the password is
+// changed and logs the user out of existing sessions.
+func ForcePasswordReset(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := targetUser(w, r)
+		if !ok {
+			return
+		}
+
+		before, ok := auditedUser(w, r, db, userID, "failed to require password reset")
+		if !ok {
+			return
+		}
+		if err := db.SetPasswordResetRequired(r.Context(), userID, true); err != nil {
+			respondUserError(w, err, "failed to require password reset")
+			return
+		}
+		after := before
+		after.PasswordResetRequired = true
+		recordAudit(r, db, "user.force_password_reset", auditUser, userID.String(), before, after)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func DeleteUser(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := targetUser(w, r)
+		if !ok {
+			return
+		}
+
+		before, ok := auditedUser(w, r, db, userID, "failed to delete user")
+		if !ok {
+			return
+		}
+		if err := db.DeleteUser(r.Context(), userID); err != nil {
+			respondUserError(w, err, "failed to delete user")
+			return
+		}
+		recordAudit(r, db, "user.delete", auditUser, userID.String(), before, nil)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// UnlockUser lifts a login lockout of the account. Lockouts of the IPs the
+// attempts came from stay in place, see UnlockIP.
+func UnlockUser(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(chi.URLParam(r, "userId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid user id")
+			return
+		}
+
+		user, err := db.GetUserByID(r.Context(), userID)
+		if err != nil {
+			respondUserError(w, err, "failed to unlock user")
+			return
+		}
+		email := strings.ToLower(strings.TrimSpace(user.Email))
+		if err := db.ClearLoginFailures(r.Context(), lockout.ScopeEmail, email); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to unlock user")
+			return
+		}
+		recordAudit(r, db, "user.unlock", auditUser, userID.String(), nil, nil)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func UnlockIP(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip := net.ParseIP(chi.URLParam(r, "ip"))
+		if ip == nil {
+			respondError(w, http.StatusBadRequest, "invalid ip")
+			return
+		}
+
+		if err := db.ClearLoginFailures(r.Context(), lockout.ScopeIP, ip.String()); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to unlock ip")
+			return
+		}
+		recordAudit(r, db, "ip.unlock", auditLockout, ip.String(), nil, nil)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// targetUser parses the userId URL param of an admin mutation. Admins
+// can't change their own account so they can't lock themselves out.
+func targetUser(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(chi.URLParam(r, "userId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid user id")
+		return uuid.Nil, false
+	}
+	if principal(r).UserID == userID {
+		respondError(w, http.StatusBadRequest, "can't modify own account")
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// auditedUser loads the target of an admin mutation so its state before
+// the change can be written to the audit log.
+func auditedUser(w http.ResponseWriter, r *http.Request, db storage.Storage, userID uuid.UUID, message string) (storage.User, bool) {
+	user, err := db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondUserError(w, err, message)
+		return storage.User{}, false
+	}
+	return user, true
+}
+
+func respondUserError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, storage.ErrNotFound) {
+		respondError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	respondError(w, http.StatusInternalServerError, message)
+}
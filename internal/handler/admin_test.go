@@ -0,0 +1,237 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/handler"
+	"github.com/mi4r/avito-pvz/internal/rbac"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUserAdmin(t *testing.T) {
+	mockRepo := mocks.NewStorage(t)
+	r := chi.NewRouter()
+	r.Get("/admin/users", handler.ListUsers(mockRepo))
+	r.Post("/admin/users", handler.CreateUser(mockRepo, testPolicy, testHasher))
+	r.Get("/admin/users/{userId}", handler.GetUser(mockRepo))
+	r.Patch("/admin/users/{userId}/role", handler.UpdateUserRole(mockRepo))
+	r.Post("/admin/users/{userId}/disable", handler.DisableUser(mockRepo))
+	r.Post("/admin/users/{userId}/enable", handler.EnableUser(mockRepo))
+	r.Post("/admin/users/{userId}/reset_password", handler.ForcePasswordReset(mockRepo))
+	r.Delete("/admin/users/{userId}", handler.DeleteUser(mockRepo))
+	r.Post("/admin/users/{userId}/unlock", handler.UnlockUser(mockRepo))
+	r.Delete("/admin/lockouts/ip/{ip}", handler.UnlockIP(mockRepo))
+
+	adminID := uuid.New()
+	asAdmin := func(req *http.Request) *http.Request {
+		return withPrincipal(req, rbac.Principal{UserID: adminID, Role: "admin", Method: rbac.AuthToken})
+	}
+
+	t.Run("list users with filters", func(t *testing.T) {
+		users := []storage.User{{ID: uuid.New(), Email: "a@example.com", Role: "employee"}}
+		mockRepo.On("ListUsers", mock.Anything, mock.MatchedBy(func(f storage.UserFilter) bool {
+			return f.Query == "example" && f.Role == "employee" && f.Disabled != nil && !*f.Disabled &&
+				f.Page == 2 && f.Limit == 5
+		})).Return(users, nil).Once()
+
+		req := httptest.NewRequest("GET", "/admin/users?q=example&role=employee&disabled=false&page=2&limit=5", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asAdmin(req))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response []storage.User
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Len(t, response, 1)
+		assert.NotContains(t, w.Body.String(), "password")
+	})
+
+	t.Run("list users invalid disabled filter", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/admin/users?disabled=maybe", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asAdmin(req))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("create moderator", func(t *testing.T) {
+		mockRepo.On("CreateUser", mock.Anything, "mod@example.com", mock.Anything, "moderator").
+			Return(storage.User{Email: "mod@example.com", Role: "moderator"}, nil).Once()
+		expectAudit(mockRepo, "user.create")
+
+		body := `{"email":"mod@example.com","password":"password123","role":"moderator"}`
+		req := httptest.NewRequest("POST", "/admin/users", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asAdmin(req))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("create user without role", func(t *testing.T) {
+		body := `{"email":"mod@example.com","password":"password123"}`
+		req := httptest.NewRequest("POST", "/admin/users", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asAdmin(req))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("get unknown user", func(t *testing.T) {
+		userID := uuid.New()
+		mockRepo.On("GetUserByID", mock.Anything, userID).Return(storage.User{}, storage.ErrNotFound).Once()
+
+		req := httptest.NewRequest("GET", "/admin/users/"+userID.String(), nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asAdmin(req))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("change role", func(t *testing.T) {
+		userID := uuid.New()
+		mockRepo.On("GetUserByID", mock.Anything, userID).Return(storage.User{ID: userID, Role: "employee"}, nil).Once()
+		mockRepo.On("UpdateUserRole", mock.Anything, userID, "moderator").Return(nil).Once()
+		mockRepo.On("AppendAudit", mock.Anything, mock.MatchedBy(func(e storage.AuditEntry) bool {
+			return e.Action == "user.update_role" && e.EntityID == userID.String() &&
+				strings.Contains(string(e.Before), `"role":"employee"`) && strings.Contains(string(e.After), `"role":"moderator"`)
+		})).Return(storage.AuditEntry{}, nil).Once()
+
+		req := httptest.NewRequest("PATCH", "/admin/users/"+userID.String()+"/role", bytes.NewBufferString(`{"role":"moderator"}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asAdmin(req))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("change to unknown role", func(t *testing.T) {
+		userID := uuid.New()
+		mockRepo.On("GetUserByID", mock.Anything, userID).Return(storage.User{ID: userID, Role: "employee"}, nil).Once()
+		mockRepo.On("UpdateUserRole", mock.Anything, userID, "root").Return(storage.ErrInvalidRole).Once()
+
+		req := httptest.NewRequest("PATCH", "/admin/users/"+userID.String()+"/role", bytes.NewBufferString(`{"role":"root"}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asAdmin(req))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("disable revokes refresh tokens", func(t *testing.T) {
+		userID := uuid.New()
+		mockRepo.On("GetUserByID", mock.Anything, userID).Return(storage.User{ID: userID}, nil).Once()
+		mockRepo.On("SetUserDisabled", mock.Anything, userID, true).Return(nil).Once()
+		expectAudit(mockRepo, "user.disable")
+
+		req := httptest.NewRequest("POST", "/admin/users/"+userID.String()+"/disable", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asAdmin(req))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("disable is not audited when revoking tokens fails", func(t *testing.T) {
+		userID := uuid.New()
+		mockRepo.On("GetUserByID", mock.Anything, userID).Return(storage.User{ID: userID}, nil).Once()
+		mockRepo.On("SetUserDisabled", mock.Anything, userID, true).Return(errors.New("db down")).Once()
+
+		req := httptest.NewRequest("POST", "/admin/users/"+userID.String()+"/disable", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asAdmin(req))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("enable", func(t *testing.T) {
+		userID := uuid.New()
+		mockRepo.On("GetUserByID", mock.Anything, userID).Return(storage.User{ID: userID}, nil).Once()
+		mockRepo.On("SetUserDisabled", mock.Anything, userID, false).Return(nil).Once()
+		expectAudit(mockRepo, "user.enable")
+
+		req := httptest.NewRequest("POST", "/admin/users/"+userID.String()+"/enable", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asAdmin(req))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("force password reset", func(t *testing.T) {
+		userID := uuid.New()
+		mockRepo.On("GetUserByID", mock.Anything, userID).Return(storage.User{ID: userID}, nil).Once()
+		mockRepo.On("SetPasswordResetRequired", mock.Anything, userID, true).Return(nil).Once()
+		expectAudit(mockRepo, "user.force_password_reset")
+
+		req := httptest.NewRequest("POST", "/admin/users/"+userID.String()+"/reset_password", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asAdmin(req))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("delete unknown user", func(t *testing.T) {
+		userID := uuid.New()
+		mockRepo.On("GetUserByID", mock.Anything, userID).Return(storage.User{}, storage.ErrNotFound).Once()
+
+		req := httptest.NewRequest("DELETE", "/admin/users/"+userID.String(), nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asAdmin(req))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("can't modify own account", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/admin/users/"+adminID.String()+"/disable", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asAdmin(req))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unlock user", func(t *testing.T) {
+		userID := uuid.New()
+		mockRepo.On("GetUserByID", mock.Anything, userID).Return(storage.User{ID: userID, Email: "Locked@Example.com"}, nil).Once()
+		mockRepo.On("ClearLoginFailures", mock.Anything, "email", "locked@example.com").Return(nil).Once()
+		expectAudit(mockRepo, "user.unlock")
+
+		req := httptest.NewRequest("POST", "/admin/users/"+userID.String()+"/unlock", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asAdmin(req))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("unlock ip", func(t *testing.T) {
+		mockRepo.On("ClearLoginFailures", mock.Anything, "ip", "2001:db8::1").Return(nil).Once()
+		expectAudit(mockRepo, "ip.unlock")
+
+		req := httptest.NewRequest("DELETE", "/admin/lockouts/ip/2001:DB8:0::1", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asAdmin(req))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("unlock invalid ip", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/admin/lockouts/ip/not-an-ip", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asAdmin(req))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid user id", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/admin/users/not-a-uuid", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asAdmin(req))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/storage"
+)
+
+func GetUserPVZs(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(chi.URLParam(r, "userId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid user id")
+			return
+		}
+
+		pvzIDs, err := db.GetUserPVZIDs(r.Context(), userID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to get assignments")
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string][]uuid.UUID{"pvzIds": pvzIDs})
+	}
+}
+
+func AssignUserPVZ(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(chi.URLParam(r, "userId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid user id")
+			return
+		}
+
+		var req struct {
+			PVZID uuid.UUID `json:"pvzId"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+
+		if err := db.AssignUserToPVZ(r.Context(), userID, req.PVZID); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				respondError(w, http.StatusNotFound, "user or PVZ not found")
+				return
+			}
+			respondError(w, http.StatusInternalServerError, "failed to assign PVZ")
+			return
+		}
+		recordAudit(r, db, "assignment.create", auditAssignment, userID.String(), nil,
+			map[string]uuid.UUID{"userId": userID, "pvzId": req.PVZID})
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func UnassignUserPVZ(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := uuid.Parse(chi.URLParam(r, "userId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid user id")
+			return
+		}
+		pvzID, err := uuid.Parse(chi.URLParam(r, "pvzId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid pvz id")
+			return
+		}
+
+		if err := db.UnassignUserFromPVZ(r.Context(), userID, pvzID); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				respondError(w, http.StatusNotFound, "assignment not found")
+				return
+			}
+			respondError(w, http.StatusInternalServerError, "failed to unassign PVZ")
+			return
+		}
+		recordAudit(r, db, "assignment.delete", auditAssignment, userID.String(),
+			map[string]uuid.UUID{"userId": userID, "pvzId": pvzID}, nil)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,83 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/handler"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUserPVZAssignments(t *testing.T) {
+	mockRepo := mocks.NewStorage(t)
+	r := chi.NewRouter()
+	r.Get("/users/{userId}/pvz", handler.GetUserPVZs(mockRepo))
+	r.Post("/users/{userId}/pvz", handler.AssignUserPVZ(mockRepo))
+	r.Delete("/users/{userId}/pvz/{pvzId}", handler.UnassignUserPVZ(mockRepo))
+
+	t.Run("list assignments", func(t *testing.T) {
+		userID, pvzID := uuid.New(), uuid.New()
+		mockRepo.On("GetUserPVZIDs", mock.Anything, userID).Return([]uuid.UUID{pvzID}, nil)
+
+		req := httptest.NewRequest("GET", "/users/"+userID.String()+"/pvz", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string][]uuid.UUID
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, []uuid.UUID{pvzID}, response["pvzIds"])
+	})
+
+	t.Run("assign", func(t *testing.T) {
+		userID, pvzID := uuid.New(), uuid.New()
+		mockRepo.On("AssignUserToPVZ", mock.Anything, userID, pvzID).Return(nil)
+		expectAudit(mockRepo, "assignment.create")
+
+		body := []byte(`{"pvzId":"` + pvzID.String() + `"}`)
+		req := httptest.NewRequest("POST", "/users/"+userID.String()+"/pvz", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("assign unknown user or pvz", func(t *testing.T) {
+		userID, pvzID := uuid.New(), uuid.New()
+		mockRepo.On("AssignUserToPVZ", mock.Anything, userID, pvzID).Return(storage.ErrNotFound)
+
+		body := []byte(`{"pvzId":"` + pvzID.String() + `"}`)
+		req := httptest.NewRequest("POST", "/users/"+userID.String()+"/pvz", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("unassign missing assignment", func(t *testing.T) {
+		userID, pvzID := uuid.New(), uuid.New()
+		mockRepo.On("UnassignUserFromPVZ", mock.Anything, userID, pvzID).Return(storage.ErrNotFound)
+
+		req := httptest.NewRequest("DELETE", "/users/"+userID.String()+"/pvz/"+pvzID.String(), nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid user id", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/users/invalid/pvz", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/metrics"
+	"github.com/mi4r/avito-pvz/internal/middleware"
+	"github.com/mi4r/avito-pvz/internal/storage"
+)
+
+// Audited entity types
+const (
+	auditPVZ            = "pvz"
+	auditCity           = "city"
+	auditHoliday        = "holiday"
+	auditReception      = "reception"
+	auditProduct        = "product"
+	auditUser           = "user"
+	auditAssignment     = "assignment"
+	auditRole           = "role"
+	auditLockout        = "lockout"
+	auditInvite         = "invite"
+	auditServiceAccount = "service_account"
+	auditAPIKey         = "api_key"
+)
+
+// recordAudit appends a mutation to the audit log. It's called after the
+// change is stored, so a failure is counted but doesn't fail the request.
+// before and after are encoded as JSON, nil leaves them empty.
+func recordAudit(r *http.Request, db storage.Storage, action, entityType, entityID string, before, after any) {
+	entry, err := auditEntry(r, action, entityType, entityID, before, after)
+	if err != nil {
+		metrics.AuditFailures.Inc()
+		return
+	}
+	if _, err := db.AppendAudit(r.Context(), entry); err != nil {
+		metrics.AuditFailures.Inc()
+	}
+}
+
+// auditEntry describes a mutation made by the caller of r.
+func auditEntry(r *http.Request, action, entityType, entityID string, before, after any) (storage.AuditEntry, error) {
+	p := principal(r)
+	entry := storage.AuditEntry{
+		ActorRole:  p.Role,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		RequestID:  chimiddleware.GetReqID(r.Context()),
+		IP:         middleware.ClientIP(r),
+	}
+	if p.IsUser() {
+		entry.ActorID = &p.UserID
+	}
+	if p.ServiceAccountID != uuid.Nil {
+		entry.ServiceAccountID = &p.ServiceAccountID
+	}
+
+	var err error
+	if entry.Before, err = auditJSON(before); err != nil {
+		return storage.AuditEntry{}, err
+	}
+	if entry.After, err = auditJSON(after); err != nil {
+		return storage.AuditEntry{}, err
+	}
+	return entry, nil
+}
+
+func auditJSON(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+func ListAudit(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		if limit < 1 || limit > 100 {
+			limit = 50
+		}
+
+		filter := storage.AuditFilter{
+			Action:     query.Get("action"),
+			EntityType: query.Get("entityType"),
+			EntityID:   query.Get("entityId"),
+			Limit:      limit,
+		}
+		if v := query.Get("actorId"); v != "" {
+			actorID, err := uuid.Parse(v)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, "invalid actor id")
+				return
+			}
+			filter.ActorID = &actorID
+		}
+		if v := query.Get("cursor"); v != "" {
+			cursor, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || cursor < 1 {
+				respondError(w, http.StatusBadRequest, "invalid cursor")
+				return
+			}
+			filter.Cursor = cursor
+		}
+		for name, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+			if v := query.Get(name); v != "" {
+				t, err := time.Parse(time.RFC3339, v)
+				if err != nil {
+					respondError(w, http.StatusBadRequest, "invalid "+name+" date")
+					return
+				}
+				t = t.UTC()
+				*dst = &t
+			}
+		}
+
+		entries, err := db.ListAuditEntries(r.Context(), filter)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to list audit entries")
+			return
+		}
+
+		resp := struct {
+			Entries    []storage.AuditEntry `json:"entries"`
+			NextCursor string               `json:"nextCursor,omitempty"`
+		}{Entries: entries}
+		if len(entries) == limit {
+			resp.NextCursor = strconv.FormatInt(entries[len(entries)-1].ID, 10)
+		}
+		respondJSON(w, http.StatusOK, resp)
+	}
+}
+
+// VerifyAudit recomputes the hash chain of the whole audit log.
+func VerifyAudit(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result, err := db.VerifyAuditLog(r.Context())
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to verify audit log")
+			return
+		}
+		respondJSON(w, http.StatusOK, result)
+	}
+}
@@ -0,0 +1,177 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/handler"
+	"github.com/mi4r/avito-pvz/internal/rbac"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// expectAudit expects one audit entry with the action
+func expectAudit(m *mocks.Storage, action string) *mock.Call {
+	return m.On("AppendAudit", mock.Anything, mock.MatchedBy(func(e storage.AuditEntry) bool {
+		return e.Action == action
+	})).Return(storage.AuditEntry{}, nil).Once()
+}
+
+func TestRecordAudit(t *testing.T) {
+	t.Run("entry describes the caller and the change", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		userID, pvzID, receptionID := uuid.New(), uuid.New(), uuid.New()
+		reception := storage.Reception{ID: receptionID, PVZID: pvzID, Status: "in_progress"}
+
+		mockRepo.On("GetOpenReception", mock.Anything, pvzID).Return(reception, nil).Once()
+		mockRepo.On("CloseReception", mock.Anything, receptionID, &userID).Return(nil).Once()
+		var entry storage.AuditEntry
+		mockRepo.On("AppendAudit", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { entry = args.Get(1).(storage.AuditEntry) }).
+			Return(storage.AuditEntry{}, nil).Once()
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("pvzId", pvzID.String())
+		req := httptest.NewRequest("POST", "/pvz/"+pvzID.String()+"/close_last_reception", nil)
+		req.RemoteAddr = "192.0.2.10:5555"
+		req = withUser(withEmployee(req, pvzID), userID)
+		ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+		ctx = context.WithValue(ctx, chimiddleware.RequestIDKey, "req-42")
+		w := httptest.NewRecorder()
+
+		handler.CloseLastReception(mockRepo)(w, req.WithContext(ctx))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "reception.close", entry.Action)
+		assert.Equal(t, "reception", entry.EntityType)
+		assert.Equal(t, receptionID.String(), entry.EntityID)
+		assert.Equal(t, &userID, entry.ActorID)
+		assert.Nil(t, entry.ServiceAccountID)
+		assert.Equal(t, rbac.RoleEmployee, entry.ActorRole)
+		assert.Equal(t, "req-42", entry.RequestID)
+		assert.Equal(t, "192.0.2.10", entry.IP)
+
+		var before, after storage.Reception
+		require.NoError(t, json.Unmarshal(entry.Before, &before))
+		require.NoError(t, json.Unmarshal(entry.After, &after))
+		assert.Equal(t, "in_progress", before.Status)
+		assert.Nil(t, before.ClosedBy)
+		assert.Equal(t, "closed", after.Status)
+		assert.Equal(t, &userID, after.ClosedBy)
+	})
+
+	t.Run("service account is recorded", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		accountID := uuid.New()
+		pvz := storage.PVZ{ID: uuid.New(), City: "Москва"}
+
+		mockRepo.On("CreatePVZ", mock.Anything, storage.PVZ{City: "Москва"}).Return(pvz, nil).Once()
+		mockRepo.On("AppendAudit", mock.Anything, mock.MatchedBy(func(e storage.AuditEntry) bool {
+			return e.ActorID == nil && e.ServiceAccountID != nil && *e.ServiceAccountID == accountID &&
+				e.ActorRole == rbac.RoleServiceAccount && e.Before == nil && e.After != nil
+		})).Return(storage.AuditEntry{}, nil).Once()
+
+		req := httptest.NewRequest("POST", "/pvz", strings.NewReader(`{"city":"Москва"}`))
+		req = withPrincipal(req, rbac.Principal{ServiceAccountID: accountID, Role: rbac.RoleServiceAccount, Method: rbac.AuthAPIKey})
+		w := httptest.NewRecorder()
+
+		handler.CreatePVZ(mockRepo)(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("audit failure doesn't fail the request", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		pvz := storage.PVZ{ID: uuid.New(), City: "Казань"}
+
+		mockRepo.On("CreatePVZ", mock.Anything, storage.PVZ{City: "Казань"}).Return(pvz, nil).Once()
+		mockRepo.On("AppendAudit", mock.Anything, mock.Anything).Return(storage.AuditEntry{}, errors.New("db down")).Once()
+
+		req := httptest.NewRequest("POST", "/pvz", strings.NewReader(`{"city":"Казань"}`))
+		w := httptest.NewRecorder()
+
+		handler.CreatePVZ(mockRepo)(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+}
+
+func TestListAudit(t *testing.T) {
+	actorID := uuid.New()
+
+	t.Run("filters and next cursor", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		mockRepo.On("ListAuditEntries", mock.Anything, mock.MatchedBy(func(f storage.AuditFilter) bool {
+			return *f.ActorID == actorID && f.Action == "product.delete" && f.EntityType == "product" &&
+				f.From.Equal(from) && f.To == nil && f.Cursor == 100 && f.Limit == 2
+		})).Return([]storage.AuditEntry{{ID: 99}, {ID: 97}}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/audit?actorId="+actorID.String()+
+			"&action=product.delete&entityType=product&from=2024-01-01T00:00:00Z&cursor=100&limit=2", nil)
+		w := httptest.NewRecorder()
+
+		handler.ListAudit(mockRepo)(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Entries    []storage.AuditEntry `json:"entries"`
+			NextCursor string               `json:"nextCursor"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Len(t, resp.Entries, 2)
+		assert.Equal(t, "97", resp.NextCursor)
+	})
+
+	t.Run("last page has no cursor", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("ListAuditEntries", mock.Anything, mock.MatchedBy(func(f storage.AuditFilter) bool {
+			return f.ActorID == nil && f.Cursor == 0 && f.Limit == 50
+		})).Return([]storage.AuditEntry{{ID: 1}}, nil).Once()
+
+		w := httptest.NewRecorder()
+		handler.ListAudit(mockRepo)(w, httptest.NewRequest("GET", "/audit", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "nextCursor")
+	})
+
+	for name, query := range map[string]string{
+		"invalid actor id": "actorId=nope",
+		"invalid cursor":   "cursor=-1",
+		"invalid to date":  "to=yesterday",
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ListAudit(mocks.NewStorage(t))(w, httptest.NewRequest("GET", "/audit?"+query, nil))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), name)
+		})
+	}
+}
+
+func TestVerifyAudit(t *testing.T) {
+	mockRepo := mocks.NewStorage(t)
+	brokenAt := int64(7)
+	mockRepo.On("VerifyAuditLog", mock.Anything).
+		Return(storage.AuditVerification{Valid: false, Checked: 7, BrokenAt: &brokenAt}, nil).Once()
+
+	w := httptest.NewRecorder()
+	handler.VerifyAudit(mockRepo)(w, httptest.NewRequest("GET", "/audit/verify", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"valid":false,"checked":7,"brokenAt":7}`, w.Body.String())
+}
@@ -1,13 +1,21 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
-	"time"
+	"net/mail"
+	"strconv"
+	"strings"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/mi4r/avito-pvz/internal/lockout"
+	"github.com/mi4r/avito-pvz/internal/middleware"
+	"github.com/mi4r/avito-pvz/internal/password"
+	"github.com/mi4r/avito-pvz/internal/rbac"
 	"github.com/mi4r/avito-pvz/internal/storage"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/mi4r/avito-pvz/internal/token"
 )
 
 func respondJSON(w http.ResponseWriter, status int, data any) {
@@ -20,7 +28,7 @@ func respondError(w http.ResponseWriter, code int, message string) {
 	respondJSON(w, code, map[string]string{"error": message})
 }
 
-func DummyLogin() http.HandlerFunc {
+func DummyLogin(issuer *token.Issuer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req struct {
 			Role string `json:"role"`
@@ -29,75 +37,237 @@ func DummyLogin() http.HandlerFunc {
 			respondError(w, http.StatusBadRequest, "invalid request")
 			return
 		}
-		if req.Role != "employee" && req.Role != "moderator" {
+		if req.Role != rbac.RoleEmployee && req.Role != rbac.RoleModerator {
 			respondError(w, http.StatusBadRequest, "invalid role")
 			return
 		}
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-			"role": req.Role,
-			"exp":  time.Now().Add(72 * time.Hour).Unix(),
-		})
-		tokenStr, _ := token.SignedString([]byte("secret"))
+		tokenStr, _, err := issuer.DummyToken(req.Role)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to sign token")
+			return
+		}
 		respondJSON(w, http.StatusOK, map[string]string{"token": tokenStr})
 	}
 }
 
-func Register(db storage.Storage) http.HandlerFunc {
+func Register(db storage.Storage, policy password.Policy, hasher password.Hasher) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req struct {
-			Email    string `json:"email"`
-			Password string `json:"password"`
-			Role     string `json:"role"`
+			Email       string `json:"email"`
+			Password    string `json:"password"`
+			Role        string `json:"role"`
+			InviteToken string `json:"inviteToken"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			respondError(w, http.StatusBadRequest, "invalid request")
 			return
 		}
 
-		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-		if err != nil {
-			respondError(w, http.StatusInternalServerError, "failed to hash password")
+		email, hash, ok := prepareCredentials(w, req.Email, req.Password, policy, hasher)
+		if !ok {
 			return
 		}
 
-		user, err := db.CreateUser(r.Context(), req.Email, string(hash), req.Role)
+		var user storage.User
+		var err error
+		if req.InviteToken != "" {
+			// The invite decides the role, the one in the request is ignored
+			user, err = db.CreateUserWithInvite(r.Context(), email, hash, token.HashOpaqueToken(req.InviteToken))
+		} else {
+			// Other roles are created by admins or through invites
+			if req.Role != rbac.RoleEmployee {
+				respondError(w, http.StatusForbidden, "role requires an invite")
+				return
+			}
+			user, err = db.CreateUser(r.Context(), email, hash, req.Role)
+		}
 		if err != nil {
-			respondError(w, http.StatusConflict, "user already exists")
+			respondCreateUserError(w, err)
 			return
 		}
 
+		recordAudit(r, db, "user.register", auditUser, user.ID.String(), nil, user)
 		respondJSON(w, http.StatusCreated, user)
 	}
 }
 
-func Login(db storage.Storage) http.HandlerFunc {
+// prepareCredentials validates the email and password of a new account and
+// returns the normalized email and the password hash. On failure it writes
+// the response itself.
+func prepareCredentials(w http.ResponseWriter, email, plain string, policy password.Policy, hasher password.Hasher) (string, string, bool) {
+	email, ok := normalizeEmail(email)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "invalid email")
+		return "", "", false
+	}
+	hash, ok := hashPassword(w, plain, policy, hasher)
+	if !ok {
+		return "", "", false
+	}
+	return email, hash, true
+}
+
+// hashPassword checks a new password against the policy and hashes it. On
+// failure it writes the response itself.
+func hashPassword(w http.ResponseWriter, plain string, policy password.Policy, hasher password.Hasher) (string, bool) {
+	if err := policy.Validate(plain); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+
+	hash, err := hasher.Hash(plain)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to hash password")
+		return "", false
+	}
+	return hash, true
+}
+
+// normalizeEmail accepts a bare address such as "user@example.com" and
+// lowercases it. Display names, missing domains and overlong addresses
+// are rejected.
+func normalizeEmail(email string) (string, bool) {
+	email = strings.TrimSpace(email)
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || len(email) > 255 {
+		return "", false
+	}
+	at := strings.LastIndex(email, "@")
+	if domain := email[at+1:]; !strings.Contains(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", false
+	}
+	return strings.ToLower(email), true
+}
+
+func respondCreateUserError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, storage.ErrUserExists):
+		respondError(w, http.StatusConflict, "user already exists")
+	case errors.Is(err, storage.ErrInvalidRole):
+		respondError(w, http.StatusBadRequest, "invalid role")
+	case errors.Is(err, storage.ErrInvalidInvite):
+		respondError(w, http.StatusBadRequest, "invalid or expired invite")
+	default:
+		respondError(w, http.StatusInternalServerError, "failed to create user")
+	}
+}
+
+func Login(db storage.Storage, issuer *token.Issuer, guard *lockout.Guard, hasher password.Hasher) http.HandlerFunc {
+	// Unknown emails are checked against this hash, so they take as long
+	// as wrong passwords
+	dummyHash, _ := hasher.Hash("dummy password")
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req struct {
 			Email    string `json:"email"`
 			Password string `json:"password"`
+			Device   string `json:"device"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			respondError(w, http.StatusBadRequest, "invalid request")
 			return
 		}
 
-		user, err := db.GetUserByEmail(r.Context(), req.Email)
-		if err != nil {
+		// No account can have an email that fails validation, and such keys
+		// wouldn't fit the lockout table either
+		email, ok := normalizeEmail(req.Email)
+		if !ok {
+			hasher.Verify(req.Password, dummyHash)
 			respondError(w, http.StatusUnauthorized, "invalid credentials")
 			return
 		}
+		ip := middleware.ClientIP(r)
+
+		wait, err := guard.Check(r.Context(), email, ip)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to login")
+			return
+		}
+		if wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			respondError(w, http.StatusTooManyRequests, "too many login attempts")
+			return
+		}
 
-		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		// Unknown emails count as failures too, so probing for accounts
+		// runs into the same limits
+		user, err := db.GetUserByEmail(r.Context(), email)
+		switch {
+		case err == nil:
+			err = hasher.Verify(req.Password, user.PasswordHash)
+		case errors.Is(err, storage.ErrNotFound):
+			hasher.Verify(req.Password, dummyHash)
+		default:
+			respondError(w, http.StatusInternalServerError, "failed to login")
+			return
+		}
+		if err != nil {
+			if err := guard.Fail(r.Context(), email, ip); err != nil {
+				respondError(w, http.StatusInternalServerError, "failed to login")
+				return
+			}
 			respondError(w, http.StatusUnauthorized, "invalid credentials")
 			return
 		}
 
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-			"sub":  user.ID.String(),
-			"role": user.Role,
-			"exp":  time.Now().Add(72 * time.Hour).Unix(),
-		})
-		tokenStr, _ := token.SignedString([]byte("secret"))
-		respondJSON(w, http.StatusOK, map[string]string{"token": tokenStr})
+		if err := guard.Succeed(r.Context(), email); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to login")
+			return
+		}
+		rehashPassword(r.Context(), db, hasher, user, req.Password)
+
+		if user.DisabledAt != nil {
+			respondError(w, http.StatusForbidden, "user is disabled")
+			return
+		}
+		if user.PasswordResetRequired {
+			respondError(w, http.StatusForbidden, "password reset required")
+			return
+		}
+
+		challenge, err := mfaChallengeFor(r.Context(), db, issuer, user)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to login")
+			return
+		}
+		if challenge != nil {
+			respondJSON(w, http.StatusOK, challenge)
+			return
+		}
+
+		if err := db.UpdateLastLogin(r.Context(), user.ID); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to login")
+			return
+		}
+
+		tokens, err := startSession(r, db, issuer, user, req.Device)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to issue token")
+			return
+		}
+		respondJSON(w, http.StatusOK, tokens)
+	}
+}
+
+// rehashPassword upgrades a hash made with an older algorithm or costs
+// while the plain password is at hand. The old hash keeps working, so a
+// failed rehash is simply retried on the next login.
+func rehashPassword(ctx context.Context, db storage.Storage, hasher password.Hasher, user storage.User, plain string) {
+	if !hasher.NeedsRehash(user.PasswordHash) {
+		return
+	}
+	hash, err := hasher.Hash(plain)
+	if err != nil {
+		return
+	}
+	db.UpdatePasswordHash(ctx, user.ID, user.PasswordHash, hash)
+}
+
+// JWKS publishes the public verification keys so other services can
+// validate tokens issued by this one.
+func JWKS(keys *token.KeySet) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		respondJSON(w, http.StatusOK, keys.JWKS())
 	}
 }
@@ -2,33 +2,57 @@ package handler_test
 
 import (
 	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/mi4r/avito-pvz/internal/handler"
+	"github.com/mi4r/avito-pvz/internal/lockout"
+	"github.com/mi4r/avito-pvz/internal/metrics"
+	"github.com/mi4r/avito-pvz/internal/password"
 	"github.com/mi4r/avito-pvz/internal/storage"
 	"github.com/mi4r/avito-pvz/internal/storage/mocks"
+	"github.com/mi4r/avito-pvz/internal/token"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
 )
 
 var (
-	ErrUserExists = errors.New("user already exists")
-	ErrNotFound   = errors.New("not found")
+	ErrNotFound = errors.New("not found")
 )
 
+func testKeySet(t *testing.T) *token.KeySet {
+	keys, err := token.NewKeySet("test", token.NewHMACKey("test", []byte("test-secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func testIssuer(t *testing.T) *token.Issuer {
+	return token.NewIssuer(testKeySet(t), 15*time.Minute, 24*time.Hour)
+}
+
 func TestDummyLogin(t *testing.T) {
+	issuer := testIssuer(t)
+	keys := issuer.Keys()
+
 	t.Run("success employee", func(t *testing.T) {
 		reqBody := []byte(`{"role":"employee"}`)
 		req := httptest.NewRequest("POST", "/dummyLogin", bytes.NewBuffer(reqBody))
 		w := httptest.NewRecorder()
 
-		handler.DummyLogin()(w, req)
+		handler.DummyLogin(issuer)(w, req)
 
 		resp := w.Result()
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
@@ -39,11 +63,11 @@ func TestDummyLogin(t *testing.T) {
 		assert.NotEmpty(t, token)
 
 		claims := jwt.MapClaims{}
-		_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte("secret"), nil
-		})
+		parsed, err := keys.Parse(token, claims)
 		assert.NoError(t, err)
+		assert.Equal(t, "test", parsed.Header["kid"])
 		assert.Equal(t, "employee", claims["role"])
+		assert.Equal(t, true, claims["dummy"])
 	})
 
 	t.Run("invalid role", func(t *testing.T) {
@@ -51,7 +75,7 @@ func TestDummyLogin(t *testing.T) {
 		req := httptest.NewRequest("POST", "/dummyLogin", bytes.NewBuffer(reqBody))
 		w := httptest.NewRecorder()
 
-		handler.DummyLogin()(w, req)
+		handler.DummyLogin(issuer)(w, req)
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
@@ -60,30 +84,43 @@ func TestDummyLogin(t *testing.T) {
 		req := httptest.NewRequest("POST", "/dummyLogin", bytes.NewBuffer([]byte("{")))
 		w := httptest.NewRecorder()
 
-		handler.DummyLogin()(w, req)
+		handler.DummyLogin(issuer)(w, req)
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 }
 
+var testPolicy = password.Policy{MinLength: 8, MaxLength: 72, RequireDigit: true}
+
+var testHasher = password.Hasher{Algorithm: password.Bcrypt, BcryptCost: bcrypt.MinCost}
+
 func TestRegister(t *testing.T) {
 	mockRepo := mocks.NewStorage(t)
-	handler := handler.Register(mockRepo)
+	handler := handler.Register(mockRepo, testPolicy, testHasher)
 
 	t.Run("success registration", func(t *testing.T) {
 		reqBody := []byte(`{
-			"email": "test@example.com",
+			"email": " Test@Example.com ",
 			"password": "password123",
 			"role": "employee"
 		}`)
 
 		expectedUser := storage.User{
-			Email: "test@example.com",
-			Role:  "employee",
+			ID:           uuid.New(),
+			Email:        "test@example.com",
+			PasswordHash: "stored-hash",
+			Role:         "employee",
 		}
 
 		mockRepo.On("CreateUser", mock.Anything, "test@example.com", mock.Anything, "employee").
 			Return(expectedUser, nil)
+		expectAudit(mockRepo, "user.register").Run(func(args mock.Arguments) {
+			entry := args.Get(1).(storage.AuditEntry)
+			assert.Equal(t, expectedUser.ID.String(), entry.EntityID)
+			assert.Empty(t, entry.Before)
+			assert.Contains(t, string(entry.After), "test@example.com")
+			assert.NotContains(t, string(entry.After), "stored-hash")
+		})
 
 		req := httptest.NewRequest("POST", "/register", bytes.NewBuffer(reqBody))
 		w := httptest.NewRecorder()
@@ -103,7 +140,7 @@ func TestRegister(t *testing.T) {
 		reqBody := []byte(`{
             "email": "exists@example.com",
             "password": "password123",
-            "role": "moderator"
+            "role": "employee"
         }`)
 
 		// Настраиваем мок
@@ -112,10 +149,10 @@ func TestRegister(t *testing.T) {
 			mock.Anything,
 			"exists@example.com",
 			mock.Anything,
-			"moderator",
+			"employee",
 		).Return(
 			storage.User{},
-			ErrUserExists,
+			storage.ErrUserExists,
 		)
 
 		req := httptest.NewRequest("POST", "/register", bytes.NewBuffer(reqBody))
@@ -127,6 +164,90 @@ func TestRegister(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("database error", func(t *testing.T) {
+		reqBody := []byte(`{"email": "broken@example.com", "password": "password123", "role": "employee"}`)
+
+		mockRepo.On("CreateUser", mock.Anything, "broken@example.com", mock.Anything, "employee").
+			Return(storage.User{}, errors.New("connection refused"))
+
+		req := httptest.NewRequest("POST", "/register", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("moderator requires invite", func(t *testing.T) {
+		reqBody := []byte(`{"email": "mod@example.com", "password": "password123", "role": "moderator"}`)
+
+		req := httptest.NewRequest("POST", "/register", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("invite sets role", func(t *testing.T) {
+		reqBody := []byte(`{"email": "mod@example.com", "password": "password123", "role": "employee", "inviteToken": "invite"}`)
+
+		mockRepo.On("CreateUserWithInvite", mock.Anything, "mod@example.com", mock.Anything, token.HashOpaqueToken("invite")).
+			Return(storage.User{Email: "mod@example.com", Role: "moderator"}, nil)
+		expectAudit(mockRepo, "user.register")
+
+		req := httptest.NewRequest("POST", "/register", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"role":"moderator"`)
+	})
+
+	t.Run("used invite", func(t *testing.T) {
+		reqBody := []byte(`{"email": "late@example.com", "password": "password123", "inviteToken": "used"}`)
+
+		mockRepo.On("CreateUserWithInvite", mock.Anything, "late@example.com", mock.Anything, token.HashOpaqueToken("used")).
+			Return(storage.User{}, storage.ErrInvalidInvite)
+
+		req := httptest.NewRequest("POST", "/register", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invite")
+	})
+
+	t.Run("invalid email", func(t *testing.T) {
+		for _, email := range []string{"", "not-an-email", "user@localhost", "User <user@example.com>", "user@example."} {
+			reqBody, _ := json.Marshal(map[string]string{"email": email, "password": "password123", "role": "employee"})
+
+			req := httptest.NewRequest("POST", "/register", bytes.NewBuffer(reqBody))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, email)
+			assert.Contains(t, w.Body.String(), "invalid email", email)
+		}
+	})
+
+	t.Run("weak password", func(t *testing.T) {
+		for _, password := range []string{"", "short1", "longbutnodigits"} {
+			reqBody, _ := json.Marshal(map[string]string{"email": "weak@example.com", "password": password, "role": "employee"})
+
+			req := httptest.NewRequest("POST", "/register", bytes.NewBuffer(reqBody))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, password)
+			assert.Contains(t, w.Body.String(), "weak password", password)
+		}
+	})
+
 	t.Run("invalid request body", func(t *testing.T) {
 		req := httptest.NewRequest("POST", "/register", bytes.NewBuffer([]byte("invalid")))
 		w := httptest.NewRecorder()
@@ -137,11 +258,28 @@ func TestRegister(t *testing.T) {
 	})
 }
 
+func testGuard(db storage.Storage) *lockout.Guard {
+	return lockout.NewGuard(db, lockout.Policy{
+		MaxFailures:     3,
+		MaxIPFailures:   10,
+		BaseDelay:       time.Second,
+		LockoutDuration: time.Minute,
+		Window:          time.Hour,
+	})
+}
+
 func TestLogin(t *testing.T) {
 	mockRepo := mocks.NewStorage(t)
-	handler := handler.Login(mockRepo)
+	handler := handler.Login(mockRepo, testIssuer(t), testGuard(mockRepo), testHasher)
+
+	mockRepo.On("GetLoginLock", mock.Anything, mock.Anything, mock.Anything).Return(time.Duration(0), nil)
+	mockRepo.On("RecordLoginFailure", mock.Anything, mock.Anything, mock.Anything, time.Hour).Return(1, nil).Maybe()
+	mockRepo.On("LockLogin", mock.Anything, mock.Anything, mock.Anything, time.Second).Return(nil).Maybe()
+	mockRepo.On("ClearLoginFailures", mock.Anything, "email", mock.Anything).Return(nil).Maybe()
+	mockRepo.On("GetMFA", mock.Anything, mock.Anything).Return(storage.MFA{}, storage.ErrNotFound).Maybe()
+	mockRepo.On("IsMFARequired", mock.Anything, mock.Anything).Return(false, nil).Maybe()
 
-	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correct_password"), bcrypt.DefaultCost)
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correct_password"), testHasher.BcryptCost)
 
 	t.Run("success login", func(t *testing.T) {
 		reqBody := []byte(`{
@@ -150,13 +288,19 @@ func TestLogin(t *testing.T) {
 		}`)
 
 		mockUser := storage.User{
+			ID:           uuid.New(),
 			Email:        "user@example.com",
 			PasswordHash: string(hashedPassword),
 			Role:         "moderator",
 		}
 
 		mockRepo.On("GetUserByEmail", mock.Anything, "user@example.com").
-			Return(mockUser, nil)
+			Return(mockUser, nil).Once()
+		mockRepo.On("UpdateLastLogin", mock.Anything, mockUser.ID).Return(nil).Once()
+		mockRepo.On("CreateSession", mock.Anything, mockUser.ID, mock.Anything, mock.Anything, mock.Anything).
+			Return(storage.Session{ID: uuid.New()}, nil).Once()
+		mockRepo.On("CreateRefreshToken", mock.Anything, mockUser.ID, mock.Anything, mock.Anything, mock.Anything).
+			Return(storage.RefreshToken{}, nil).Once()
 
 		req := httptest.NewRequest("POST", "/login", bytes.NewBuffer(reqBody))
 		w := httptest.NewRecorder()
@@ -169,6 +313,7 @@ func TestLogin(t *testing.T) {
 		var response map[string]string
 		json.NewDecoder(resp.Body).Decode(&response)
 		assert.NotEmpty(t, response["token"])
+		assert.NotEmpty(t, response["refreshToken"])
 	})
 
 	t.Run("invalid credentials", func(t *testing.T) {
@@ -192,6 +337,42 @@ func TestLogin(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
 
+	t.Run("disabled user", func(t *testing.T) {
+		reqBody := []byte(`{
+			"email": "disabled@example.com",
+			"password": "correct_password"
+		}`)
+
+		disabledAt := time.Now()
+		mockRepo.On("GetUserByEmail", mock.Anything, "disabled@example.com").
+			Return(storage.User{ID: uuid.New(), PasswordHash: string(hashedPassword), DisabledAt: &disabledAt}, nil)
+
+		req := httptest.NewRequest("POST", "/login", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("password reset required", func(t *testing.T) {
+		reqBody := []byte(`{
+			"email": "reset@example.com",
+			"password": "correct_password"
+		}`)
+
+		mockRepo.On("GetUserByEmail", mock.Anything, "reset@example.com").
+			Return(storage.User{ID: uuid.New(), PasswordHash: string(hashedPassword), PasswordResetRequired: true}, nil)
+
+		req := httptest.NewRequest("POST", "/login", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "password reset required")
+	})
+
 	t.Run("user not found", func(t *testing.T) {
 		reqBody := []byte(`{
 			"email": "notfound@example.com",
@@ -199,7 +380,7 @@ func TestLogin(t *testing.T) {
 		}`)
 
 		mockRepo.On("GetUserByEmail", mock.Anything, "notfound@example.com").
-			Return(storage.User{}, errors.New("not found"))
+			Return(storage.User{}, storage.ErrNotFound)
 
 		req := httptest.NewRequest("POST", "/login", bytes.NewBuffer(reqBody))
 		w := httptest.NewRecorder()
@@ -208,4 +389,176 @@ func TestLogin(t *testing.T) {
 
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
+
+	t.Run("storage error is not a failed attempt", func(t *testing.T) {
+		reqBody := []byte(`{"email": " Outage@Example.com ", "password": "password123"}`)
+
+		mockRepo.On("GetUserByEmail", mock.Anything, "outage@example.com").
+			Return(storage.User{}, errors.New("connection refused")).Once()
+
+		req := httptest.NewRequest("POST", "/login", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		mockRepo.AssertNotCalled(t, "RecordLoginFailure", mock.Anything, "email", "outage@example.com", mock.Anything)
+	})
+}
+
+func TestLoginInvalidEmail(t *testing.T) {
+	for name, email := range map[string]string{
+		"overlong":  strings.Repeat("a", 250) + "@example.com",
+		"malformed": "user",
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Strict mock: neither the guard nor the user lookup is reached
+			mockRepo := mocks.NewStorage(t)
+			reqBody, _ := json.Marshal(map[string]string{"email": email, "password": "password123"})
+
+			req := httptest.NewRequest("POST", "/login", bytes.NewBuffer(reqBody))
+			w := httptest.NewRecorder()
+			handler.Login(mockRepo, testIssuer(t), testGuard(mockRepo), testHasher)(w, req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Contains(t, w.Body.String(), "invalid credentials")
+		})
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	issuer := testIssuer(t)
+	reqBody := []byte(`{"email": "User@Example.com", "password": "wrong_password"}`)
+
+	t.Run("locked account gets retry after", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetLoginLock", mock.Anything, "email", "user@example.com").Return(90*time.Second+time.Millisecond, nil)
+		mockRepo.On("GetLoginLock", mock.Anything, "ip", "192.0.2.1").Return(time.Duration(0), nil)
+
+		req := httptest.NewRequest("POST", "/login", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+
+		handler.Login(mockRepo, issuer, testGuard(mockRepo), testHasher)(w, req)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "91", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("failure backs off exponentially", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetLoginLock", mock.Anything, mock.Anything, mock.Anything).Return(time.Duration(0), nil)
+		mockRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(storage.User{}, storage.ErrNotFound)
+		mockRepo.On("RecordLoginFailure", mock.Anything, "email", "user@example.com", time.Hour).Return(2, nil)
+		mockRepo.On("LockLogin", mock.Anything, "email", "user@example.com", 2*time.Second).Return(nil)
+		mockRepo.On("RecordLoginFailure", mock.Anything, "ip", "192.0.2.1", time.Hour).Return(4, nil)
+		mockRepo.On("LockLogin", mock.Anything, "ip", "192.0.2.1", 8*time.Second).Return(nil)
+
+		req := httptest.NewRequest("POST", "/login", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+
+		handler.Login(mockRepo, issuer, testGuard(mockRepo), testHasher)(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("failure limit locks account", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetLoginLock", mock.Anything, mock.Anything, mock.Anything).Return(time.Duration(0), nil)
+		mockRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(storage.User{}, storage.ErrNotFound)
+		mockRepo.On("RecordLoginFailure", mock.Anything, "email", "user@example.com", time.Hour).Return(3, nil)
+		mockRepo.On("LockLogin", mock.Anything, "email", "user@example.com", time.Minute).Return(nil)
+		mockRepo.On("RecordLoginFailure", mock.Anything, "ip", "192.0.2.1", time.Hour).Return(1, nil)
+		mockRepo.On("LockLogin", mock.Anything, "ip", "192.0.2.1", time.Second).Return(nil)
+
+		before := testutil.ToFloat64(metrics.LoginLockouts.WithLabelValues("email"))
+
+		req := httptest.NewRequest("POST", "/login", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+
+		handler.Login(mockRepo, issuer, testGuard(mockRepo), testHasher)(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, before+1, testutil.ToFloat64(metrics.LoginLockouts.WithLabelValues("email")))
+	})
+}
+
+func TestJWKS(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	keys, err := token.NewKeySet("ed",
+		token.NewEdDSAKey("ed", priv),
+		token.NewHMACKey("legacy", []byte("secret")),
+	)
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/.well-known/jwks.json", nil)
+	w := httptest.NewRecorder()
+
+	handler.JWKS(keys)(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var set token.JWKS
+	json.NewDecoder(w.Body).Decode(&set)
+	assert.Len(t, set.Keys, 1) // HMAC secrets must not be published
+	assert.Equal(t, "ed", set.Keys[0].Kid)
+	assert.Equal(t, "OKP", set.Keys[0].Kty)
+}
+
+func TestLoginRehash(t *testing.T) {
+	issuer := testIssuer(t)
+	argon := password.Hasher{
+		Algorithm: password.Argon2id,
+		Argon2:    password.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+	}
+	bcryptHash, _ := bcrypt.GenerateFromPassword([]byte("correct_password"), bcrypt.MinCost)
+	user := storage.User{ID: uuid.New(), Email: "user@example.com", PasswordHash: string(bcryptHash), Role: "employee"}
+
+	newRepo := func(t *testing.T) *mocks.Storage {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetLoginLock", mock.Anything, mock.Anything, mock.Anything).Return(time.Duration(0), nil)
+		mockRepo.On("ClearLoginFailures", mock.Anything, "email", "user@example.com").Return(nil)
+		mockRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(user, nil)
+		mockRepo.On("GetMFA", mock.Anything, user.ID).Return(storage.MFA{}, storage.ErrNotFound)
+		mockRepo.On("IsMFARequired", mock.Anything, "employee").Return(false, nil)
+		mockRepo.On("UpdateLastLogin", mock.Anything, user.ID).Return(nil)
+		mockRepo.On("CreateSession", mock.Anything, user.ID, mock.Anything, mock.Anything, mock.Anything).
+			Return(storage.Session{ID: uuid.New()}, nil)
+		mockRepo.On("CreateRefreshToken", mock.Anything, user.ID, mock.Anything, mock.Anything, mock.Anything).
+			Return(storage.RefreshToken{}, nil)
+		return mockRepo
+	}
+	login := func(mockRepo *mocks.Storage, hasher password.Hasher) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/login", bytes.NewBufferString(`{"email":"user@example.com","password":"correct_password"}`))
+		w := httptest.NewRecorder()
+		handler.Login(mockRepo, issuer, testGuard(mockRepo), hasher)(w, req)
+		return w
+	}
+
+	t.Run("bcrypt hash is upgraded to argon2id", func(t *testing.T) {
+		mockRepo := newRepo(t)
+		var newHash string
+		mockRepo.On("UpdatePasswordHash", mock.Anything, user.ID, string(bcryptHash), mock.Anything).
+			Run(func(args mock.Arguments) { newHash = args.String(3) }).
+			Return(nil)
+
+		w := login(mockRepo, argon)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, strings.HasPrefix(newHash, "$argon2id$v=19$m=1024,t=1,p=1$"), newHash)
+		assert.NoError(t, argon.Verify("correct_password", newHash))
+	})
+
+	t.Run("failed rehash doesn't block login", func(t *testing.T) {
+		mockRepo := newRepo(t)
+		mockRepo.On("UpdatePasswordHash", mock.Anything, user.ID, mock.Anything, mock.Anything).Return(storage.ErrNotFound)
+
+		w := login(mockRepo, argon)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("current hash is kept", func(t *testing.T) {
+		w := login(newRepo(t), testHasher)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
 }
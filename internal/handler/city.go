@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mi4r/avito-pvz/internal/storage"
+)
+
+const maxCityNameLength = 50
+
+// ListCities returns the city directory. Without includeInactive only the
+// cities new PVZs can be created in are listed.
+func ListCities(db storage.Storage, includeInactive bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cities, err := db.ListCities(r.Context(), includeInactive)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to list cities")
+			return
+		}
+		respondJSON(w, http.StatusOK, cities)
+	}
+}
+
+func CreateCity(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+		name := strings.TrimSpace(req.Name)
+		if name == "" || utf8.RuneCountInString(name) > maxCityNameLength {
+			respondError(w, http.StatusBadRequest, "invalid city name")
+			return
+		}
+
+		city, err := db.CreateCity(r.Context(), name)
+		if err != nil {
+			if errors.Is(err, storage.ErrCityExists) {
+				respondError(w, http.StatusConflict, err.Error())
+				return
+			}
+			respondError(w, http.StatusInternalServerError, "failed to create city")
+			return
+		}
+		recordAudit(r, db, "city.create", auditCity, city.Name, nil, city)
+		respondJSON(w, http.StatusCreated, city)
+	}
+}
+
+// UpdateCity activates or deactivates a city. PVZs already opened in a
+// deactivated city keep working.
+func UpdateCity(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Active *bool `json:"active"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Active == nil {
+			respondError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+
+		name := chi.URLParam(r, "name")
+		before, err := db.GetCity(r.Context(), name)
+		if err != nil {
+			respondCityError(w, err, "failed to update city")
+			return
+		}
+		city, err := db.SetCityActive(r.Context(), name, *req.Active)
+		if err != nil {
+			respondCityError(w, err, "failed to update city")
+			return
+		}
+		recordAudit(r, db, "city.update", auditCity, city.Name, before, city)
+		respondJSON(w, http.StatusOK, city)
+	}
+}
+
+// DeleteCity removes a city that has never had PVZs.
+func DeleteCity(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		before, err := db.GetCity(r.Context(), name)
+		if err != nil {
+			respondCityError(w, err, "failed to delete city")
+			return
+		}
+		if err := db.DeleteCity(r.Context(), name); err != nil {
+			if errors.Is(err, storage.ErrCityInUse) {
+				respondError(w, http.StatusConflict, "city has PVZs, deactivate it instead")
+				return
+			}
+			respondCityError(w, err, "failed to delete city")
+			return
+		}
+		recordAudit(r, db, "city.delete", auditCity, name, before, nil)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func respondCityError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, storage.ErrNotFound) {
+		respondError(w, http.StatusNotFound, "city not found")
+		return
+	}
+	respondError(w, http.StatusInternalServerError, message)
+}
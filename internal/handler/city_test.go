@@ -0,0 +1,125 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mi4r/avito-pvz/internal/handler"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCityAdmin(t *testing.T) {
+	mockRepo := mocks.NewStorage(t)
+	r := chi.NewRouter()
+	r.Get("/cities", handler.ListCities(mockRepo, false))
+	r.Get("/admin/cities", handler.ListCities(mockRepo, true))
+	r.Post("/admin/cities", handler.CreateCity(mockRepo))
+	r.Patch("/admin/cities/{name}", handler.UpdateCity(mockRepo))
+	r.Delete("/admin/cities/{name}", handler.DeleteCity(mockRepo))
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("public list has only active cities", func(t *testing.T) {
+		mockRepo.On("ListCities", mock.Anything, false).
+			Return([]storage.City{{Name: "Москва", Active: true}}, nil).Once()
+
+		w := serve("GET", "/cities", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var cities []storage.City
+		json.NewDecoder(w.Body).Decode(&cities)
+		assert.Len(t, cities, 1)
+	})
+
+	t.Run("admin list includes inactive cities", func(t *testing.T) {
+		mockRepo.On("ListCities", mock.Anything, true).
+			Return([]storage.City{{Name: "Казань"}, {Name: "Москва", Active: true}}, nil).Once()
+
+		w := serve("GET", "/admin/cities", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("create city", func(t *testing.T) {
+		mockRepo.On("CreateCity", mock.Anything, "Новосибирск").
+			Return(storage.City{Name: "Новосибирск", Active: true}, nil).Once()
+		expectAudit(mockRepo, "city.create")
+
+		w := serve("POST", "/admin/cities", `{"name":" Новосибирск "}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("create existing city", func(t *testing.T) {
+		mockRepo.On("CreateCity", mock.Anything, "Москва").Return(storage.City{}, storage.ErrCityExists).Once()
+
+		w := serve("POST", "/admin/cities", `{"name":"Москва"}`)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("create city without name", func(t *testing.T) {
+		w := serve("POST", "/admin/cities", `{"name":"  "}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("deactivate city", func(t *testing.T) {
+		mockRepo.On("GetCity", mock.Anything, "Казань").Return(storage.City{Name: "Казань", Active: true}, nil).Once()
+		mockRepo.On("SetCityActive", mock.Anything, "Казань", false).Return(storage.City{Name: "Казань"}, nil).Once()
+		expectAudit(mockRepo, "city.update")
+
+		w := serve("PATCH", "/admin/cities/"+url.PathEscape("Казань"), `{"active":false}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var city storage.City
+		json.NewDecoder(w.Body).Decode(&city)
+		assert.False(t, city.Active)
+	})
+
+	t.Run("update without active", func(t *testing.T) {
+		w := serve("PATCH", "/admin/cities/"+url.PathEscape("Казань"), `{}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("update unknown city", func(t *testing.T) {
+		mockRepo.On("GetCity", mock.Anything, "Атлантида").Return(storage.City{}, storage.ErrNotFound).Once()
+
+		w := serve("PATCH", "/admin/cities/"+url.PathEscape("Атлантида"), `{"active":true}`)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("delete city in use", func(t *testing.T) {
+		mockRepo.On("GetCity", mock.Anything, "Москва").Return(storage.City{Name: "Москва", Active: true}, nil).Once()
+		mockRepo.On("DeleteCity", mock.Anything, "Москва").Return(storage.ErrCityInUse).Once()
+
+		w := serve("DELETE", "/admin/cities/"+url.PathEscape("Москва"), "")
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("delete unused city", func(t *testing.T) {
+		mockRepo.On("GetCity", mock.Anything, "Новосибирск").Return(storage.City{Name: "Новосибирск"}, nil).Once()
+		mockRepo.On("DeleteCity", mock.Anything, "Новосибирск").Return(nil).Once()
+		expectAudit(mockRepo, "city.delete")
+
+		w := serve("DELETE", "/admin/cities/"+url.PathEscape("Новосибирск"), "")
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
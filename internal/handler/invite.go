@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/mi4r/avito-pvz/internal/rbac"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/token"
+)
+
+// CreateInvite issues a single-use registration token for a role. Only
+// the hash is stored, the token itself is returned once.
+func CreateInvite(db storage.Storage, ttl time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Role string `json:"role"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Role == "" {
+			respondError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+
+		// Without user:manage only employees can be invited, so moderators
+		// can't hand out roles above the ones they manage
+		if req.Role != rbac.RoleEmployee && !rbac.HasPermission(r.Context(), rbac.UserManage) {
+			respondError(w, http.StatusForbidden, "not allowed to invite this role")
+			return
+		}
+
+		createdBy := actorID(r)
+
+		plain, hash, err := token.NewOpaqueToken()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to create invite")
+			return
+		}
+
+		invite, err := db.CreateInvite(r.Context(), hash, req.Role, createdBy, time.Now().Add(ttl))
+		if err != nil {
+			respondCreateUserError(w, err)
+			return
+		}
+		recordAudit(r, db, "invite.create", auditInvite, invite.ID.String(), nil, invite)
+
+		respondJSON(w, http.StatusCreated, struct {
+			storage.Invite
+			Token string `json:"token"`
+		}{invite, plain})
+	}
+}
@@ -0,0 +1,85 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/handler"
+	"github.com/mi4r/avito-pvz/internal/rbac"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/storage/mocks"
+	"github.com/mi4r/avito-pvz/internal/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateInvite(t *testing.T) {
+	moderatorID := uuid.New()
+	asModerator := func(req *http.Request, perms ...rbac.Permission) *http.Request {
+		req = withPrincipal(req, rbac.Principal{UserID: moderatorID, Role: "moderator", Method: rbac.AuthToken})
+		return withPermissions(req, append(perms, rbac.InviteCreate)...)
+	}
+
+	t.Run("moderator invites employee", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		var hash string
+		mockRepo.On("CreateInvite", mock.Anything, mock.Anything, "employee", &moderatorID, mock.Anything).
+			Run(func(args mock.Arguments) { hash = args.String(1) }).
+			Return(storage.Invite{ID: uuid.New(), Role: "employee", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+		expectAudit(mockRepo, "invite.create")
+
+		req := httptest.NewRequest("POST", "/invites", bytes.NewBufferString(`{"role":"employee"}`))
+		w := httptest.NewRecorder()
+
+		handler.CreateInvite(mockRepo, time.Hour)(w, asModerator(req))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response map[string]any
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, "employee", response["role"])
+		plain, _ := response["token"].(string)
+		assert.NotEmpty(t, plain)
+		assert.Equal(t, token.HashOpaqueToken(plain), hash)
+	})
+
+	t.Run("moderator can't invite moderator", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/invites", bytes.NewBufferString(`{"role":"moderator"}`))
+		w := httptest.NewRecorder()
+
+		handler.CreateInvite(mocks.NewStorage(t), time.Hour)(w, asModerator(req))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("admin invites moderator", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("CreateInvite", mock.Anything, mock.Anything, "moderator", mock.Anything, mock.Anything).
+			Return(storage.Invite{Role: "moderator"}, nil)
+		expectAudit(mockRepo, "invite.create")
+
+		req := httptest.NewRequest("POST", "/invites", bytes.NewBufferString(`{"role":"moderator"}`))
+		w := httptest.NewRecorder()
+
+		handler.CreateInvite(mockRepo, time.Hour)(w, asModerator(req, rbac.UserManage))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("CreateInvite", mock.Anything, mock.Anything, "root", mock.Anything, mock.Anything).
+			Return(storage.Invite{}, storage.ErrInvalidRole)
+
+		req := httptest.NewRequest("POST", "/invites", bytes.NewBufferString(`{"role":"root"}`))
+		w := httptest.NewRecorder()
+
+		handler.CreateInvite(mockRepo, time.Hour)(w, asModerator(req, rbac.UserManage))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
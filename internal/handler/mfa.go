@@ -0,0 +1,508 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/lockout"
+	"github.com/mi4r/avito-pvz/internal/middleware"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/token"
+	"github.com/mi4r/avito-pvz/internal/totp"
+)
+
+const recoveryCodeCount = 10
+
+// mfaChallenge is returned by Login instead of tokens when the user has to
+// pass the second factor.
+type mfaChallenge struct {
+	MFARequired bool   `json:"mfaRequired"`
+	MFAToken    string `json:"mfaToken"`
+	// EnrollmentRequired is set when the role requires 2FA but the user
+	// hasn't enabled it yet
+	EnrollmentRequired bool `json:"mfaEnrollmentRequired"`
+}
+
+type mfaEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// mfaChallengeFor returns the challenge for users that need a second
+// factor, or nil if the password is enough.
+func mfaChallengeFor(ctx context.Context, db storage.Storage, issuer *token.Issuer, user storage.User) (*mfaChallenge, error) {
+	mfa, err := db.GetMFA(ctx, user.ID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+	required, err := db.IsMFARequired(ctx, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	if !mfa.Enabled() && !required {
+		return nil, nil
+	}
+
+	mfaToken, _, err := issuer.MFAToken(user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	return &mfaChallenge{MFARequired: true, MFAToken: mfaToken, EnrollmentRequired: !mfa.Enabled()}, nil
+}
+
+// LoginMFA finishes a two-step login with a TOTP code or a recovery code.
+// Users whose role requires 2FA confirm their enrollment here and get
+// their recovery codes in the response.
+func LoginMFA(db storage.Storage, issuer *token.Issuer, guard *lockout.Guard) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			MFAToken     string `json:"mfaToken"`
+			Code         string `json:"code"`
+			RecoveryCode string `json:"recoveryCode"`
+			Device       string `json:"device"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.Code == "" && req.RecoveryCode == "") {
+			respondError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+
+		user, claims, ok := pendingMFAUser(w, r, db, issuer, req.MFAToken)
+		if !ok {
+			return
+		}
+
+		mfa, err := db.GetMFA(r.Context(), user.ID)
+		if errors.Is(err, storage.ErrNotFound) {
+			respondError(w, http.StatusBadRequest, "two-factor authentication is not set up")
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to login")
+			return
+		}
+
+		email, ip := strings.ToLower(user.Email), middleware.ClientIP(r)
+		wait, err := guard.Check(r.Context(), email, ip)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to login")
+			return
+		}
+		if wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			respondError(w, http.StatusTooManyRequests, "too many login attempts")
+			return
+		}
+
+		var recoveryCodes []string
+		if mfa.Enabled() {
+			ok, err = checkSecondFactor(r.Context(), db, mfa, req.Code, req.RecoveryCode)
+		} else {
+			recoveryCodes, ok, err = confirmEnrollment(r.Context(), db, mfa, req.Code)
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to login")
+			return
+		}
+		if !ok {
+			if err := guard.Fail(r.Context(), email, ip); err != nil {
+				respondError(w, http.StatusInternalServerError, "failed to login")
+				return
+			}
+			respondError(w, http.StatusUnauthorized, "invalid code")
+			return
+		}
+		if err := guard.Succeed(r.Context(), email); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to login")
+			return
+		}
+
+		// The pending token is single-use
+		if err := db.RevokeToken(r.Context(), claims.ID, claims.ExpiresAt.Time); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to login")
+			return
+		}
+		if err := db.UpdateLastLogin(r.Context(), user.ID); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to login")
+			return
+		}
+
+		tokens, err := startSession(r, db, issuer, user, req.Device)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to issue token")
+			return
+		}
+		respondJSON(w, http.StatusOK, struct {
+			tokenPair
+			RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+		}{tokens, recoveryCodes})
+	}
+}
+
+// LoginMFAEnroll hands out a TOTP secret during login to users whose role
+// requires 2FA and who haven't enabled it yet.
+func LoginMFAEnroll(db storage.Storage, issuer *token.Issuer, issuerName string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			MFAToken string `json:"mfaToken"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+
+		user, _, ok := pendingMFAUser(w, r, db, issuer, req.MFAToken)
+		if !ok {
+			return
+		}
+		startEnrollment(w, r, db, user, issuerName)
+	}
+}
+
+// EnrollMFA starts 2FA enrollment for the current user. It takes effect
+// after ConfirmMFA.
+func EnrollMFA(db storage.Storage, issuerName string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := currentUser(w, r, db)
+		if !ok {
+			return
+		}
+		startEnrollment(w, r, db, user, issuerName)
+	}
+}
+
+// ConfirmMFA enables 2FA with the first code from the authenticator app
+// and returns the recovery codes. They are shown only once.
+func ConfirmMFA(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Code string `json:"code"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+			respondError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+
+		user, ok := currentUser(w, r, db)
+		if !ok {
+			return
+		}
+
+		mfa, err := db.GetMFA(r.Context(), user.ID)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			respondError(w, http.StatusInternalServerError, "failed to confirm two-factor authentication")
+			return
+		}
+		if err != nil || mfa.Enabled() {
+			respondError(w, http.StatusConflict, "no pending two-factor enrollment")
+			return
+		}
+
+		codes, ok, err := confirmEnrollment(r.Context(), db, mfa, req.Code)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to confirm two-factor authentication")
+			return
+		}
+		if !ok {
+			respondError(w, http.StatusBadRequest, "invalid code")
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string][]string{"recoveryCodes": codes})
+	}
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the current user.
+// A current TOTP code is required.
+func RegenerateRecoveryCodes(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Code string `json:"code"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+			respondError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+
+		mfa, ok := enabledMFA(w, r, db)
+		if !ok {
+			return
+		}
+
+		valid, err := checkSecondFactor(r.Context(), db, mfa, req.Code, "")
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to create recovery codes")
+			return
+		}
+		if !valid {
+			respondError(w, http.StatusBadRequest, "invalid code")
+			return
+		}
+
+		codes, hashes, err := newRecoveryCodes()
+		if err == nil {
+			err = db.ReplaceRecoveryCodes(r.Context(), mfa.UserID, hashes)
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to create recovery codes")
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string][]string{"recoveryCodes": codes})
+	}
+}
+
+// DisableMFA turns 2FA off after checking a TOTP or recovery code. Users
+// whose role requires 2FA can't turn it off.
+func DisableMFA(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Code         string `json:"code"`
+			RecoveryCode string `json:"recoveryCode"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.Code == "" && req.RecoveryCode == "") {
+			respondError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+
+		mfa, ok := enabledMFA(w, r, db)
+		if !ok {
+			return
+		}
+
+		required, err := db.IsMFARequired(r.Context(), principal(r).Role)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to disable two-factor authentication")
+			return
+		}
+		if required {
+			respondError(w, http.StatusForbidden, "two-factor authentication is required for your role")
+			return
+		}
+
+		valid, err := checkSecondFactor(r.Context(), db, mfa, req.Code, req.RecoveryCode)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to disable two-factor authentication")
+			return
+		}
+		if !valid {
+			respondError(w, http.StatusBadRequest, "invalid code")
+			return
+		}
+
+		if err := db.DeleteMFA(r.Context(), mfa.UserID); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to disable two-factor authentication")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// SetRoleMFA makes 2FA mandatory or optional for a role. Users of the
+// role without 2FA have to enroll on their next login.
+func SetRoleMFA(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Required *bool `json:"required"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Required == nil {
+			respondError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+
+		if err := db.SetRoleMFARequired(r.Context(), chi.URLParam(r, "role"), *req.Required); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				respondError(w, http.StatusNotFound, "role not found")
+				return
+			}
+			respondError(w, http.StatusInternalServerError, "failed to update role")
+			return
+		}
+		role := chi.URLParam(r, "role")
+		recordAudit(r, db, "role.set_mfa", auditRole, role, nil, map[string]any{"role": role, "mfaRequired": *req.Required})
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ResetUserMFA removes 2FA of a user who lost their device.
+func ResetUserMFA(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := targetUser(w, r)
+		if !ok {
+			return
+		}
+
+		if err := db.DeleteMFA(r.Context(), userID); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				respondError(w, http.StatusNotFound, "two-factor authentication is not enabled")
+				return
+			}
+			respondError(w, http.StatusInternalServerError, "failed to reset two-factor authentication")
+			return
+		}
+		recordAudit(r, db, "user.reset_mfa", auditUser, userID.String(), nil, nil)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func startEnrollment(w http.ResponseWriter, r *http.Request, db storage.Storage, user storage.User, issuerName string) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to start enrollment")
+		return
+	}
+	if err := db.StartMFAEnrollment(r.Context(), user.ID, secret); err != nil {
+		if errors.Is(err, storage.ErrMFAEnabled) {
+			respondError(w, http.StatusConflict, err.Error())
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to start enrollment")
+		return
+	}
+	respondJSON(w, http.StatusOK, mfaEnrollment{
+		Secret: secret,
+		URI:    totp.ProvisioningURI(secret, issuerName, user.Email),
+	})
+}
+
+// pendingMFAUser validates the token issued by the password step and
+// returns its user. On failure it writes the error response.
+func pendingMFAUser(w http.ResponseWriter, r *http.Request, db storage.Storage, issuer *token.Issuer, tokenStr string) (storage.User, token.Claims, bool) {
+	var claims token.Claims
+	parsed, err := issuer.Keys().Parse(tokenStr, &claims)
+	if err != nil || !parsed.Valid || !claims.MFAPending || claims.ExpiresAt == nil {
+		respondError(w, http.StatusUnauthorized, "invalid mfa token")
+		return storage.User{}, claims, false
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "invalid mfa token")
+		return storage.User{}, claims, false
+	}
+
+	revoked, err := db.IsTokenRevoked(r.Context(), claims.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to login")
+		return storage.User{}, claims, false
+	}
+	if revoked {
+		respondError(w, http.StatusUnauthorized, "invalid mfa token")
+		return storage.User{}, claims, false
+	}
+
+	user, err := db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			respondError(w, http.StatusUnauthorized, "invalid mfa token")
+			return storage.User{}, claims, false
+		}
+		respondError(w, http.StatusInternalServerError, "failed to login")
+		return storage.User{}, claims, false
+	}
+	if user.DisabledAt != nil {
+		respondError(w, http.StatusForbidden, "user is disabled")
+		return storage.User{}, claims, false
+	}
+	return user, claims, true
+}
+
+// currentUser loads the account behind the access token. Dummy tokens
+// and API keys have no account.
+func currentUser(w http.ResponseWriter, r *http.Request, db storage.Storage) (storage.User, bool) {
+	p := principal(r)
+	if !p.IsUser() {
+		respondError(w, http.StatusForbidden, "only user accounts can use two-factor authentication")
+		return storage.User{}, false
+	}
+	user, err := db.GetUserByID(r.Context(), p.UserID)
+	if err != nil {
+		respondUserError(w, err, "failed to get user")
+		return storage.User{}, false
+	}
+	return user, true
+}
+
+func enabledMFA(w http.ResponseWriter, r *http.Request, db storage.Storage) (storage.MFA, bool) {
+	user, ok := currentUser(w, r, db)
+	if !ok {
+		return storage.MFA{}, false
+	}
+	mfa, err := db.GetMFA(r.Context(), user.ID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		respondError(w, http.StatusInternalServerError, "failed to get two-factor authentication")
+		return storage.MFA{}, false
+	}
+	if err != nil || !mfa.Enabled() {
+		respondError(w, http.StatusConflict, "two-factor authentication is not enabled")
+		return storage.MFA{}, false
+	}
+	return mfa, true
+}
+
+// checkSecondFactor validates a TOTP code or, if code is empty, a
+// recovery code. Both are single-use.
+func checkSecondFactor(ctx context.Context, db storage.Storage, mfa storage.MFA, code, recoveryCode string) (bool, error) {
+	if code != "" {
+		step, ok := totp.Validate(mfa.Secret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		return db.UseMFAStep(ctx, mfa.UserID, step)
+	}
+	if recoveryCode != "" {
+		return db.UseRecoveryCode(ctx, mfa.UserID, hashRecoveryCode(recoveryCode))
+	}
+	return false, nil
+}
+
+// confirmEnrollment enables a pending enrollment if code is valid and
+// returns the new recovery codes.
+func confirmEnrollment(ctx context.Context, db storage.Storage, mfa storage.MFA, code string) ([]string, bool, error) {
+	step, ok := totp.Validate(mfa.Secret, code, time.Now())
+	if !ok {
+		return nil, false, nil
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, false, err
+	}
+	if err := db.ConfirmMFA(ctx, mfa.UserID, step, hashes); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return codes, true, nil
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCodes returns codes like "k3j5q-x7m2p" and their hashes.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	buf := make([]byte, 7)
+	for range recoveryCodeCount {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(recoveryEncoding.EncodeToString(buf)[:10])
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case, dashes and spaces so codes can be typed
+// the way they were written down.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return token.HashOpaqueToken(code)
+}
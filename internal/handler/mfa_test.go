@@ -0,0 +1,379 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/handler"
+	"github.com/mi4r/avito-pvz/internal/rbac"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/storage/mocks"
+	"github.com/mi4r/avito-pvz/internal/token"
+	"github.com/mi4r/avito-pvz/internal/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoginMFA(t *testing.T) {
+	issuer := testIssuer(t)
+	secret, _ := totp.GenerateSecret()
+	confirmedAt := time.Now()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correct_password"), bcrypt.MinCost)
+	user := storage.User{ID: uuid.New(), Email: "mod@example.com", PasswordHash: string(hashedPassword), Role: "moderator"}
+	enabled := storage.MFA{UserID: user.ID, Secret: secret, ConfirmedAt: &confirmedAt}
+
+	newRepo := func(t *testing.T) *mocks.Storage {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetLoginLock", mock.Anything, mock.Anything, mock.Anything).Return(time.Duration(0), nil).Maybe()
+		mockRepo.On("ClearLoginFailures", mock.Anything, "email", "mod@example.com").Return(nil).Maybe()
+		return mockRepo
+	}
+	pendingToken := func(t *testing.T) string {
+		mfaToken, _, err := issuer.MFAToken(user.ID.String())
+		require.NoError(t, err)
+		return mfaToken
+	}
+	loginMFA := func(mockRepo *mocks.Storage, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.LoginMFA(mockRepo, issuer, testGuard(mockRepo))(w, httptest.NewRequest("POST", "/login/mfa", bytes.NewBufferString(body)))
+		return w
+	}
+	currentCode := func() string {
+		code, _ := totp.Code(secret, totp.Step(time.Now()))
+		return code
+	}
+
+	t.Run("password step returns challenge", func(t *testing.T) {
+		mockRepo := newRepo(t)
+		mockRepo.On("GetUserByEmail", mock.Anything, "mod@example.com").Return(user, nil)
+		mockRepo.On("GetMFA", mock.Anything, user.ID).Return(enabled, nil)
+		mockRepo.On("IsMFARequired", mock.Anything, "moderator").Return(false, nil)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/login", bytes.NewBufferString(`{"email":"mod@example.com","password":"correct_password"}`))
+		handler.Login(mockRepo, issuer, testGuard(mockRepo), testHasher)(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, true, response["mfaRequired"])
+		assert.Equal(t, false, response["mfaEnrollmentRequired"])
+		assert.Nil(t, response["token"])
+
+		var claims token.Claims
+		_, err := issuer.Keys().Parse(response["mfaToken"].(string), &claims)
+		require.NoError(t, err)
+		assert.True(t, claims.MFAPending)
+		assert.Empty(t, claims.Role)
+	})
+
+	t.Run("role requirement forces enrollment", func(t *testing.T) {
+		mockRepo := newRepo(t)
+		mockRepo.On("GetUserByEmail", mock.Anything, "mod@example.com").Return(user, nil)
+		mockRepo.On("GetMFA", mock.Anything, user.ID).Return(storage.MFA{}, storage.ErrNotFound)
+		mockRepo.On("IsMFARequired", mock.Anything, "moderator").Return(true, nil)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/login", bytes.NewBufferString(`{"email":"mod@example.com","password":"correct_password"}`))
+		handler.Login(mockRepo, issuer, testGuard(mockRepo), testHasher)(w, req)
+
+		var response map[string]any
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, true, response["mfaEnrollmentRequired"])
+		assert.NotEmpty(t, response["mfaToken"])
+	})
+
+	t.Run("valid code", func(t *testing.T) {
+		mockRepo := newRepo(t)
+		mockRepo.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+		mockRepo.On("GetMFA", mock.Anything, user.ID).Return(enabled, nil)
+		mockRepo.On("UseMFAStep", mock.Anything, user.ID, totp.Step(time.Now())).Return(true, nil)
+		mockRepo.On("RevokeToken", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("UpdateLastLogin", mock.Anything, user.ID).Return(nil)
+		mockRepo.On("CreateSession", mock.Anything, user.ID, mock.Anything, mock.Anything, mock.Anything).
+			Return(storage.Session{ID: uuid.New()}, nil)
+		mockRepo.On("CreateRefreshToken", mock.Anything, user.ID, mock.Anything, mock.Anything, mock.Anything).
+			Return(storage.RefreshToken{}, nil)
+
+		w := loginMFA(mockRepo, `{"mfaToken":"`+pendingToken(t)+`","code":"`+currentCode()+`"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.NotEmpty(t, response["token"])
+		assert.NotEmpty(t, response["refreshToken"])
+		assert.Nil(t, response["recoveryCodes"])
+	})
+
+	t.Run("replayed code", func(t *testing.T) {
+		mockRepo := newRepo(t)
+		mockRepo.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+		mockRepo.On("GetMFA", mock.Anything, user.ID).Return(enabled, nil)
+		mockRepo.On("UseMFAStep", mock.Anything, user.ID, mock.Anything).Return(false, nil)
+		mockRepo.On("RecordLoginFailure", mock.Anything, mock.Anything, mock.Anything, time.Hour).Return(1, nil)
+		mockRepo.On("LockLogin", mock.Anything, mock.Anything, mock.Anything, time.Second).Return(nil)
+
+		w := loginMFA(mockRepo, `{"mfaToken":"`+pendingToken(t)+`","code":"`+currentCode()+`"}`)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("recovery code", func(t *testing.T) {
+		mockRepo := newRepo(t)
+		mockRepo.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+		mockRepo.On("GetMFA", mock.Anything, user.ID).Return(enabled, nil)
+		mockRepo.On("UseRecoveryCode", mock.Anything, user.ID, token.HashOpaqueToken("abcdefghij")).Return(true, nil)
+		mockRepo.On("RevokeToken", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("UpdateLastLogin", mock.Anything, user.ID).Return(nil)
+		mockRepo.On("CreateSession", mock.Anything, user.ID, mock.Anything, mock.Anything, mock.Anything).
+			Return(storage.Session{ID: uuid.New()}, nil)
+		mockRepo.On("CreateRefreshToken", mock.Anything, user.ID, mock.Anything, mock.Anything, mock.Anything).
+			Return(storage.RefreshToken{}, nil)
+
+		w := loginMFA(mockRepo, `{"mfaToken":"`+pendingToken(t)+`","recoveryCode":"ABCDE-FGHIJ"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("enrollment is confirmed at login", func(t *testing.T) {
+		mockRepo := newRepo(t)
+		mockRepo.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+		mockRepo.On("GetMFA", mock.Anything, user.ID).Return(storage.MFA{UserID: user.ID, Secret: secret}, nil)
+		mockRepo.On("ConfirmMFA", mock.Anything, user.ID, totp.Step(time.Now()), mock.Anything).Return(nil)
+		mockRepo.On("RevokeToken", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("UpdateLastLogin", mock.Anything, user.ID).Return(nil)
+		mockRepo.On("CreateSession", mock.Anything, user.ID, mock.Anything, mock.Anything, mock.Anything).
+			Return(storage.Session{ID: uuid.New()}, nil)
+		mockRepo.On("CreateRefreshToken", mock.Anything, user.ID, mock.Anything, mock.Anything, mock.Anything).
+			Return(storage.RefreshToken{}, nil)
+
+		w := loginMFA(mockRepo, `{"mfaToken":"`+pendingToken(t)+`","code":"`+currentCode()+`"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Token         string   `json:"token"`
+			RecoveryCodes []string `json:"recoveryCodes"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.NotEmpty(t, response.Token)
+		assert.Len(t, response.RecoveryCodes, 10)
+	})
+
+	t.Run("access token isn't a pending token", func(t *testing.T) {
+		access, _, _ := issuer.AccessToken(user.ID.String(), "moderator", uuid.NewString())
+
+		w := loginMFA(newRepo(t), `{"mfaToken":"`+access+`","code":"`+currentCode()+`"}`)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("used pending token", func(t *testing.T) {
+		mockRepo := newRepo(t)
+		mockRepo.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(true, nil)
+
+		w := loginMFA(mockRepo, `{"mfaToken":"`+pendingToken(t)+`","code":"`+currentCode()+`"}`)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("enroll during login", func(t *testing.T) {
+		mockRepo := newRepo(t)
+		mockRepo.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+		mockRepo.On("StartMFAEnrollment", mock.Anything, user.ID, mock.Anything).Return(nil)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/login/mfa/enroll", bytes.NewBufferString(`{"mfaToken":"`+pendingToken(t)+`"}`))
+		handler.LoginMFAEnroll(mockRepo, issuer, "avito-pvz")(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]string
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.NotEmpty(t, response["secret"])
+		assert.True(t, strings.HasPrefix(response["uri"], "otpauth://totp/avito-pvz:mod@example.com?"))
+	})
+}
+
+func TestMFASettings(t *testing.T) {
+	secret, _ := totp.GenerateSecret()
+	confirmedAt := time.Now()
+	user := storage.User{ID: uuid.New(), Email: "mod@example.com", Role: "moderator"}
+	enabled := storage.MFA{UserID: user.ID, Secret: secret, ConfirmedAt: &confirmedAt}
+
+	asUser := func(req *http.Request) *http.Request {
+		return withPrincipal(req, rbac.Principal{UserID: user.ID, Role: user.Role, Method: rbac.AuthToken})
+	}
+	serve := func(h http.HandlerFunc, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h(w, asUser(httptest.NewRequest("POST", "/mfa", bytes.NewBufferString(body))))
+		return w
+	}
+	currentCode := func() string {
+		code, _ := totp.Code(secret, totp.Step(time.Now()))
+		return code
+	}
+
+	t.Run("enroll", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+		mockRepo.On("StartMFAEnrollment", mock.Anything, user.ID, mock.Anything).Return(nil)
+
+		w := serve(handler.EnrollMFA(mockRepo, "avito-pvz"), "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("enroll when already enabled", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+		mockRepo.On("StartMFAEnrollment", mock.Anything, user.ID, mock.Anything).Return(storage.ErrMFAEnabled)
+
+		w := serve(handler.EnrollMFA(mockRepo, "avito-pvz"), "")
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("dummy token can't enroll", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/mfa/enroll", nil)
+		req = withPrincipal(req, rbac.Principal{Role: "moderator", Method: rbac.AuthDummy})
+
+		handler.EnrollMFA(mocks.NewStorage(t), "avito-pvz")(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("confirm", func(t *testing.T) {
+		var hashes []string
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+		mockRepo.On("GetMFA", mock.Anything, user.ID).Return(storage.MFA{UserID: user.ID, Secret: secret}, nil)
+		mockRepo.On("ConfirmMFA", mock.Anything, user.ID, totp.Step(time.Now()), mock.Anything).
+			Run(func(args mock.Arguments) { hashes = args.Get(3).([]string) }).
+			Return(nil)
+
+		w := serve(handler.ConfirmMFA(mockRepo), `{"code":"`+currentCode()+`"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			RecoveryCodes []string `json:"recoveryCodes"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		require.Len(t, response.RecoveryCodes, 10)
+		assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, response.RecoveryCodes[0])
+		assert.Equal(t, token.HashOpaqueToken(strings.ReplaceAll(response.RecoveryCodes[0], "-", "")), hashes[0])
+	})
+
+	t.Run("confirm with wrong code", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+		mockRepo.On("GetMFA", mock.Anything, user.ID).Return(storage.MFA{UserID: user.ID, Secret: secret}, nil)
+
+		w := serve(handler.ConfirmMFA(mockRepo), `{"code":"000000x"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("regenerate recovery codes", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+		mockRepo.On("GetMFA", mock.Anything, user.ID).Return(enabled, nil)
+		mockRepo.On("UseMFAStep", mock.Anything, user.ID, mock.Anything).Return(true, nil)
+		mockRepo.On("ReplaceRecoveryCodes", mock.Anything, user.ID, mock.Anything).Return(nil)
+
+		w := serve(handler.RegenerateRecoveryCodes(mockRepo), `{"code":"`+currentCode()+`"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("disable", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+		mockRepo.On("GetMFA", mock.Anything, user.ID).Return(enabled, nil)
+		mockRepo.On("IsMFARequired", mock.Anything, "moderator").Return(false, nil)
+		mockRepo.On("UseMFAStep", mock.Anything, user.ID, mock.Anything).Return(true, nil)
+		mockRepo.On("DeleteMFA", mock.Anything, user.ID).Return(nil)
+
+		w := serve(handler.DisableMFA(mockRepo), `{"code":"`+currentCode()+`"}`)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("disable when required by role", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+		mockRepo.On("GetMFA", mock.Anything, user.ID).Return(enabled, nil)
+		mockRepo.On("IsMFARequired", mock.Anything, "moderator").Return(true, nil)
+
+		w := serve(handler.DisableMFA(mockRepo), `{"code":"`+currentCode()+`"}`)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("disable when not enabled", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+		mockRepo.On("GetMFA", mock.Anything, user.ID).Return(storage.MFA{}, storage.ErrNotFound)
+
+		w := serve(handler.DisableMFA(mockRepo), `{"code":"`+currentCode()+`"}`)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestMFAAdmin(t *testing.T) {
+	mockRepo := mocks.NewStorage(t)
+	r := chi.NewRouter()
+	r.Put("/admin/roles/{role}/mfa", handler.SetRoleMFA(mockRepo))
+	r.Delete("/admin/users/{userId}/mfa", handler.ResetUserMFA(mockRepo))
+
+	t.Run("require mfa for role", func(t *testing.T) {
+		mockRepo.On("SetRoleMFARequired", mock.Anything, "moderator", true).Return(nil).Once()
+		expectAudit(mockRepo, "role.set_mfa")
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("PUT", "/admin/roles/moderator/mfa", bytes.NewBufferString(`{"required":true}`)))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		mockRepo.On("SetRoleMFARequired", mock.Anything, "root", true).Return(storage.ErrNotFound).Once()
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("PUT", "/admin/roles/root/mfa", bytes.NewBufferString(`{"required":true}`)))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("missing flag", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("PUT", "/admin/roles/moderator/mfa", bytes.NewBufferString(`{}`)))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("reset user mfa", func(t *testing.T) {
+		userID := uuid.New()
+		mockRepo.On("DeleteMFA", mock.Anything, userID).Return(nil).Once()
+		expectAudit(mockRepo, "user.reset_mfa")
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/users/"+userID.String()+"/mfa", nil))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/mi4r/avito-pvz/internal/oidc"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/token"
+)
+
+// OIDCLogin starts single sign-on: it stores a state, nonce and PKCE
+// verifier and redirects the browser to the identity provider.
+func OIDCLogin(db storage.Storage, provider *oidc.Provider, stateTTL time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		state, stateHash, err := token.NewOpaqueToken()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to start sign-in")
+			return
+		}
+		nonce, _, err := token.NewOpaqueToken()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to start sign-in")
+			return
+		}
+		verifier, err := oidc.NewVerifier()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to start sign-in")
+			return
+		}
+
+		if err := db.CreateOIDCState(r.Context(), stateHash, nonce, verifier, time.Now().Add(stateTTL)); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to start sign-in")
+			return
+		}
+		http.Redirect(w, r, provider.AuthCodeURL(state, nonce, verifier), http.StatusFound)
+	}
+}
+
+// OIDCCallback finishes single sign-on. The user is found by their
+// identity at the provider, then by verified email, and created if
+// neither exists. Users without a mapped group are rejected, users with
+// 2FA get an mfaToken for /login/mfa instead of tokens.
+func OIDCCallback(db storage.Storage, provider *oidc.Provider, roles oidc.GroupRoles, issuer *token.Issuer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if idpErr := query.Get("error"); idpErr != "" {
+			respondError(w, http.StatusUnauthorized, "sign-in failed: "+idpErr)
+			return
+		}
+		code, state := query.Get("code"), query.Get("state")
+		if code == "" || state == "" {
+			respondError(w, http.StatusBadRequest, "code and state are required")
+			return
+		}
+
+		stored, err := db.ConsumeOIDCState(r.Context(), token.HashOpaqueToken(state))
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				respondError(w, http.StatusBadRequest, "invalid or expired state")
+				return
+			}
+			respondError(w, http.StatusInternalServerError, "failed to sign in")
+			return
+		}
+
+		identity, err := provider.Exchange(r.Context(), code, stored.CodeVerifier, stored.Nonce)
+		if err != nil {
+			respondError(w, http.StatusUnauthorized, "failed to verify identity")
+			return
+		}
+
+		role, ok := roles.Role(identity.Groups)
+		if !ok {
+			respondError(w, http.StatusForbidden, "no role is mapped to the user's groups")
+			return
+		}
+
+		user, ok := provisionUser(w, r, db, identity, role)
+		if !ok {
+			return
+		}
+		if user.DisabledAt != nil {
+			respondError(w, http.StatusForbidden, "user is disabled")
+			return
+		}
+
+		// The provider's own second factor isn't trusted, 2FA works as
+		// for /login
+		challenge, err := mfaChallengeFor(r.Context(), db, issuer, user)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to sign in")
+			return
+		}
+		if challenge != nil {
+			respondJSON(w, http.StatusOK, challenge)
+			return
+		}
+
+		if err := db.UpdateLastLogin(r.Context(), user.ID); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to sign in")
+			return
+		}
+
+		tokens, err := startSession(r, db, issuer, user, "")
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to issue token")
+			return
+		}
+		respondJSON(w, http.StatusOK, tokens)
+	}
+}
+
+// provisionUser returns the user for the identity, linking an existing
+// account with the same verified email or creating a new one. The role of
+// accounts the provider created follows its groups, linked local accounts
+// keep theirs. On failure it writes the error response and returns false.
+func provisionUser(w http.ResponseWriter, r *http.Request, db storage.Storage, identity oidc.Identity, role string) (storage.User, bool) {
+	ctx := r.Context()
+	linked, err := db.GetUserByIdentity(ctx, identity.Issuer, identity.Subject)
+	user, syncRole := linked.User, linked.SyncRole
+	if errors.Is(err, storage.ErrNotFound) {
+		if identity.EmailVerified != nil && !*identity.EmailVerified {
+			respondError(w, http.StatusForbidden, "email is not verified")
+			return storage.User{}, false
+		}
+		email, ok := normalizeEmail(identity.Email)
+		if !ok {
+			respondError(w, http.StatusForbidden, "identity has no valid email")
+			return storage.User{}, false
+		}
+
+		user, err = db.GetUserByEmail(ctx, email)
+		syncRole = errors.Is(err, storage.ErrNotFound)
+		if syncRole {
+			// SSO users have no password, the hasher rejects an empty hash as
+			// an unknown format, so they can't log in with one
+			user, err = db.CreateUser(ctx, email, "", role)
+			if errors.Is(err, storage.ErrUserExists) {
+				syncRole = false
+				user, err = db.GetUserByEmail(ctx, email)
+			}
+		}
+		// An email the provider didn't verify could be used to take over
+		// a local account
+		if err == nil && !syncRole && (identity.EmailVerified == nil || !*identity.EmailVerified) {
+			respondError(w, http.StatusForbidden, "email is not verified")
+			return storage.User{}, false
+		}
+		if err == nil {
+			err = db.LinkIdentity(ctx, identity.Issuer, identity.Subject, user.ID, syncRole)
+		}
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to sign in")
+		return storage.User{}, false
+	}
+
+	if syncRole && user.Role != role {
+		if err := db.UpdateUserRole(ctx, user.ID, role); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to sign in")
+			return storage.User{}, false
+		}
+		user.Role = role
+	}
+	return user, true
+}
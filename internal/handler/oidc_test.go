@@ -0,0 +1,247 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/handler"
+	"github.com/mi4r/avito-pvz/internal/oidc"
+	"github.com/mi4r/avito-pvz/internal/oidc/oidctest"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/storage/mocks"
+	"github.com/mi4r/avito-pvz/internal/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCLogin(t *testing.T) {
+	idp := oidctest.NewServer("pvz", "secret")
+	provider, err := oidc.Discover(context.Background(), oidc.Config{
+		Issuer:       oidctest.Issuer,
+		ClientID:     "pvz",
+		ClientSecret: "secret",
+		RedirectURL:  "https://pvz.test/auth/oidc/callback",
+		Scopes:       []string{"email"},
+		HTTPClient:   idp.Client(),
+	})
+	require.NoError(t, err)
+	roles, err := oidc.ParseGroupRoles([]string{"pvz-admins:admin", "pvz-staff:employee"})
+	require.NoError(t, err)
+	issuer := testIssuer(t)
+
+	// signIn runs the redirect to the IdP and back and returns the
+	// callback response
+	signIn := func(t *testing.T, mockRepo *mocks.Storage) *httptest.ResponseRecorder {
+		var stateHash string
+		var stored storage.OIDCState
+		mockRepo.On("CreateOIDCState", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				stateHash = args.String(1)
+				stored = storage.OIDCState{Nonce: args.String(2), CodeVerifier: args.String(3)}
+			}).
+			Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		handler.OIDCLogin(mockRepo, provider, time.Minute)(w, httptest.NewRequest("GET", "/auth/oidc/login", nil))
+		require.Equal(t, http.StatusFound, w.Code)
+
+		resp, err := idp.Client().Get(w.Header().Get("Location"))
+		require.NoError(t, err)
+		callback, err := url.Parse(resp.Header.Get("Location"))
+		require.NoError(t, err)
+		assert.Equal(t, stateHash, token.HashOpaqueToken(callback.Query().Get("state")))
+
+		mockRepo.On("ConsumeOIDCState", mock.Anything, stateHash).Return(stored, nil).Once()
+
+		w = httptest.NewRecorder()
+		handler.OIDCCallback(mockRepo, provider, roles, issuer)(w, httptest.NewRequest("GET", callback.RequestURI(), nil))
+		return w
+	}
+
+	t.Run("new user is provisioned", func(t *testing.T) {
+		idp.SignIn(oidctest.User{Subject: "staff-1", Email: "Staff@Example.com", EmailVerified: true, Groups: []string{"pvz-staff"}})
+		userID := uuid.New()
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetUserByIdentity", mock.Anything, oidctest.Issuer, "staff-1").Return(storage.LinkedUser{}, storage.ErrNotFound)
+		mockRepo.On("GetUserByEmail", mock.Anything, "staff@example.com").Return(storage.User{}, storage.ErrNotFound)
+		mockRepo.On("CreateUser", mock.Anything, "staff@example.com", "", "employee").
+			Return(storage.User{ID: userID, Email: "staff@example.com", Role: "employee"}, nil)
+		mockRepo.On("LinkIdentity", mock.Anything, oidctest.Issuer, "staff-1", userID, true).Return(nil)
+		mockRepo.On("GetMFA", mock.Anything, userID).Return(storage.MFA{}, storage.ErrNotFound)
+		mockRepo.On("IsMFARequired", mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("UpdateLastLogin", mock.Anything, userID).Return(nil)
+		mockRepo.On("CreateSession", mock.Anything, userID, mock.Anything, mock.Anything, mock.Anything).
+			Return(storage.Session{ID: uuid.New()}, nil)
+		mockRepo.On("CreateRefreshToken", mock.Anything, userID, mock.Anything, mock.Anything, mock.Anything).
+			Return(storage.RefreshToken{}, nil)
+
+		w := signIn(t, mockRepo)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response map[string]string
+		json.NewDecoder(w.Body).Decode(&response)
+		var claims token.Claims
+		_, err := issuer.Keys().Parse(response["token"], &claims)
+		require.NoError(t, err)
+		assert.Equal(t, userID.String(), claims.Subject)
+		assert.Equal(t, "employee", claims.Role)
+		assert.NotEmpty(t, response["refreshToken"])
+	})
+
+	t.Run("linked user gets role from groups", func(t *testing.T) {
+		idp.SignIn(oidctest.User{Subject: "admin-1", Email: "boss@example.com", EmailVerified: true, Groups: []string{"pvz-staff", "pvz-admins"}})
+		userID := uuid.New()
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetUserByIdentity", mock.Anything, oidctest.Issuer, "admin-1").
+			Return(storage.LinkedUser{User: storage.User{ID: userID, Email: "boss@example.com", Role: "employee"}, SyncRole: true}, nil)
+		mockRepo.On("UpdateUserRole", mock.Anything, userID, "admin").Return(nil)
+		mockRepo.On("GetMFA", mock.Anything, userID).Return(storage.MFA{}, storage.ErrNotFound)
+		mockRepo.On("IsMFARequired", mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("UpdateLastLogin", mock.Anything, userID).Return(nil)
+		mockRepo.On("CreateSession", mock.Anything, userID, mock.Anything, mock.Anything, mock.Anything).
+			Return(storage.Session{ID: uuid.New()}, nil)
+		mockRepo.On("CreateRefreshToken", mock.Anything, userID, mock.Anything, mock.Anything, mock.Anything).
+			Return(storage.RefreshToken{}, nil)
+
+		w := signIn(t, mockRepo)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("existing account is linked by email", func(t *testing.T) {
+		idp.SignIn(oidctest.User{Subject: "staff-2", Email: "old@example.com", EmailVerified: true, Groups: []string{"pvz-staff"}})
+		userID := uuid.New()
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetUserByIdentity", mock.Anything, oidctest.Issuer, "staff-2").Return(storage.LinkedUser{}, storage.ErrNotFound)
+		mockRepo.On("GetUserByEmail", mock.Anything, "old@example.com").
+			Return(storage.User{ID: userID, Email: "old@example.com", Role: "employee"}, nil)
+		mockRepo.On("LinkIdentity", mock.Anything, oidctest.Issuer, "staff-2", userID, false).Return(nil)
+		mockRepo.On("GetMFA", mock.Anything, userID).Return(storage.MFA{}, storage.ErrNotFound)
+		mockRepo.On("IsMFARequired", mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("UpdateLastLogin", mock.Anything, userID).Return(nil)
+		mockRepo.On("CreateSession", mock.Anything, userID, mock.Anything, mock.Anything, mock.Anything).
+			Return(storage.Session{ID: uuid.New()}, nil)
+		mockRepo.On("CreateRefreshToken", mock.Anything, userID, mock.Anything, mock.Anything, mock.Anything).
+			Return(storage.RefreshToken{}, nil)
+
+		w := signIn(t, mockRepo)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("linked local account keeps its role", func(t *testing.T) {
+		idp.SignIn(oidctest.User{Subject: "staff-5", Email: "lead@example.com", EmailVerified: true, Groups: []string{"pvz-staff"}})
+		userID := uuid.New()
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetUserByIdentity", mock.Anything, oidctest.Issuer, "staff-5").
+			Return(storage.LinkedUser{User: storage.User{ID: userID, Email: "lead@example.com", Role: "moderator"}}, nil)
+		mockRepo.On("GetMFA", mock.Anything, userID).Return(storage.MFA{}, storage.ErrNotFound)
+		mockRepo.On("IsMFARequired", mock.Anything, mock.Anything).Return(false, nil)
+		mockRepo.On("UpdateLastLogin", mock.Anything, userID).Return(nil)
+		mockRepo.On("CreateSession", mock.Anything, userID, mock.Anything, mock.Anything, mock.Anything).
+			Return(storage.Session{ID: uuid.New()}, nil)
+		mockRepo.On("CreateRefreshToken", mock.Anything, userID, mock.Anything, mock.Anything, mock.Anything).
+			Return(storage.RefreshToken{}, nil)
+
+		w := signIn(t, mockRepo)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response map[string]string
+		json.NewDecoder(w.Body).Decode(&response)
+		var claims token.Claims
+		_, err := issuer.Keys().Parse(response["token"], &claims)
+		require.NoError(t, err)
+		assert.Equal(t, "moderator", claims.Role)
+		mockRepo.AssertNotCalled(t, "UpdateUserRole", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("role requiring 2FA gets a challenge", func(t *testing.T) {
+		idp.SignIn(oidctest.User{Subject: "admin-2", Email: "root@example.com", EmailVerified: true, Groups: []string{"pvz-admins"}})
+		userID := uuid.New()
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetUserByIdentity", mock.Anything, oidctest.Issuer, "admin-2").
+			Return(storage.LinkedUser{User: storage.User{ID: userID, Email: "root@example.com", Role: "admin"}, SyncRole: true}, nil)
+		mockRepo.On("GetMFA", mock.Anything, userID).Return(storage.MFA{}, storage.ErrNotFound)
+		mockRepo.On("IsMFARequired", mock.Anything, "admin").Return(true, nil)
+
+		w := signIn(t, mockRepo)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.Equal(t, true, response["mfaRequired"])
+		assert.NotEmpty(t, response["mfaToken"])
+		assert.Nil(t, response["token"])
+		mockRepo.AssertNotCalled(t, "CreateSession", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("email without verified claim isn't linked", func(t *testing.T) {
+		idp.SignIn(oidctest.User{Subject: "staff-6", Email: "old@example.com", Groups: []string{"pvz-admins"},
+			Claims: map[string]any{"email_verified": nil}})
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetUserByIdentity", mock.Anything, oidctest.Issuer, "staff-6").Return(storage.LinkedUser{}, storage.ErrNotFound)
+		mockRepo.On("GetUserByEmail", mock.Anything, "old@example.com").
+			Return(storage.User{ID: uuid.New(), Email: "old@example.com", Role: "employee"}, nil)
+
+		w := signIn(t, mockRepo)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockRepo.AssertNotCalled(t, "LinkIdentity", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "UpdateUserRole", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("unverified email isn't linked", func(t *testing.T) {
+		idp.SignIn(oidctest.User{Subject: "staff-3", Email: "old@example.com", EmailVerified: false, Groups: []string{"pvz-staff"}})
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetUserByIdentity", mock.Anything, oidctest.Issuer, "staff-3").Return(storage.LinkedUser{}, storage.ErrNotFound)
+
+		w := signIn(t, mockRepo)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("no mapped group", func(t *testing.T) {
+		idp.SignIn(oidctest.User{Subject: "sales-1", Email: "sales@example.com", EmailVerified: true, Groups: []string{"sales"}})
+
+		w := signIn(t, mocks.NewStorage(t))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("disabled user", func(t *testing.T) {
+		idp.SignIn(oidctest.User{Subject: "staff-4", Email: "gone@example.com", EmailVerified: true, Groups: []string{"pvz-staff"}})
+		disabledAt := time.Now()
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetUserByIdentity", mock.Anything, oidctest.Issuer, "staff-4").
+			Return(storage.LinkedUser{User: storage.User{ID: uuid.New(), Role: "employee", DisabledAt: &disabledAt}}, nil)
+
+		w := signIn(t, mockRepo)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("unknown state", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("ConsumeOIDCState", mock.Anything, token.HashOpaqueToken("forged")).
+			Return(storage.OIDCState{}, storage.ErrNotFound)
+
+		w := httptest.NewRecorder()
+		handler.OIDCCallback(mockRepo, provider, roles, issuer)(w, httptest.NewRequest("GET", "/auth/oidc/callback?code=c&state=forged", nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("provider error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.OIDCCallback(mocks.NewStorage(t), provider, roles, issuer)(w, httptest.NewRequest("GET", "/auth/oidc/callback?error=access_denied&state=s", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mi4r/avito-pvz/internal/metrics"
+	"github.com/mi4r/avito-pvz/internal/notify"
+	"github.com/mi4r/avito-pvz/internal/password"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/token"
+)
+
+// ForgotPassword mails a single-use reset link to the user. The response
+// is the same whether the account exists or not, so it can't be used to
+// probe for emails.
+func ForgotPassword(db storage.Storage, notifier notify.Notifier, ttl time.Duration, resetURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Email string `json:"email"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+		email, ok := normalizeEmail(req.Email)
+		if !ok {
+			respondError(w, http.StatusBadRequest, "invalid email")
+			return
+		}
+
+		user, err := db.GetUserByEmail(r.Context(), email)
+		if errors.Is(err, storage.ErrNotFound) || (err == nil && user.DisabledAt != nil) {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to reset password")
+			return
+		}
+
+		plain, hash, err := token.NewOpaqueToken()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to reset password")
+			return
+		}
+		if err := db.CreatePasswordReset(r.Context(), user.ID, hash, time.Now().Add(ttl)); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to reset password")
+			return
+		}
+
+		msg := notify.Message{
+			To:      user.Email,
+			Subject: "Password reset",
+			Body:    resetMessage(resetLink(resetURL, plain), ttl),
+		}
+		// Sending in the background keeps the response time and status
+		// the same as for unknown emails
+		ctx := context.WithoutCancel(r.Context())
+		go func() {
+			if err := notifier.Send(ctx, msg); err != nil {
+				metrics.NotificationFailures.Inc()
+				log.Printf("Failed to send password reset email: %v", err)
+			}
+		}()
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// resetLink appends the token to the reset page address. Without an
+// address the bare token is sent.
+func resetLink(resetURL, plain string) string {
+	if resetURL == "" {
+		return plain
+	}
+	sep := "?"
+	if strings.Contains(resetURL, "?") {
+		sep = "&"
+	}
+	return resetURL + sep + "token=" + url.QueryEscape(plain)
+}
+
+func resetMessage(link string, ttl time.Duration) string {
+	return fmt.Sprintf("Someone asked to reset the password of your account.\n\n"+
+		"To choose a new password, use this link within %s:\n\n%s\n\n"+
+		"If it wasn't you, ignore this message, your password stays the same.\n", ttl, link)
+}
+
+// ResetPassword sets a new password using a token from ForgotPassword.
+// Existing sessions are ended.
+func ResetPassword(db storage.Storage, policy password.Policy, hasher password.Hasher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Token    string `json:"token"`
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+			respondError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+
+		hash, ok := hashPassword(w, req.Password, policy, hasher)
+		if !ok {
+			return
+		}
+
+		userID, err := db.ResetPassword(r.Context(), token.HashOpaqueToken(req.Token), hash)
+		if errors.Is(err, storage.ErrInvalidResetToken) {
+			respondError(w, http.StatusBadRequest, "invalid or expired token")
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to reset password")
+			return
+		}
+		recordAudit(r, db, "user.password_reset", auditUser, userID.String(), nil, nil)
+
+		if err := db.RevokeUserSessions(r.Context(), userID); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to revoke tokens")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
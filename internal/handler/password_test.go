@@ -0,0 +1,180 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/handler"
+	"github.com/mi4r/avito-pvz/internal/notify"
+	"github.com/mi4r/avito-pvz/internal/password"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/storage/mocks"
+	"github.com/mi4r/avito-pvz/internal/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// recordingNotifier passes sent messages to the test, which waits for
+// them as reset emails are sent in the background.
+type recordingNotifier struct {
+	sent chan notify.Message
+	err  error
+}
+
+func newRecordingNotifier(err error) *recordingNotifier {
+	return &recordingNotifier{sent: make(chan notify.Message, 1), err: err}
+}
+
+func (n *recordingNotifier) Send(ctx context.Context, msg notify.Message) error {
+	n.sent <- msg
+	return n.err
+}
+
+func (n *recordingNotifier) next(t *testing.T) notify.Message {
+	select {
+	case msg := <-n.sent:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message was sent")
+		return notify.Message{}
+	}
+}
+
+func TestForgotPassword(t *testing.T) {
+	user := storage.User{ID: uuid.New(), Email: "user@example.com", Role: "employee"}
+	forgot := func(db storage.Storage, notifier notify.Notifier, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/password/forgot", bytes.NewBufferString(body))
+		handler.ForgotPassword(db, notifier, time.Hour, "https://pvz.example.com/reset")(w, req)
+		return w
+	}
+
+	t.Run("sends reset link", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		var hash string
+		mockRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(user, nil)
+		mockRepo.On("CreatePasswordReset", mock.Anything, user.ID, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { hash = args.String(2) }).
+			Return(nil)
+		notifier := newRecordingNotifier(nil)
+
+		w := forgot(mockRepo, notifier, `{"email":"User@Example.com"}`)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		msg := notifier.next(t)
+		assert.Equal(t, "user@example.com", msg.To)
+
+		start := strings.Index(msg.Body, "https://pvz.example.com/reset?token=")
+		require.NotEqual(t, -1, start)
+		link, err := url.Parse(strings.Fields(msg.Body[start:])[0])
+		require.NoError(t, err)
+		assert.Equal(t, hash, token.HashOpaqueToken(link.Query().Get("token")))
+	})
+
+	t.Run("unknown email looks the same", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetUserByEmail", mock.Anything, "nobody@example.com").Return(storage.User{}, storage.ErrNotFound)
+		notifier := newRecordingNotifier(nil)
+
+		w := forgot(mockRepo, notifier, `{"email":"nobody@example.com"}`)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Empty(t, notifier.sent)
+	})
+
+	t.Run("disabled user gets no link", func(t *testing.T) {
+		disabledAt := time.Now()
+		disabled := user
+		disabled.DisabledAt = &disabledAt
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(disabled, nil)
+		notifier := newRecordingNotifier(nil)
+
+		w := forgot(mockRepo, notifier, `{"email":"user@example.com"}`)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Empty(t, notifier.sent)
+	})
+
+	t.Run("invalid email", func(t *testing.T) {
+		w := forgot(mocks.NewStorage(t), newRecordingNotifier(nil), `{"email":"user"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("delivery failure looks the same", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(user, nil)
+		mockRepo.On("CreatePasswordReset", mock.Anything, user.ID, mock.Anything, mock.Anything).Return(nil)
+
+		notifier := newRecordingNotifier(errors.New("connection refused"))
+
+		w := forgot(mockRepo, notifier, `{"email":"user@example.com"}`)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		notifier.next(t)
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	policy := password.Policy{MinLength: 8, RequireDigit: true}
+	userID := uuid.New()
+	reset := func(db storage.Storage, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/password/reset", bytes.NewBufferString(body))
+		handler.ResetPassword(db, policy, testHasher)(w, req)
+		return w
+	}
+
+	t.Run("sets password and ends sessions", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		var hash string
+		mockRepo.On("ResetPassword", mock.Anything, token.HashOpaqueToken("reset-token"), mock.Anything).
+			Run(func(args mock.Arguments) { hash = args.String(2) }).
+			Return(userID, nil)
+		expectAudit(mockRepo, "user.password_reset").Run(func(args mock.Arguments) {
+			entry := args.Get(1).(storage.AuditEntry)
+			assert.Equal(t, userID.String(), entry.EntityID)
+			assert.Empty(t, entry.Before)
+			assert.Empty(t, entry.After)
+		})
+		mockRepo.On("RevokeUserSessions", mock.Anything, userID).Return(nil)
+
+		w := reset(mockRepo, `{"token":"reset-token","password":"newpass123"}`)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("newpass123")))
+	})
+
+	t.Run("used or expired token", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("ResetPassword", mock.Anything, mock.Anything, mock.Anything).Return(uuid.Nil, storage.ErrInvalidResetToken)
+
+		w := reset(mockRepo, `{"token":"reset-token","password":"newpass123"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid or expired token")
+	})
+
+	t.Run("weak password", func(t *testing.T) {
+		w := reset(mocks.NewStorage(t), `{"token":"reset-token","password":"short"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		w := reset(mocks.NewStorage(t), `{"password":"newpass123"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
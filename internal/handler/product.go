@@ -21,6 +21,14 @@ func AddProduct(db storage.Storage) http.HandlerFunc {
 			return
 		}
 
+		if !pvzAccessible(r, req.PVZID) {
+			respondError(w, http.StatusForbidden, "no access to this PVZ")
+			return
+		}
+		if !pvzOpen(w, r, db, req.PVZID) {
+			return
+		}
+
 		// Get open reception
 		reception, err := db.GetOpenReception(r.Context(), req.PVZID)
 		if err != nil {
@@ -28,12 +36,13 @@ func AddProduct(db storage.Storage) http.HandlerFunc {
 			return
 		}
 
-		product, err := db.AddProduct(r.Context(), reception.ID, req.Type)
+		product, err := db.AddProduct(r.Context(), reception.ID, req.Type, actorID(r))
 		if err != nil {
 			respondError(w, http.StatusInternalServerError, "failed to add product")
 			return
 		}
 
+		recordAudit(r, db, "product.create", auditProduct, product.ID.String(), nil, product)
 		metrics.ProductsAdded.Inc()
 		respondJSON(w, http.StatusCreated, product)
 	}
@@ -47,6 +56,11 @@ func DeleteLastProduct(db storage.Storage) http.HandlerFunc {
 			return
 		}
 
+		if !pvzAccessible(r, pvzID) {
+			respondError(w, http.StatusForbidden, "no access to this PVZ")
+			return
+		}
+
 		// Get open reception
 		reception, err := db.GetOpenReception(r.Context(), pvzID)
 		if err != nil {
@@ -60,10 +74,11 @@ func DeleteLastProduct(db storage.Storage) http.HandlerFunc {
 			return
 		}
 
-		if err := db.DeleteProduct(r.Context(), product.ID); err != nil {
+		if err := db.DeleteProduct(r.Context(), product.ID, actorID(r)); err != nil {
 			respondError(w, http.StatusInternalServerError, "failed to delete product")
 			return
 		}
+		recordAudit(r, db, "product.delete", auditProduct, product.ID.String(), product, nil)
 
 		w.WriteHeader(http.StatusOK)
 	}
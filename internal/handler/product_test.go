@@ -31,13 +31,17 @@ func TestAddProduct(t *testing.T) {
 		}`)
 
 		// Mock expectations
+		expectOpenPVZ(mockStorage, pvzID)
 		mockStorage.On("GetOpenReception", mock.Anything, pvzID).
 			Return(storage.Reception{ID: receptionID}, nil)
 
-		mockStorage.On("AddProduct", mock.Anything, receptionID, productType).
-			Return(storage.Product{ID: uuid.New(), Type: productType}, nil)
+		employeeID := uuid.New()
+		mockStorage.On("AddProduct", mock.Anything, receptionID, productType, &employeeID).
+			Return(storage.Product{ID: uuid.New(), Type: productType, CreatedBy: &employeeID}, nil)
+		expectAudit(mockStorage, "product.create")
 
 		req := httptest.NewRequest("POST", "/products", bytes.NewBuffer(reqBody))
+		req = withUser(withAccessAll(req), employeeID)
 		w := httptest.NewRecorder()
 
 		handler(w, req)
@@ -46,8 +50,22 @@ func TestAddProduct(t *testing.T) {
 		mockStorage.AssertExpectations(t)
 	})
 
+	t.Run("employee not assigned to pvz", func(t *testing.T) {
+		pvzID := uuid.New()
+		reqBody := []byte(`{"type": "обувь", "pvzId": "` + pvzID.String() + `"}`)
+
+		req := httptest.NewRequest("POST", "/products", bytes.NewBuffer(reqBody))
+		req = withEmployee(req, uuid.New())
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
 	t.Run("invalid request body", func(t *testing.T) {
 		req := httptest.NewRequest("POST", "/products", bytes.NewBuffer([]byte("{")))
+		req = withAccessAll(req)
 		w := httptest.NewRecorder()
 
 		handler(w, req)
@@ -62,10 +80,12 @@ func TestAddProduct(t *testing.T) {
 			"pvzId": "` + pvzID.String() + `"
 		}`)
 
+		expectOpenPVZ(mockStorage, pvzID)
 		mockStorage.On("GetOpenReception", mock.Anything, pvzID).
 			Return(storage.Reception{}, storage.ErrNotFound)
 
 		req := httptest.NewRequest("POST", "/products", bytes.NewBuffer(reqBody))
+		req = withAccessAll(req)
 		w := httptest.NewRecorder()
 
 		handler(w, req)
@@ -82,13 +102,15 @@ func TestAddProduct(t *testing.T) {
 			"pvzId": "` + pvzID.String() + `"
 		}`)
 
+		expectOpenPVZ(mockStorage, pvzID)
 		mockStorage.On("GetOpenReception", mock.Anything, pvzID).
 			Return(storage.Reception{ID: receptionID}, nil)
 
-		mockStorage.On("AddProduct", mock.Anything, receptionID, "электроника").
+		mockStorage.On("AddProduct", mock.Anything, receptionID, "электроника", (*uuid.UUID)(nil)).
 			Return(storage.Product{}, errors.New("database error"))
 
 		req := httptest.NewRequest("POST", "/products", bytes.NewBuffer(reqBody))
+		req = withAccessAll(req)
 		w := httptest.NewRecorder()
 
 		handler(w, req)
@@ -118,10 +140,13 @@ func TestDeleteLastProduct(t *testing.T) {
 		mockStorage.On("GetLastProduct", mock.Anything, receptionID).
 			Return(storage.Product{ID: productID}, nil)
 
-		mockStorage.On("DeleteProduct", mock.Anything, productID).
+		employeeID := uuid.New()
+		mockStorage.On("DeleteProduct", mock.Anything, productID, &employeeID).
 			Return(nil)
+		expectAudit(mockStorage, "product.delete")
 
 		req := httptest.NewRequest("POST", "/pvz/"+pvzID.String()+"/delete_last_product", nil)
+		req = withUser(withAccessAll(req), employeeID)
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
 
@@ -131,6 +156,7 @@ func TestDeleteLastProduct(t *testing.T) {
 
 	t.Run("invalid pvz id", func(t *testing.T) {
 		req := httptest.NewRequest("POST", "/pvz/invalid/delete_last_product", nil)
+		req = withAccessAll(req)
 		w := httptest.NewRecorder()
 		handler(w, req)
 
@@ -146,6 +172,7 @@ func TestDeleteLastProduct(t *testing.T) {
 			Return(storage.Reception{}, storage.ErrNotFound)
 
 		req := httptest.NewRequest("POST", "/pvz/"+pvzID.String()+"/delete_last_product", nil)
+		req = withAccessAll(req)
 		w := httptest.NewRecorder()
 		handler(w, req)
 
@@ -166,6 +193,7 @@ func TestDeleteLastProduct(t *testing.T) {
 			Return(storage.Product{}, storage.ErrNotFound)
 
 		req := httptest.NewRequest("POST", "/pvz/"+pvzID.String()+"/delete_last_product", nil)
+		req = withAccessAll(req)
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
 
@@ -185,13 +213,27 @@ func TestDeleteLastProduct(t *testing.T) {
 		mockStorage.On("GetLastProduct", mock.Anything, receptionID).
 			Return(storage.Product{ID: productID}, nil)
 
-		mockStorage.On("DeleteProduct", mock.Anything, productID).
+		mockStorage.On("DeleteProduct", mock.Anything, productID, (*uuid.UUID)(nil)).
 			Return(errors.New("database error"))
 
 		req := httptest.NewRequest("POST", "/pvz/"+pvzID.String()+"/delete_last_product", nil)
+		req = withAccessAll(req)
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
 	})
+
+	t.Run("employee not assigned to pvz", func(t *testing.T) {
+		pvzID := uuid.New()
+		r := chi.NewRouter()
+		r.Post("/pvz/{pvzId}/delete_last_product", handler)
+
+		req := httptest.NewRequest("POST", "/pvz/"+pvzID.String()+"/delete_last_product", nil)
+		req = withEmployee(req)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
 }
@@ -2,18 +2,31 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/mi4r/avito-pvz/internal/metrics"
+	"github.com/mi4r/avito-pvz/internal/rbac"
 	"github.com/mi4r/avito-pvz/internal/storage"
 )
 
 func CreatePVZ(db storage.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req struct {
-			City string `json:"city"`
+			City         string               `json:"city"`
+			Code         *string              `json:"code"`
+			Address      string               `json:"address"`
+			Latitude     *float64             `json:"latitude"`
+			Longitude    *float64             `json:"longitude"`
+			Timezone     string               `json:"timezone"`
+			Phone        string               `json:"phone"`
+			WorkingHours storage.WorkingHours `json:"workingHours"`
 		}
 
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -21,29 +34,214 @@ func CreatePVZ(db storage.Storage) http.HandlerFunc {
 			return
 		}
 
-		// Check user role
-		role := r.Context().Value("role").(string)
-		if role != "moderator" {
-			respondError(w, http.StatusForbidden, "only moderators can create PVZ")
+		pvz := storage.PVZ{
+			City:         req.City,
+			Code:         req.Code,
+			Address:      strings.TrimSpace(req.Address),
+			Latitude:     req.Latitude,
+			Longitude:    req.Longitude,
+			Timezone:     req.Timezone,
+			Phone:        req.Phone,
+			WorkingHours: req.WorkingHours,
+		}
+		if err := pvz.Validate(); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		pvz, err := db.CreatePVZ(r.Context(), req.City)
+		pvz, err := db.CreatePVZ(r.Context(), pvz)
 		if err != nil {
-			switch err {
-			case storage.ErrInvalidCity:
+			switch {
+			case errors.Is(err, storage.ErrInvalidCity):
 				respondError(w, http.StatusBadRequest, err.Error())
+			case errors.Is(err, storage.ErrPVZCodeExists):
+				respondError(w, http.StatusConflict, err.Error())
 			default:
 				respondError(w, http.StatusInternalServerError, "failed to create PVZ")
 			}
 			return
 		}
 
+		recordAudit(r, db, "pvz.create", auditPVZ, pvz.ID.String(), nil, pvz)
 		metrics.PVZCreated.Inc()
 		respondJSON(w, http.StatusCreated, pvz)
 	}
 }
 
+func GetPVZ(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pvzID, ok := accessiblePVZ(w, r)
+		if !ok {
+			return
+		}
+
+		pvz, err := db.GetPVZ(r.Context(), pvzID)
+		if err != nil {
+			respondPVZError(w, err, "failed to get PVZ")
+			return
+		}
+		respondJSON(w, http.StatusOK, pvz)
+	}
+}
+
+// UpdatePVZ changes the fields present in the request. workingHours
+// replaces the whole week, an empty code removes the code.
+func UpdatePVZ(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pvzID, ok := accessiblePVZ(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Code         *string               `json:"code"`
+			Address      *string               `json:"address"`
+			Latitude     optional[float64]     `json:"latitude"`
+			Longitude    optional[float64]     `json:"longitude"`
+			Timezone     *string               `json:"timezone"`
+			Phone        *string               `json:"phone"`
+			WorkingHours *storage.WorkingHours `json:"workingHours"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		before, err := db.GetPVZ(r.Context(), pvzID)
+		if err != nil {
+			respondPVZError(w, err, "failed to update PVZ")
+			return
+		}
+
+		pvz := before
+		if req.Code != nil {
+			pvz.Code = req.Code
+			if *req.Code == "" {
+				pvz.Code = nil
+			}
+		}
+		if req.Address != nil {
+			pvz.Address = strings.TrimSpace(*req.Address)
+		}
+		if req.Latitude.Set {
+			pvz.Latitude = req.Latitude.Value
+		}
+		if req.Longitude.Set {
+			pvz.Longitude = req.Longitude.Value
+		}
+		// A null for either coordinate clears both, they only exist together
+		if req.Latitude.Set && req.Latitude.Value == nil || req.Longitude.Set && req.Longitude.Value == nil {
+			if req.Latitude.Value != nil || req.Longitude.Value != nil {
+				respondError(w, http.StatusBadRequest, "latitude and longitude are cleared together")
+				return
+			}
+			pvz.Latitude, pvz.Longitude = nil, nil
+		}
+		if req.Timezone != nil {
+			pvz.Timezone = *req.Timezone
+			if pvz.Timezone == "" {
+				respondError(w, http.StatusBadRequest, "timezone can't be empty")
+				return
+			}
+		}
+		if req.Phone != nil {
+			pvz.Phone = *req.Phone
+		}
+		if req.WorkingHours != nil {
+			pvz.WorkingHours = *req.WorkingHours
+		}
+		if err := pvz.Validate(); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		pvz, err = db.UpdatePVZ(r.Context(), pvz)
+		if err != nil {
+			if errors.Is(err, storage.ErrPVZCodeExists) {
+				respondError(w, http.StatusConflict, err.Error())
+				return
+			}
+			respondPVZError(w, err, "failed to update PVZ")
+			return
+		}
+		recordAudit(r, db, "pvz.update", auditPVZ, pvz.ID.String(), before, pvz)
+		respondJSON(w, http.StatusOK, pvz)
+	}
+}
+
+// GetNearbyPVZs lists the PVZs closest to lat/lon, nearest first.
+// Callers without pvz:access_all only find the PVZs they are assigned to.
+func GetNearbyPVZs(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := storage.NearbyFilter{
+			Radius: storage.DefaultNearbyRadius,
+			Limit:  storage.DefaultNearbyLimit,
+			City:   query.Get("city"),
+			Status: query.Get("status"),
+		}
+
+		var err error
+		if filter.Latitude, err = strconv.ParseFloat(query.Get("lat"), 64); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid lat")
+			return
+		}
+		if filter.Longitude, err = strconv.ParseFloat(query.Get("lon"), 64); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid lon")
+			return
+		}
+		if v := query.Get("radius"); v != "" {
+			if filter.Radius, err = strconv.ParseFloat(v, 64); err != nil {
+				respondError(w, http.StatusBadRequest, "invalid radius")
+				return
+			}
+		}
+		if v := query.Get("limit"); v != "" {
+			if filter.Limit, err = strconv.Atoi(v); err != nil {
+				respondError(w, http.StatusBadRequest, "invalid limit")
+				return
+			}
+		}
+		if err := filter.Validate(); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if !rbac.HasPermission(r.Context(), rbac.PVZAccessAll) {
+			filter.PVZIDs = assignedPVZs(r)
+		}
+
+		pvzs, err := db.FindNearbyPVZs(r.Context(), filter)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to find nearby PVZs")
+			return
+		}
+		respondJSON(w, http.StatusOK, pvzs)
+	}
+}
+
+// accessiblePVZ parses the pvzId URL param and checks that the caller may
+// work with the PVZ. On failure it writes the error response.
+func accessiblePVZ(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	pvzID, err := uuid.Parse(chi.URLParam(r, "pvzId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid pvz id")
+		return uuid.Nil, false
+	}
+	if !pvzAccessible(r, pvzID) {
+		respondError(w, http.StatusForbidden, "no access to this PVZ")
+		return uuid.Nil, false
+	}
+	return pvzID, true
+}
+
+func respondPVZError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, storage.ErrNotFound) {
+		respondError(w, http.StatusNotFound, "PVZ not found")
+		return
+	}
+	respondError(w, http.StatusInternalServerError, message)
+}
+
 func GetPVZs(db storage.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Parse pagination
@@ -56,24 +254,87 @@ func GetPVZs(db storage.Storage) http.HandlerFunc {
 			limit = 10
 		}
 
-		// Parse date filters
-		var startDate time.Time
-		var endDate time.Time = time.Now()
-		if sd := r.URL.Query().Get("startDate"); sd != "" {
-			startDate, _ = time.Parse(time.RFC3339, sd)
+		// Parse date filters, a missing date leaves that side open
+		var startDate, endDate time.Time
+		for name, dest := range map[string]*time.Time{"startDate": &startDate, "endDate": &endDate} {
+			if v := r.URL.Query().Get(name); v != "" {
+				t, err := time.Parse(time.RFC3339, v)
+				if err != nil {
+					respondError(w, http.StatusBadRequest, "invalid "+name)
+					return
+				}
+				*dest = t.UTC()
+			}
+		}
+		if !startDate.IsZero() && !endDate.IsZero() && startDate.After(endDate) {
+			respondError(w, http.StatusBadRequest, "startDate is after endDate")
+			return
+		}
+
+		filter := storage.PVZFilter{
+			StartDate:       startDate,
+			EndDate:         endDate,
+			Page:            page,
+			Limit:           limit,
+			City:            r.URL.Query().Get("city"),
+			ReceptionStatus: r.URL.Query().Get("receptionStatus"),
+			ProductType:     r.URL.Query().Get("productType"),
+			IncludeDeleted:  r.URL.Query().Get("includeDeleted") == "true",
+		}
+		filter.Status = r.URL.Query().Get("status")
+		if filter.Status != "" && !slices.Contains(storage.PVZStatuses, filter.Status) {
+			respondError(w, http.StatusBadRequest, "invalid status")
+			return
+		}
+		if filter.ReceptionStatus != "" && !slices.Contains(storage.ReceptionStatuses, filter.ReceptionStatus) {
+			respondError(w, http.StatusBadRequest, "invalid receptionStatus")
+			return
+		}
+		if filter.ProductType != "" && !slices.Contains(storage.ProductTypes, filter.ProductType) {
+			respondError(w, http.StatusBadRequest, "invalid productType")
+			return
+		}
+		if v := r.URL.Query().Get("withReceptions"); v != "" {
+			withReceptions, err := strconv.ParseBool(v)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, "invalid withReceptions")
+				return
+			}
+			filter.WithReceptions = withReceptions
 		}
-		if ed := r.URL.Query().Get("endDate"); ed != "" {
-			endDate, _ = time.Parse(time.RFC3339, ed)
+		if employee := r.URL.Query().Get("employeeId"); employee != "" {
+			employeeID, err := uuid.Parse(employee)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, "invalid employee id")
+				return
+			}
+			filter.CreatedBy = &employeeID
 		}
 
-		// Check user role
-		role := r.Context().Value("role").(string)
-		if role != "moderator" && role != "employee" {
-			respondError(w, http.StatusForbidden, "access denied")
+		// Callers without pvz:access_all only see the PVZs they work at,
+		// others see all unless they ask for their own
+		accessAll := rbac.HasPermission(r.Context(), rbac.PVZAccessAll)
+		scope := r.URL.Query().Get("scope")
+		if scope == "" {
+			scope = "assigned"
+			if accessAll {
+				scope = "all"
+			}
+		}
+		switch scope {
+		case "assigned":
+			filter.PVZIDs = assignedPVZs(r)
+		case "all":
+			if !accessAll {
+				respondError(w, http.StatusForbidden, "only assigned PVZ are available")
+				return
+			}
+		default:
+			respondError(w, http.StatusBadRequest, "invalid scope")
 			return
 		}
 
-		result, err := db.GetPVZsWithReceptions(r.Context(), startDate, endDate, page, limit)
+		result, err := db.GetPVZsWithReceptions(r.Context(), filter)
 		if err != nil {
 			respondError(w, http.StatusInternalServerError, "failed to get PVZ data")
 			return
@@ -91,6 +352,9 @@ func GetPVZs(db storage.Storage) http.HandlerFunc {
 						CreatedAt:   p.CreatedAt,
 						Type:        p.Type,
 						ReceptionID: p.ReceptionID,
+						CreatedBy:   p.CreatedBy,
+						DeletedAt:   p.DeletedAt,
+						DeletedBy:   p.DeletedBy,
 					})
 				}
 
@@ -100,17 +364,15 @@ func GetPVZs(db storage.Storage) http.HandlerFunc {
 						CreatedAt: rec.Reception.CreatedAt,
 						PVZID:     rec.Reception.PVZID,
 						Status:    rec.Reception.Status,
+						CreatedBy: rec.Reception.CreatedBy,
+						ClosedBy:  rec.Reception.ClosedBy,
 					},
 					Products: products,
 				})
 			}
 
 			response = append(response, storage.PVZWithReceptions{
-				PVZ: storage.PVZ{
-					ID:               pvzWithRec.PVZ.ID,
-					RegistrationDate: pvzWithRec.PVZ.RegistrationDate,
-					City:             pvzWithRec.PVZ.City,
-				},
+				PVZ:        pvzWithRec.PVZ,
 				Receptions: receptions,
 			})
 		}
@@ -118,3 +380,20 @@ func GetPVZs(db storage.Storage) http.HandlerFunc {
 		respondJSON(w, http.StatusOK, response)
 	}
 }
+
+// optional is a PATCH field that tells a missing value from an explicit
+// null: Set is true for both, Value is nil for null.
+type optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (o *optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+	o.Value = new(T)
+	return json.Unmarshal(data, o.Value)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/mi4r/avito-pvz/internal/metrics"
+	"github.com/mi4r/avito-pvz/internal/pvzimport"
+	"github.com/mi4r/avito-pvz/internal/storage"
+)
+
+const maxImportSize = 10 << 20
+
+// ImportPVZs creates PVZs from a CSV (text/csv) or JSON (application/json)
+// body. With dryRun nothing is stored, with partial the valid rows are
+// stored even if others fail. The per-row report is returned with 200,
+// or 422 when failed rows rolled the import back.
+func ImportPVZs(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var opts storage.ImportOptions
+		for name, dest := range map[string]*bool{"dryRun": &opts.DryRun, "partial": &opts.Partial} {
+			if v := r.URL.Query().Get(name); v != "" {
+				b, err := strconv.ParseBool(v)
+				if err != nil {
+					respondError(w, http.StatusBadRequest, "invalid "+name)
+					return
+				}
+				*dest = b
+			}
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		format := map[string]string{
+			"text/csv":         pvzimport.FormatCSV,
+			"application/json": pvzimport.FormatJSON,
+		}[mediaType]
+		if format == "" {
+			respondError(w, http.StatusUnsupportedMediaType, "body must be text/csv or application/json")
+			return
+		}
+
+		rows, err := pvzimport.Parse(format, http.MaxBytesReader(w, r.Body, maxImportSize))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				respondError(w, http.StatusRequestEntityTooLarge, "import file is too large")
+				return
+			}
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if len(rows) == 0 {
+			respondError(w, http.StatusBadRequest, "import file has no rows")
+			return
+		}
+
+		report, err := db.ImportPVZs(r.Context(), rows, opts)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to import PVZs")
+			return
+		}
+
+		if !report.Committed {
+			status := http.StatusOK
+			if !opts.DryRun {
+				status = http.StatusUnprocessableEntity
+			}
+			respondJSON(w, status, report)
+			return
+		}
+		recordImportAudit(r, db, report)
+		metrics.PVZCreated.Add(float64(report.Imported))
+		respondJSON(w, http.StatusOK, report)
+	}
+}
+
+// recordImportAudit writes an entry per imported PVZ in one batch. The
+// PVZs are already stored, so it isn't cut short if the client goes away.
+func recordImportAudit(r *http.Request, db storage.Storage, report storage.ImportReport) {
+	entries := make([]storage.AuditEntry, 0, report.Imported)
+	for _, row := range report.Rows {
+		if row.PVZ == nil {
+			continue
+		}
+		entry, err := auditEntry(r, "pvz.import", auditPVZ, row.PVZ.ID.String(), nil, row.PVZ)
+		if err != nil {
+			metrics.AuditFailures.Inc()
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return
+	}
+	if _, err := db.AppendAuditBatch(context.WithoutCancel(r.Context()), entries); err != nil {
+		metrics.AuditFailures.Add(float64(len(entries)))
+	}
+}
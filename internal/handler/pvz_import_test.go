@@ -0,0 +1,112 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/handler"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestImportPVZs(t *testing.T) {
+	mockRepo := mocks.NewStorage(t)
+	csvFile := "city,code,mon\nКазань,KZN-7,09:00-21:00\nКазань,KZN-8,\n"
+
+	serve := func(target, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", target, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		handler.ImportPVZs(mockRepo)(w, req)
+		return w
+	}
+
+	t.Run("csv import", func(t *testing.T) {
+		created := storage.PVZ{ID: uuid.New(), City: "Казань"}
+		mockRepo.On("ImportPVZs", mock.Anything, mock.MatchedBy(func(rows []storage.ImportRow) bool {
+			return len(rows) == 2 && *rows[0].PVZ.Code == "KZN-7" && rows[0].PVZ.WorkingHours["mon"].Close == "21:00"
+		}), storage.ImportOptions{}).Return(storage.ImportReport{
+			Imported:  2,
+			Committed: true,
+			Rows:      []storage.ImportResult{{Row: 1, PVZ: &created}, {Row: 2, PVZ: &created}},
+		}, nil).Once()
+		mockRepo.On("AppendAuditBatch", mock.Anything, mock.MatchedBy(func(entries []storage.AuditEntry) bool {
+			return len(entries) == 2 && entries[0].Action == "pvz.import" && entries[1].EntityID == created.ID.String()
+		})).Return(nil, nil).Once()
+
+		w := serve("/pvz/import", "text/csv; charset=utf-8", csvFile)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var report storage.ImportReport
+		json.NewDecoder(w.Body).Decode(&report)
+		assert.Equal(t, 2, report.Imported)
+	})
+
+	t.Run("audit outlives the request", func(t *testing.T) {
+		created := storage.PVZ{ID: uuid.New(), City: "Казань"}
+		mockRepo.On("ImportPVZs", mock.Anything, mock.Anything, storage.ImportOptions{Partial: true}).Return(storage.ImportReport{
+			Imported:  1,
+			Committed: true,
+			Rows:      []storage.ImportResult{{Row: 1, PVZ: &created}},
+		}, nil).Once()
+		mockRepo.On("AppendAuditBatch", mock.MatchedBy(func(ctx context.Context) bool {
+			return ctx.Err() == nil
+		}), mock.Anything).Return(nil, nil).Once()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		req := httptest.NewRequest("POST", "/pvz/import?partial=true", bytes.NewBufferString(csvFile)).WithContext(ctx)
+		req.Header.Set("Content-Type", "text/csv")
+		w := httptest.NewRecorder()
+		cancel()
+		handler.ImportPVZs(mockRepo)(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("failed rows", func(t *testing.T) {
+		mockRepo.On("ImportPVZs", mock.Anything, mock.Anything, storage.ImportOptions{}).Return(storage.ImportReport{
+			Imported: 1,
+			Failed:   1,
+			Rows:     []storage.ImportResult{{Row: 1}, {Row: 2, Error: storage.ErrPVZCodeExists.Error()}},
+		}, nil).Once()
+
+		w := serve("/pvz/import", "application/json", `[{"city":"Казань"},{"city":"Казань"}]`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), storage.ErrPVZCodeExists.Error())
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		mockRepo.On("ImportPVZs", mock.Anything, mock.Anything, storage.ImportOptions{DryRun: true, Partial: true}).
+			Return(storage.ImportReport{Imported: 2}, nil).Once()
+
+		w := serve("/pvz/import?dryRun=true&partial=1", "text/csv", csvFile)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		w := serve("/pvz/import", "application/vnd.ms-excel", csvFile)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	t.Run("malformed file", func(t *testing.T) {
+		w := serve("/pvz/import", "text/csv", "region\nТатарстан\n")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid flag", func(t *testing.T) {
+		w := serve("/pvz/import?dryRun=maybe", "text/csv", csvFile)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
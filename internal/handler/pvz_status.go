@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/storage"
+)
+
+const maxStatusReasonLength = 500
+
+// ClosePVZ temporarily closes the PVZ. With until the reopening is
+// planned in the same request.
+func ClosePVZ(db storage.Storage) http.HandlerFunc {
+	return changePVZStatus(db, storage.PVZTemporarilyClosed, "pvz.close")
+}
+
+func ReopenPVZ(db storage.Storage) http.HandlerFunc {
+	return changePVZStatus(db, storage.PVZActive, "pvz.reopen")
+}
+
+// DecommissionPVZ retires the PVZ for good.
+func DecommissionPVZ(db storage.Storage) http.HandlerFunc {
+	return changePVZStatus(db, storage.PVZDecommissioned, "pvz.decommission")
+}
+
+// changePVZStatus moves the PVZ to status from effectiveFrom, now if it's
+// omitted. A reason is required for everything but reopening.
+func changePVZStatus(db storage.Storage, status, action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pvzID, ok := accessiblePVZ(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Reason        string     `json:"reason"`
+			EffectiveFrom *time.Time `json:"effectiveFrom"`
+			Until         *time.Time `json:"until"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		reason := strings.TrimSpace(req.Reason)
+		if reason == "" && status != storage.PVZActive {
+			respondError(w, http.StatusBadRequest, "reason is required")
+			return
+		}
+		if utf8.RuneCountInString(reason) > maxStatusReasonLength {
+			respondError(w, http.StatusBadRequest, "reason is too long")
+			return
+		}
+
+		now := time.Now().UTC().Truncate(time.Microsecond)
+		from := now
+		if req.EffectiveFrom != nil {
+			from = req.EffectiveFrom.UTC().Truncate(time.Microsecond)
+			if from.Before(now) {
+				respondError(w, http.StatusBadRequest, "effectiveFrom can't be in the past")
+				return
+			}
+		}
+
+		changes := []storage.PVZStatusChange{{
+			Status:        status,
+			Reason:        reason,
+			EffectiveFrom: from,
+			ChangedBy:     actorID(r),
+		}}
+		if req.Until != nil {
+			until := req.Until.UTC().Truncate(time.Microsecond)
+			if status != storage.PVZTemporarilyClosed || !until.After(from) {
+				respondError(w, http.StatusBadRequest, "until must be after effectiveFrom and is only allowed when closing")
+				return
+			}
+			changes = append(changes, storage.PVZStatusChange{
+				Status:        storage.PVZActive,
+				EffectiveFrom: until,
+				ChangedBy:     actorID(r),
+			})
+		}
+
+		changes, err := db.ChangePVZStatus(r.Context(), pvzID, changes)
+		if err != nil {
+			if errors.Is(err, storage.ErrInvalidTransition) {
+				respondError(w, http.StatusConflict, err.Error())
+				return
+			}
+			respondPVZError(w, err, "failed to change PVZ status")
+			return
+		}
+
+		recordAudit(r, db, action, auditPVZ, pvzID.String(), nil, changes)
+		respondJSON(w, http.StatusCreated, changes)
+	}
+}
+
+// PVZStatusHistory lists the status changes of the PVZ, planned ones
+// included.
+func PVZStatusHistory(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pvzID, ok := accessiblePVZ(w, r)
+		if !ok {
+			return
+		}
+
+		changes, err := db.ListPVZStatusChanges(r.Context(), pvzID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to get PVZ status history")
+			return
+		}
+		respondJSON(w, http.StatusOK, changes)
+	}
+}
+
+// pvzOpen checks that receptions can be opened and filled at the PVZ: it
+// has to be active and open by its schedule or a moderator override. On
+// failure it writes the error response.
+func pvzOpen(w http.ResponseWriter, r *http.Request, db storage.Storage, pvzID uuid.UUID) bool {
+	pvz, err := db.GetPVZ(r.Context(), pvzID)
+	if err != nil {
+		respondPVZError(w, err, "failed to get PVZ")
+		return false
+	}
+	if pvz.Status != storage.PVZActive {
+		respondError(w, http.StatusConflict, "PVZ is not active: "+pvz.Status)
+		return false
+	}
+	if !pvz.IsOpenNow {
+		respondError(w, http.StatusConflict, "PVZ is closed by its schedule")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,159 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/handler"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// expectOpenPVZ mocks the status and schedule check done before
+// receptions and products are added.
+func expectOpenPVZ(m *mocks.Storage, pvzID uuid.UUID) *mock.Call {
+	return m.On("GetPVZ", mock.Anything, pvzID).
+		Return(storage.PVZ{ID: pvzID, Status: storage.PVZActive, IsOpenNow: true}, nil).Once()
+}
+
+func TestPVZStatusTransitions(t *testing.T) {
+	mockRepo := mocks.NewStorage(t)
+	r := chi.NewRouter()
+	r.Post("/pvz/{pvzId}/close", handler.ClosePVZ(mockRepo))
+	r.Post("/pvz/{pvzId}/reopen", handler.ReopenPVZ(mockRepo))
+	r.Post("/pvz/{pvzId}/decommission", handler.DecommissionPVZ(mockRepo))
+	r.Get("/pvz/{pvzId}/status_history", handler.PVZStatusHistory(mockRepo))
+
+	pvzID := uuid.New()
+	moderatorID := uuid.New()
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/pvz/"+pvzID.String()+path, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, withUser(withAccessAll(req), moderatorID))
+		return w
+	}
+
+	t.Run("close now", func(t *testing.T) {
+		mockRepo.On("ChangePVZStatus", mock.Anything, pvzID, mock.MatchedBy(func(c []storage.PVZStatusChange) bool {
+			return len(c) == 1 && c[0].Status == storage.PVZTemporarilyClosed && c[0].Reason == "ремонт" &&
+				time.Since(c[0].EffectiveFrom) < time.Minute && *c[0].ChangedBy == moderatorID
+		})).Return([]storage.PVZStatusChange{{PVZID: pvzID, Status: storage.PVZTemporarilyClosed}}, nil).Once()
+		expectAudit(mockRepo, "pvz.close")
+
+		w := serve("POST", "/close", `{"reason":" ремонт "}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var changes []storage.PVZStatusChange
+		json.NewDecoder(w.Body).Decode(&changes)
+		assert.Len(t, changes, 1)
+	})
+
+	t.Run("close with planned reopening", func(t *testing.T) {
+		from := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+		until := from.Add(72 * time.Hour)
+		mockRepo.On("ChangePVZStatus", mock.Anything, pvzID, mock.MatchedBy(func(c []storage.PVZStatusChange) bool {
+			return len(c) == 2 && c[0].EffectiveFrom.Equal(from) &&
+				c[1].Status == storage.PVZActive && c[1].EffectiveFrom.Equal(until)
+		})).Return([]storage.PVZStatusChange{{}, {}}, nil).Once()
+		expectAudit(mockRepo, "pvz.close")
+
+		body := `{"reason":"инвентаризация","effectiveFrom":"` + from.Format(time.RFC3339) +
+			`","until":"` + until.Format(time.RFC3339) + `"}`
+		w := serve("POST", "/close", body)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("close without reason", func(t *testing.T) {
+		w := serve("POST", "/close", `{}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("effective date in the past", func(t *testing.T) {
+		w := serve("POST", "/decommission", `{"reason":"аренда","effectiveFrom":"2020-01-01T00:00:00Z"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("until when decommissioning", func(t *testing.T) {
+		until := time.Now().Add(time.Hour).Format(time.RFC3339)
+		w := serve("POST", "/decommission", `{"reason":"аренда","until":"`+until+`"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("reopen decommissioned pvz", func(t *testing.T) {
+		mockRepo.On("ChangePVZStatus", mock.Anything, pvzID, mock.Anything).
+			Return(nil, storage.ErrInvalidTransition).Once()
+
+		w := serve("POST", "/reopen", `{}`)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("unknown pvz", func(t *testing.T) {
+		mockRepo.On("ChangePVZStatus", mock.Anything, pvzID, mock.Anything).
+			Return(nil, storage.ErrNotFound).Once()
+
+		w := serve("POST", "/decommission", `{"reason":"аренда"}`)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("status history", func(t *testing.T) {
+		mockRepo.On("ListPVZStatusChanges", mock.Anything, pvzID).
+			Return([]storage.PVZStatusChange{{PVZID: pvzID, Status: storage.PVZTemporarilyClosed}}, nil).Once()
+
+		w := serve("GET", "/status_history", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestInactivePVZ(t *testing.T) {
+	mockRepo := mocks.NewStorage(t)
+	pvzID := uuid.New()
+	mockRepo.On("GetPVZ", mock.Anything, pvzID).
+		Return(storage.PVZ{ID: pvzID, Status: storage.PVZTemporarilyClosed}, nil)
+
+	body := `{"pvzId":"` + pvzID.String() + `","type":"обувь"}`
+
+	t.Run("no new receptions", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.CreateReception(mockRepo)(w, withAccessAll(httptest.NewRequest("POST", "/receptions", bytes.NewBufferString(body))))
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), storage.PVZTemporarilyClosed)
+	})
+
+	t.Run("no new products", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.AddProduct(mockRepo)(w, withAccessAll(httptest.NewRequest("POST", "/products", bytes.NewBufferString(body))))
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestPVZClosedBySchedule(t *testing.T) {
+	mockRepo := mocks.NewStorage(t)
+	pvzID := uuid.New()
+	mockRepo.On("GetPVZ", mock.Anything, pvzID).
+		Return(storage.PVZ{ID: pvzID, Status: storage.PVZActive}, nil).Once()
+
+	body := `{"pvzId":"` + pvzID.String() + `","type":"обувь"}`
+	w := httptest.NewRecorder()
+	handler.CreateReception(mockRepo)(w, withAccessAll(httptest.NewRequest("POST", "/receptions", bytes.NewBufferString(body))))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "schedule")
+}
@@ -7,12 +7,14 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/mi4r/avito-pvz/internal/handler"
+	"github.com/mi4r/avito-pvz/internal/rbac"
 	"github.com/mi4r/avito-pvz/internal/storage"
 	"github.com/mi4r/avito-pvz/internal/storage/mocks"
 	"github.com/stretchr/testify/assert"
@@ -32,12 +34,13 @@ func TestCreatePVZ(t *testing.T) {
 		}
 
 		// Mock setup
-		mockPVZRepo.On("CreatePVZ", mock.Anything, expectedCity).
-			Return(expectedPVZ, nil)
+		mockPVZRepo.On("CreatePVZ", mock.Anything, storage.PVZ{City: expectedCity}).
+			Return(expectedPVZ, nil).Once()
+		expectAudit(mockPVZRepo, "pvz.create")
 
 		reqBody := []byte(`{"city":"Москва"}`)
 		req := httptest.NewRequest("POST", "/pvz", bytes.NewBuffer(reqBody))
-		req = req.WithContext(context.WithValue(req.Context(), "role", "moderator"))
+		req = withPrincipal(req, rbac.Principal{Role: "moderator"})
 
 		w := httptest.NewRecorder()
 		handler(w, req)
@@ -49,24 +52,13 @@ func TestCreatePVZ(t *testing.T) {
 		mockPVZRepo.AssertExpectations(t)
 	})
 
-	t.Run("forbidden for non-moderator", func(t *testing.T) {
-		reqBody := []byte(`{"city":"Москва"}`)
-		req := httptest.NewRequest("POST", "/pvz", bytes.NewBuffer(reqBody))
-		req = req.WithContext(context.WithValue(req.Context(), "role", "employee"))
-
-		w := httptest.NewRecorder()
-		handler(w, req)
-
-		assert.Equal(t, http.StatusForbidden, w.Code)
-	})
-
 	t.Run("invalid city", func(t *testing.T) {
-		mockPVZRepo.On("CreatePVZ", mock.Anything, "Новосибирск").
+		mockPVZRepo.On("CreatePVZ", mock.Anything, storage.PVZ{City: "Новосибирск"}).
 			Return(storage.PVZ{}, storage.ErrInvalidCity)
 
 		reqBody := []byte(`{"city":"Новосибирск"}`)
 		req := httptest.NewRequest("POST", "/pvz", bytes.NewBuffer(reqBody))
-		req = req.WithContext(context.WithValue(req.Context(), "role", "moderator"))
+		req = withPrincipal(req, rbac.Principal{Role: "moderator"})
 
 		w := httptest.NewRecorder()
 		handler(w, req)
@@ -77,23 +69,67 @@ func TestCreatePVZ(t *testing.T) {
 
 	t.Run("invalid request body", func(t *testing.T) {
 		req := httptest.NewRequest("POST", "/pvz", bytes.NewBuffer([]byte("{")))
-		req = req.WithContext(context.WithValue(req.Context(), "role", "moderator"))
+		req = withPrincipal(req, rbac.Principal{Role: "moderator"})
+
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("create pvz with profile", func(t *testing.T) {
+		mockPVZRepo.On("CreatePVZ", mock.Anything, mock.MatchedBy(func(p storage.PVZ) bool {
+			return p.City == "Казань" && *p.Code == "KZN-7" && p.Address == "ул. Баумана, 1" &&
+				*p.Latitude == 55.79 && p.Timezone == "Europe/Moscow" && p.WorkingHours["mon"].Open == "09:00"
+		})).Return(storage.PVZ{ID: uuid.New(), City: "Казань"}, nil).Once()
+		expectAudit(mockPVZRepo, "pvz.create")
+
+		reqBody := []byte(`{"city":"Казань","code":"KZN-7","address":" ул. Баумана, 1 ","latitude":55.79,"longitude":49.12,
+			"timezone":"Europe/Moscow","phone":"+78431234567","workingHours":{"mon":{"open":"09:00","close":"21:00"}}}`)
+		req := httptest.NewRequest("POST", "/pvz", bytes.NewBuffer(reqBody))
+		req = withPrincipal(req, rbac.Principal{Role: "moderator"})
+
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("invalid profile", func(t *testing.T) {
+		reqBody := []byte(`{"city":"Москва","latitude":55.75}`)
+		req := httptest.NewRequest("POST", "/pvz", bytes.NewBuffer(reqBody))
+		req = withPrincipal(req, rbac.Principal{Role: "moderator"})
 
 		w := httptest.NewRecorder()
 		handler(w, req)
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "latitude and longitude must be set together")
+	})
+
+	t.Run("duplicate code", func(t *testing.T) {
+		mockPVZRepo.On("CreatePVZ", mock.Anything, mock.MatchedBy(func(p storage.PVZ) bool {
+			return p.Code != nil && *p.Code == "MSK-1"
+		})).Return(storage.PVZ{}, storage.ErrPVZCodeExists).Once()
+
+		req := httptest.NewRequest("POST", "/pvz", bytes.NewBufferString(`{"city":"Москва","code":"MSK-1"}`))
+		req = withPrincipal(req, rbac.Principal{Role: "moderator"})
+
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
 	})
 
 	t.Run("storage error", func(t *testing.T) {
 		r := chi.NewRouter()
 		r.Post("/pvz", handler)
-		mockPVZRepo.On("CreatePVZ", mock.Anything, "Москва").
+		mockPVZRepo.On("CreatePVZ", mock.Anything, storage.PVZ{City: "Москва"}).
 			Return(storage.PVZ{}, errors.New("db error"))
 
 		reqBody := []byte(`{"city":"Москва"}`)
 		req := httptest.NewRequest("POST", "/pvz", bytes.NewBuffer(reqBody))
-		req = req.WithContext(context.WithValue(req.Context(), "role", "moderator"))
+		req = withPrincipal(req, rbac.Principal{Role: "moderator"})
 
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
@@ -102,6 +138,127 @@ func TestCreatePVZ(t *testing.T) {
 	})
 }
 
+func TestPVZProfile(t *testing.T) {
+	mockRepo := mocks.NewStorage(t)
+	r := chi.NewRouter()
+	r.Get("/pvz/{pvzId}", handler.GetPVZ(mockRepo))
+	r.Patch("/pvz/{pvzId}", handler.UpdatePVZ(mockRepo))
+
+	code := "MSK-1"
+	pvzID := uuid.New()
+	stored := storage.PVZ{
+		ID:       pvzID,
+		City:     "Москва",
+		Code:     &code,
+		Address:  "ул. Тверская, 1",
+		Timezone: storage.DefaultTimezone,
+	}
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("get pvz", func(t *testing.T) {
+		mockRepo.On("GetPVZ", mock.Anything, pvzID).Return(stored, nil).Once()
+
+		w := serve(withAccessAll(httptest.NewRequest("GET", "/pvz/"+pvzID.String(), nil)))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var pvz storage.PVZ
+		json.NewDecoder(w.Body).Decode(&pvz)
+		assert.Equal(t, "MSK-1", *pvz.Code)
+	})
+
+	t.Run("get unknown pvz", func(t *testing.T) {
+		unknownID := uuid.New()
+		mockRepo.On("GetPVZ", mock.Anything, unknownID).Return(storage.PVZ{}, storage.ErrNotFound).Once()
+
+		w := serve(withAccessAll(httptest.NewRequest("GET", "/pvz/"+unknownID.String(), nil)))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("get unassigned pvz", func(t *testing.T) {
+		w := serve(withEmployee(httptest.NewRequest("GET", "/pvz/"+pvzID.String(), nil), uuid.New()))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("update keeps fields missing from the request", func(t *testing.T) {
+		mockRepo.On("GetPVZ", mock.Anything, pvzID).Return(stored, nil).Once()
+		mockRepo.On("UpdatePVZ", mock.Anything, mock.MatchedBy(func(p storage.PVZ) bool {
+			return p.Code == nil && p.Address == stored.Address && p.Phone == "+74951234567" &&
+				p.WorkingHours["sat"] == storage.DayHours{Open: "10:00", Close: "16:00"}
+		})).Return(stored, nil).Once()
+		expectAudit(mockRepo, "pvz.update")
+
+		body := `{"code":"","phone":"+74951234567","workingHours":{"sat":{"open":"10:00","close":"16:00"}}}`
+		w := serve(withAccessAll(httptest.NewRequest("PATCH", "/pvz/"+pvzID.String(), bytes.NewBufferString(body))))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("update clears coordinates", func(t *testing.T) {
+		lat, lon := 55.7887, 49.1221
+		located := stored
+		located.Latitude, located.Longitude = &lat, &lon
+		mockRepo.On("GetPVZ", mock.Anything, pvzID).Return(located, nil).Once()
+		mockRepo.On("UpdatePVZ", mock.Anything, mock.MatchedBy(func(p storage.PVZ) bool {
+			return p.Latitude == nil && p.Longitude == nil && p.Address == stored.Address
+		})).Return(stored, nil).Once()
+		expectAudit(mockRepo, "pvz.update")
+
+		body := `{"latitude":null}`
+		w := serve(withAccessAll(httptest.NewRequest("PATCH", "/pvz/"+pvzID.String(), bytes.NewBufferString(body))))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("update keeps coordinates missing from the request", func(t *testing.T) {
+		lat, lon := 55.7887, 49.1221
+		located := stored
+		located.Latitude, located.Longitude = &lat, &lon
+		mockRepo.On("GetPVZ", mock.Anything, pvzID).Return(located, nil).Once()
+		mockRepo.On("UpdatePVZ", mock.Anything, mock.MatchedBy(func(p storage.PVZ) bool {
+			return p.Latitude != nil && *p.Latitude == 56.0 && p.Longitude != nil && *p.Longitude == lon
+		})).Return(located, nil).Once()
+		expectAudit(mockRepo, "pvz.update")
+
+		w := serve(withAccessAll(httptest.NewRequest("PATCH", "/pvz/"+pvzID.String(), bytes.NewBufferString(`{"latitude":56}`))))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("update clears only one coordinate", func(t *testing.T) {
+		mockRepo.On("GetPVZ", mock.Anything, pvzID).Return(stored, nil).Once()
+
+		body := `{"latitude":null,"longitude":49.1}`
+		w := serve(withAccessAll(httptest.NewRequest("PATCH", "/pvz/"+pvzID.String(), bytes.NewBufferString(body))))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("update with invalid hours", func(t *testing.T) {
+		mockRepo.On("GetPVZ", mock.Anything, pvzID).Return(stored, nil).Once()
+
+		body := `{"workingHours":{"mon":{"open":"21:00","close":"09:00"}}}`
+		w := serve(withAccessAll(httptest.NewRequest("PATCH", "/pvz/"+pvzID.String(), bytes.NewBufferString(body))))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("update to a taken code", func(t *testing.T) {
+		mockRepo.On("GetPVZ", mock.Anything, pvzID).Return(stored, nil).Once()
+		mockRepo.On("UpdatePVZ", mock.Anything, mock.Anything).Return(storage.PVZ{}, storage.ErrPVZCodeExists).Once()
+
+		w := serve(withAccessAll(httptest.NewRequest("PATCH", "/pvz/"+pvzID.String(), bytes.NewBufferString(`{"code":"SPB-2"}`))))
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
 func TestGetPVZs(t *testing.T) {
 	mockPVZRepo := new(mocks.Storage)
 	handler := handler.GetPVZs(mockPVZRepo)
@@ -136,11 +293,12 @@ func TestGetPVZs(t *testing.T) {
 		}
 
 		// Mock setup
-		mockPVZRepo.On("GetPVZsWithReceptions", mock.Anything, mock.Anything, mock.Anything, 1, 10).
-			Return(expectedPVZs, nil)
+		mockPVZRepo.On("GetPVZsWithReceptions", mock.Anything, mock.MatchedBy(func(f storage.PVZFilter) bool {
+			return f.Page == 1 && f.Limit == 10 && f.PVZIDs == nil
+		})).Return(expectedPVZs, nil)
 
 		req := httptest.NewRequest("GET", "/pvz?page=1&limit=10", nil)
-		req = req.WithContext(context.WithValue(req.Context(), "role", "moderator"))
+		req = withAccessAll(req)
 
 		w := httptest.NewRecorder()
 		handler(w, req)
@@ -155,16 +313,6 @@ func TestGetPVZs(t *testing.T) {
 		mockPVZRepo.AssertExpectations(t)
 	})
 
-	t.Run("access denied for client role", func(t *testing.T) {
-		req := httptest.NewRequest("GET", "/pvz", nil)
-		req = req.WithContext(context.WithValue(req.Context(), "role", "client"))
-
-		w := httptest.NewRecorder()
-		handler(w, req)
-
-		assert.Equal(t, http.StatusForbidden, w.Code)
-	})
-
 	t.Run("invalid pagination params", func(t *testing.T) {
 		testCases := []struct {
 			query string
@@ -183,14 +331,13 @@ func TestGetPVZs(t *testing.T) {
 				// Setup mock for this specific test case with correct parameter types
 				mockPVZRepo.On("GetPVZsWithReceptions",
 					mock.MatchedBy(func(ctx context.Context) bool { return true }), // context
-					mock.MatchedBy(func(t time.Time) bool { return true }),         // startDate
-					mock.MatchedBy(func(t time.Time) bool { return true }),         // endDate
-					tc.page,  // page
-					tc.limit, // limit
+					mock.MatchedBy(func(f storage.PVZFilter) bool {
+						return f.Page == tc.page && f.Limit == tc.limit
+					}),
 				).Return([]storage.PVZWithReceptions{}, nil)
 
 				req := httptest.NewRequest("GET", "/pvz?"+tc.query, nil)
-				req = req.WithContext(context.WithValue(req.Context(), "role", "employee"))
+				req = withPrincipal(req, rbac.Principal{Role: "employee"})
 
 				w := httptest.NewRecorder()
 				handler(w, req)
@@ -206,14 +353,13 @@ func TestGetPVZs(t *testing.T) {
 		end := time.Now()
 
 		req := httptest.NewRequest("GET", "/pvz?startDate="+start.Format(time.RFC3339)+"&endDate="+end.Format(time.RFC3339), nil)
-		req = req.WithContext(context.WithValue(req.Context(), "role", "moderator"))
+		req = withPrincipal(req, rbac.Principal{Role: "moderator"})
 
 		mockPVZRepo.On("GetPVZsWithReceptions",
 			mock.MatchedBy(func(ctx context.Context) bool { return true }), // context
-			start, // startDate
-			end,   // endDate
-			1,     // page
-			10,    // limit
+			mock.MatchedBy(func(f storage.PVZFilter) bool {
+				return f.StartDate.Equal(start.Truncate(time.Second)) && f.EndDate.Equal(end.Truncate(time.Second))
+			}),
 		).Return([]storage.PVZWithReceptions{}, nil)
 
 		w := httptest.NewRecorder()
@@ -225,17 +371,232 @@ func TestGetPVZs(t *testing.T) {
 	t.Run("storage error", func(t *testing.T) {
 		mockPVZRepo.On("GetPVZsWithReceptions",
 			mock.MatchedBy(func(ctx context.Context) bool { return true }), // context
-			mock.MatchedBy(func(t time.Time) bool { return true }),         // startDate
-			mock.MatchedBy(func(t time.Time) bool { return true }),         // endDate
-			1,  // page
-			10, // limit
+			mock.MatchedBy(func(f storage.PVZFilter) bool { return f.Page == 1 && f.Limit == 10 }),
 		).Return(nil, errors.New("db error"))
 
 		req := httptest.NewRequest("GET", "/pvz", nil)
-		req = req.WithContext(context.WithValue(req.Context(), "role", "moderator"))
+		req = withPrincipal(req, rbac.Principal{Role: "moderator"})
+
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+	})
+
+	t.Run("employee sees only assigned pvzs", func(t *testing.T) {
+		mockPVZRepo.ExpectedCalls = nil
+		assigned := []uuid.UUID{uuid.New()}
+
+		mockPVZRepo.On("GetPVZsWithReceptions", mock.Anything, mock.MatchedBy(func(f storage.PVZFilter) bool {
+			return len(f.PVZIDs) == 1 && f.PVZIDs[0] == assigned[0]
+		})).Return([]storage.PVZWithReceptions{}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/pvz", nil)
+		req = withEmployee(req, assigned...)
 
 		w := httptest.NewRecorder()
 		handler(w, req)
 
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockPVZRepo.AssertExpectations(t)
+	})
+
+	t.Run("caller without pvz:access_all can't request all pvzs", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/pvz?scope=all", nil)
+		req = withPrincipal(req, rbac.Principal{Role: "employee"})
+
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("invalid scope", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/pvz?scope=mine", nil)
+		req = withPrincipal(req, rbac.Principal{Role: "moderator"})
+
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+	t.Run("filter by employee", func(t *testing.T) {
+		mockPVZRepo.ExpectedCalls = nil
+		employeeID := uuid.New()
+		deletedAt := time.Now()
+		mockPVZRepo.On("GetPVZsWithReceptions", mock.Anything, mock.MatchedBy(func(f storage.PVZFilter) bool {
+			return f.CreatedBy != nil && *f.CreatedBy == employeeID && f.IncludeDeleted
+		})).Return([]storage.PVZWithReceptions{{
+			PVZ: storage.PVZ{ID: uuid.New(), City: "Казань"},
+			Receptions: []storage.ReceptionWithProducts{{
+				Reception: storage.Reception{ID: uuid.New(), Status: "closed", CreatedBy: &employeeID, ClosedBy: &employeeID},
+				Products: []storage.Product{
+					{ID: uuid.New(), Type: "обувь", CreatedBy: &employeeID, DeletedAt: &deletedAt, DeletedBy: &employeeID},
+				},
+			}},
+		}}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/pvz?employeeId="+employeeID.String()+"&includeDeleted=true", nil)
+		w := httptest.NewRecorder()
+		handler(w, withAccessAll(req))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response []storage.PVZWithReceptions
+		json.NewDecoder(w.Body).Decode(&response)
+		reception := response[0].Receptions[0]
+		assert.Equal(t, &employeeID, reception.Reception.CreatedBy)
+		assert.Equal(t, &employeeID, reception.Reception.ClosedBy)
+		assert.Equal(t, &employeeID, reception.Products[0].CreatedBy)
+		assert.Equal(t, &employeeID, reception.Products[0].DeletedBy)
+		mockPVZRepo.AssertExpectations(t)
 	})
+
+	t.Run("filter by status", func(t *testing.T) {
+		mockPVZRepo.ExpectedCalls = nil
+		mockPVZRepo.On("GetPVZsWithReceptions", mock.Anything, mock.MatchedBy(func(f storage.PVZFilter) bool {
+			return f.Status == storage.PVZTemporarilyClosed
+		})).Return([]storage.PVZWithReceptions{}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/pvz?status=temporarily_closed", nil)
+		w := httptest.NewRecorder()
+		handler(w, withAccessAll(req))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockPVZRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/pvz?status=paused", nil)
+		w := httptest.NewRecorder()
+		handler(w, withAccessAll(req))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid employee id", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/pvz?employeeId=abc", nil)
+		w := httptest.NewRecorder()
+		handler(w, withAccessAll(req))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("missing endDate leaves the range open", func(t *testing.T) {
+		mockPVZRepo.ExpectedCalls = nil
+		start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+		mockPVZRepo.On("GetPVZsWithReceptions", mock.Anything, mock.MatchedBy(func(f storage.PVZFilter) bool {
+			return f.StartDate.Equal(start) && f.EndDate.IsZero()
+		})).Return([]storage.PVZWithReceptions{}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/pvz?startDate=2024-03-01T00:00:00Z", nil)
+		w := httptest.NewRecorder()
+		handler(w, withAccessAll(req))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockPVZRepo.AssertExpectations(t)
+	})
+
+	t.Run("dates with an offset are converted to UTC", func(t *testing.T) {
+		mockPVZRepo.ExpectedCalls = nil
+		mockPVZRepo.On("GetPVZsWithReceptions", mock.Anything, mock.MatchedBy(func(f storage.PVZFilter) bool {
+			return f.StartDate == time.Date(2026, 1, 1, 7, 0, 0, 0, time.UTC) &&
+				f.EndDate == time.Date(2026, 1, 2, 7, 0, 0, 0, time.UTC)
+		})).Return([]storage.PVZWithReceptions{}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/pvz?startDate=2026-01-01T10:00:00%2B03:00&endDate=2026-01-02T10:00:00%2B03:00", nil)
+		w := httptest.NewRecorder()
+		handler(w, withAccessAll(req))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockPVZRepo.AssertExpectations(t)
+	})
+
+	t.Run("only pvzs with matching receptions", func(t *testing.T) {
+		mockPVZRepo.ExpectedCalls = nil
+		mockPVZRepo.On("GetPVZsWithReceptions", mock.Anything, mock.MatchedBy(func(f storage.PVZFilter) bool {
+			return f.WithReceptions && f.City == "Казань" && f.ReceptionStatus == storage.ReceptionClosed &&
+				f.ProductType == "обувь" && f.Page == 2
+		})).Return([]storage.PVZWithReceptions{}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/pvz?withReceptions=true&city=Казань&receptionStatus=closed&productType=обувь&page=2", nil)
+		w := httptest.NewRecorder()
+		handler(w, withAccessAll(req))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockPVZRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid filters", func(t *testing.T) {
+		for _, query := range []string{
+			"startDate=2024-03-01",
+			"endDate=yesterday",
+			"startDate=2024-03-02T00:00:00Z&endDate=2024-03-01T00:00:00Z",
+			"receptionStatus=open",
+			"productType=мебель",
+			"withReceptions=maybe",
+		} {
+			t.Run(query, func(t *testing.T) {
+				req := httptest.NewRequest("GET", "/pvz?"+query, nil)
+				w := httptest.NewRecorder()
+				handler(w, withAccessAll(req))
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			})
+		}
+	})
+}
+
+func TestGetNearbyPVZs(t *testing.T) {
+	mockRepo := mocks.NewStorage(t)
+	handler := handler.GetNearbyPVZs(mockRepo)
+
+	t.Run("defaults", func(t *testing.T) {
+		mockRepo.On("FindNearbyPVZs", mock.Anything, storage.NearbyFilter{
+			Latitude:  55.75,
+			Longitude: 37.61,
+			Radius:    storage.DefaultNearbyRadius,
+			Limit:     storage.DefaultNearbyLimit,
+		}).Return([]storage.NearbyPVZ{{PVZ: storage.PVZ{ID: uuid.New(), City: "Москва"}, Distance: 420.5}}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/pvz/nearby?lat=55.75&lon=37.61", nil)
+		w := httptest.NewRecorder()
+		handler(w, withAccessAll(req))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var pvzs []map[string]any
+		json.NewDecoder(w.Body).Decode(&pvzs)
+		assert.Equal(t, "Москва", pvzs[0]["city"])
+		assert.Equal(t, 420.5, pvzs[0]["distance"])
+	})
+
+	t.Run("employee searches only assigned pvzs", func(t *testing.T) {
+		assigned := uuid.New()
+		mockRepo.On("FindNearbyPVZs", mock.Anything, mock.MatchedBy(func(f storage.NearbyFilter) bool {
+			return f.Radius == 2500 && f.Limit == 3 && f.City == "Казань" && f.Status == storage.PVZActive &&
+				len(f.PVZIDs) == 1 && f.PVZIDs[0] == assigned
+		})).Return([]storage.NearbyPVZ{}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/pvz/nearby?lat=55.79&lon=49.12&radius=2500&limit=3&status=active&city="+url.QueryEscape("Казань"), nil)
+		w := httptest.NewRecorder()
+		handler(w, withEmployee(req, assigned))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	for _, query := range []string{
+		"lon=37.61",
+		"lat=abc&lon=37.61",
+		"lat=95&lon=37.61",
+		"lat=NaN&lon=37.61",
+		"lat=55.75&lon=37.61&radius=-1",
+		"lat=55.75&lon=37.61&limit=1000",
+		"lat=55.75&lon=37.61&status=paused",
+	} {
+		t.Run("invalid "+query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/pvz/nearby?"+query, nil)
+			w := httptest.NewRecorder()
+			handler(w, withAccessAll(req))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
 }
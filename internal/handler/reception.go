@@ -1,8 +1,14 @@
 package handler
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -20,6 +26,14 @@ func CreateReception(db storage.Storage) http.HandlerFunc {
 			return
 		}
 
+		if !pvzAccessible(r, req.PVZID) {
+			respondError(w, http.StatusForbidden, "no access to this PVZ")
+			return
+		}
+		if !pvzOpen(w, r, db, req.PVZID) {
+			return
+		}
+
 		// Check for existing open reception
 		_, err := db.GetOpenReception(r.Context(), req.PVZID)
 		if err == nil {
@@ -27,12 +41,13 @@ func CreateReception(db storage.Storage) http.HandlerFunc {
 			return
 		}
 
-		reception, err := db.CreateReception(r.Context(), req.PVZID)
+		reception, err := db.CreateReception(r.Context(), req.PVZID, actorID(r))
 		if err != nil {
 			respondError(w, http.StatusInternalServerError, "failed to create reception")
 			return
 		}
 
+		recordAudit(r, db, "reception.create", auditReception, reception.ID.String(), nil, reception)
 		metrics.ReceptionsCreated.Inc()
 		respondJSON(w, http.StatusCreated, reception)
 	}
@@ -46,17 +61,149 @@ func CloseLastReception(db storage.Storage) http.HandlerFunc {
 			return
 		}
 
+		if !pvzAccessible(r, pvzID) {
+			respondError(w, http.StatusForbidden, "no access to this PVZ")
+			return
+		}
+
 		reception, err := db.GetOpenReception(r.Context(), pvzID)
 		if err != nil {
 			respondError(w, http.StatusNotFound, "no open reception found")
 			return
 		}
 
-		if err := db.CloseReception(r.Context(), reception.ID); err != nil {
+		closedBy := actorID(r)
+		if err := db.CloseReception(r.Context(), reception.ID, closedBy); err != nil {
 			respondError(w, http.StatusInternalServerError, "failed to close reception")
 			return
 		}
+		before := reception
 		reception.Status = "closed"
+		reception.ClosedBy = closedBy
+		recordAudit(r, db, "reception.close", auditReception, reception.ID.String(), before, reception)
 		respondJSON(w, http.StatusOK, reception)
 	}
 }
+
+// ListPVZReceptions pages through the receptions of the PVZ, newest first.
+// status, from and to filter them, nextCursor is returned while there can
+// be more pages.
+func ListPVZReceptions(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pvzID, ok := accessiblePVZ(w, r)
+		if !ok {
+			return
+		}
+		query := r.URL.Query()
+
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		if limit < 1 || limit > 100 {
+			limit = 20
+		}
+
+		filter := storage.ReceptionFilter{
+			PVZID:  pvzID,
+			Status: query.Get("status"),
+			Limit:  limit,
+		}
+		if filter.Status != "" && !slices.Contains(storage.ReceptionStatuses, filter.Status) {
+			respondError(w, http.StatusBadRequest, "invalid status")
+			return
+		}
+		for name, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+			if v := query.Get(name); v != "" {
+				t, err := time.Parse(time.RFC3339, v)
+				if err != nil {
+					respondError(w, http.StatusBadRequest, "invalid "+name+" date")
+					return
+				}
+				t = t.UTC()
+				*dst = &t
+			}
+		}
+		if v := query.Get("cursor"); v != "" {
+			cursor, err := parseReceptionCursor(v)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, "invalid cursor")
+				return
+			}
+			filter.Cursor = &cursor
+		}
+
+		// An unknown PVZ is a 404 rather than an empty history
+		if _, err := db.GetPVZ(r.Context(), pvzID); err != nil {
+			respondPVZError(w, err, "failed to get PVZ")
+			return
+		}
+
+		receptions, err := db.ListReceptions(r.Context(), filter)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to list receptions")
+			return
+		}
+
+		resp := struct {
+			Receptions []storage.Reception `json:"receptions"`
+			NextCursor string              `json:"nextCursor,omitempty"`
+		}{Receptions: receptions}
+		if len(receptions) == limit {
+			last := receptions[len(receptions)-1]
+			resp.NextCursor = formatReceptionCursor(storage.ReceptionCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		}
+		respondJSON(w, http.StatusOK, resp)
+	}
+}
+
+// GetReception returns the reception with its products. Deleted products
+// are listed with includeDeleted=true. Receptions of PVZs the caller
+// can't access are reported as not found.
+func GetReception(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		receptionID, err := uuid.Parse(chi.URLParam(r, "receptionId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid reception id")
+			return
+		}
+
+		reception, err := db.GetReception(r.Context(), receptionID, r.URL.Query().Get("includeDeleted") == "true")
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				respondError(w, http.StatusNotFound, "reception not found")
+				return
+			}
+			respondError(w, http.StatusInternalServerError, "failed to get reception")
+			return
+		}
+		// Receptions of other PVZs look missing, so IDs can't be probed
+		if !pvzAccessible(r, reception.Reception.PVZID) {
+			respondError(w, http.StatusNotFound, "reception not found")
+			return
+		}
+		respondJSON(w, http.StatusOK, reception)
+	}
+}
+
+// Reception cursors are opaque to clients: the creation time and ID of
+// the last reception, base64 encoded.
+func formatReceptionCursor(c storage.ReceptionCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.CreatedAt.UTC().Format(time.RFC3339Nano) + "_" + c.ID.String()))
+}
+
+func parseReceptionCursor(s string) (storage.ReceptionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return storage.ReceptionCursor{}, err
+	}
+	at, id, ok := strings.Cut(string(raw), "_")
+	if !ok {
+		return storage.ReceptionCursor{}, errors.New("malformed cursor")
+	}
+	var c storage.ReceptionCursor
+	if c.CreatedAt, err = time.Parse(time.RFC3339Nano, at); err != nil {
+		return storage.ReceptionCursor{}, err
+	}
+	if c.ID, err = uuid.Parse(id); err != nil {
+		return storage.ReceptionCursor{}, err
+	}
+	return c, nil
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/mi4r/avito-pvz/internal/handler"
+	"github.com/mi4r/avito-pvz/internal/rbac"
 	"github.com/mi4r/avito-pvz/internal/storage"
 	"github.com/mi4r/avito-pvz/internal/storage/mocks"
 	"github.com/stretchr/testify/assert"
@@ -24,22 +25,27 @@ func TestCreateReception(t *testing.T) {
 
 	t.Run("success create reception", func(t *testing.T) {
 		pvzID := uuid.New()
+		employeeID := uuid.New()
 		expectedReception := storage.Reception{
 			ID:        uuid.New(),
 			CreatedAt: time.Now(),
 			PVZID:     pvzID,
 			Status:    "in_progress",
+			CreatedBy: &employeeID,
 		}
 
 		// Mock setup
+		expectOpenPVZ(mockReceptionRepo, pvzID)
 		mockReceptionRepo.On("GetOpenReception", mock.Anything, pvzID).
 			Return(storage.Reception{}, storage.ErrNotFound)
 
-		mockReceptionRepo.On("CreateReception", mock.Anything, pvzID).
+		mockReceptionRepo.On("CreateReception", mock.Anything, pvzID, &employeeID).
 			Return(expectedReception, nil)
+		expectAudit(mockReceptionRepo, "reception.create")
 
 		reqBody := []byte(`{"pvzId":"` + pvzID.String() + `"}`)
 		req := httptest.NewRequest("POST", "/receptions", bytes.NewBuffer(reqBody))
+		req = withUser(withAccessAll(req), employeeID)
 		w := httptest.NewRecorder()
 
 		handler(w, req)
@@ -50,6 +56,7 @@ func TestCreateReception(t *testing.T) {
 		assert.Equal(t, expectedReception.ID, reception.ID)
 		assert.Equal(t, expectedReception.PVZID, reception.PVZID)
 		assert.Equal(t, expectedReception.Status, reception.Status)
+		assert.Equal(t, &employeeID, reception.CreatedBy)
 		mockReceptionRepo.AssertExpectations(t)
 	})
 
@@ -63,11 +70,13 @@ func TestCreateReception(t *testing.T) {
 		}
 
 		// Mock setup
+		expectOpenPVZ(mockReceptionRepo, pvzID)
 		mockReceptionRepo.On("GetOpenReception", mock.Anything, pvzID).
 			Return(existingReception, nil)
 
 		reqBody := []byte(`{"pvzId":"` + pvzID.String() + `"}`)
 		req := httptest.NewRequest("POST", "/receptions", bytes.NewBuffer(reqBody))
+		req = withAccessAll(req)
 		w := httptest.NewRecorder()
 
 		handler(w, req)
@@ -78,6 +87,7 @@ func TestCreateReception(t *testing.T) {
 
 	t.Run("invalid request body", func(t *testing.T) {
 		req := httptest.NewRequest("POST", "/receptions", bytes.NewBuffer([]byte("{")))
+		req = withAccessAll(req)
 		w := httptest.NewRecorder()
 
 		handler(w, req)
@@ -89,14 +99,16 @@ func TestCreateReception(t *testing.T) {
 		pvzID := uuid.New()
 
 		// Mock setup
+		expectOpenPVZ(mockReceptionRepo, pvzID)
 		mockReceptionRepo.On("GetOpenReception", mock.Anything, pvzID).
 			Return(storage.Reception{}, storage.ErrNotFound)
 
-		mockReceptionRepo.On("CreateReception", mock.Anything, pvzID).
+		mockReceptionRepo.On("CreateReception", mock.Anything, pvzID, (*uuid.UUID)(nil)).
 			Return(storage.Reception{}, errors.New("db error"))
 
 		reqBody := []byte(`{"pvzId":"` + pvzID.String() + `"}`)
 		req := httptest.NewRequest("POST", "/receptions", bytes.NewBuffer(reqBody))
+		req = withAccessAll(req)
 		w := httptest.NewRecorder()
 
 		handler(w, req)
@@ -104,6 +116,72 @@ func TestCreateReception(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		mockReceptionRepo.AssertExpectations(t)
 	})
+
+	t.Run("employee not assigned to pvz", func(t *testing.T) {
+		pvzID := uuid.New()
+
+		reqBody := []byte(`{"pvzId":"` + pvzID.String() + `"}`)
+		req := httptest.NewRequest("POST", "/receptions", bytes.NewBuffer(reqBody))
+		req = withEmployee(req, uuid.New())
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("assigned employee", func(t *testing.T) {
+		pvzID := uuid.New()
+
+		expectOpenPVZ(mockReceptionRepo, pvzID)
+		mockReceptionRepo.On("GetOpenReception", mock.Anything, pvzID).
+			Return(storage.Reception{}, storage.ErrNotFound)
+		mockReceptionRepo.On("CreateReception", mock.Anything, pvzID, (*uuid.UUID)(nil)).
+			Return(storage.Reception{ID: uuid.New(), PVZID: pvzID}, nil)
+		expectAudit(mockReceptionRepo, "reception.create")
+
+		reqBody := []byte(`{"pvzId":"` + pvzID.String() + `"}`)
+		req := httptest.NewRequest("POST", "/receptions", bytes.NewBuffer(reqBody))
+		req = withEmployee(req, pvzID)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+}
+
+// withPrincipal authenticates the request as p
+func withPrincipal(req *http.Request, p rbac.Principal) *http.Request {
+	return req.WithContext(rbac.WithPrincipal(req.Context(), p))
+}
+
+// withEmployee authenticates the request as an employee assigned to pvzIDs
+func withEmployee(req *http.Request, pvzIDs ...uuid.UUID) *http.Request {
+	p, _ := rbac.PrincipalFromContext(req.Context())
+	p.Role = rbac.RoleEmployee
+	p.PVZIDs = pvzIDs
+	return withPrincipal(req, p)
+}
+
+// withAccessAll authenticates the request as a caller allowed to work with any PVZ
+func withAccessAll(req *http.Request) *http.Request {
+	return withPermissions(req, rbac.PVZAccessAll)
+}
+
+// withUser attributes the request to a user account
+func withUser(req *http.Request, userID uuid.UUID) *http.Request {
+	p, _ := rbac.PrincipalFromContext(req.Context())
+	p.UserID = userID
+	p.Method = rbac.AuthToken
+	return withPrincipal(req, p)
+}
+
+// withPermissions grants permissions to the caller of the request
+func withPermissions(req *http.Request, permissions ...rbac.Permission) *http.Request {
+	p, _ := rbac.PrincipalFromContext(req.Context())
+	p.Permissions = permissions
+	return withPrincipal(req, p)
 }
 
 func TestCloseLastReception(t *testing.T) {
@@ -128,10 +206,13 @@ func TestCloseLastReception(t *testing.T) {
 		mockReceptionRepo.On("GetOpenReception", mock.Anything, pvzID).
 			Return(reception, nil)
 
-		mockReceptionRepo.On("CloseReception", mock.Anything, receptionID).
+		employeeID := uuid.New()
+		mockReceptionRepo.On("CloseReception", mock.Anything, receptionID, &employeeID).
 			Return(nil)
+		expectAudit(mockReceptionRepo, "reception.close")
 
 		req := httptest.NewRequest("POST", "/pvz/"+pvzID.String()+"/close_last_reception", nil)
+		req = withUser(withAccessAll(req), employeeID)
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
 
@@ -140,11 +221,13 @@ func TestCloseLastReception(t *testing.T) {
 		json.NewDecoder(w.Body).Decode(&response)
 		assert.Equal(t, receptionID, response.ID)
 		assert.Equal(t, "closed", response.Status)
+		assert.Equal(t, &employeeID, response.ClosedBy)
 		mockReceptionRepo.AssertExpectations(t)
 	})
 
 	t.Run("invalid pvz id", func(t *testing.T) {
 		req := httptest.NewRequest("POST", "/pvz/invalid/close_last_reception", nil)
+		req = withAccessAll(req)
 		w := httptest.NewRecorder()
 		handler(w, req)
 
@@ -160,6 +243,7 @@ func TestCloseLastReception(t *testing.T) {
 			Return(storage.Reception{}, storage.ErrNotFound)
 
 		req := httptest.NewRequest("POST", "/pvz/"+pvzID.String()+"/close_last_reception", nil)
+		req = withAccessAll(req)
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
 
@@ -182,14 +266,146 @@ func TestCloseLastReception(t *testing.T) {
 		mockReceptionRepo.On("GetOpenReception", mock.Anything, pvzID).
 			Return(reception, nil)
 
-		mockReceptionRepo.On("CloseReception", mock.Anything, receptionID).
+		mockReceptionRepo.On("CloseReception", mock.Anything, receptionID, (*uuid.UUID)(nil)).
 			Return(errors.New("db error"))
 
 		req := httptest.NewRequest("POST", "/pvz/"+pvzID.String()+"/close_last_reception", nil)
+		req = withAccessAll(req)
 		w := httptest.NewRecorder()
 		r.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		mockReceptionRepo.AssertExpectations(t)
 	})
+
+	t.Run("employee not assigned to pvz", func(t *testing.T) {
+		pvzID := uuid.New()
+		r := chi.NewRouter()
+		r.Post("/pvz/{pvzId}/close_last_reception", handler)
+
+		req := httptest.NewRequest("POST", "/pvz/"+pvzID.String()+"/close_last_reception", nil)
+		req = withEmployee(req)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestListPVZReceptions(t *testing.T) {
+	mockRepo := mocks.NewStorage(t)
+	r := chi.NewRouter()
+	r.Get("/pvz/{pvzId}/receptions", handler.ListPVZReceptions(mockRepo))
+
+	pvzID := uuid.New()
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	path := "/pvz/" + pvzID.String() + "/receptions"
+
+	t.Run("pages through the history", func(t *testing.T) {
+		now := time.Now().UTC()
+		last := storage.Reception{ID: uuid.New(), CreatedAt: now.Add(-time.Hour), PVZID: pvzID, Status: storage.ReceptionClosed}
+		mockRepo.On("GetPVZ", mock.Anything, pvzID).Return(storage.PVZ{ID: pvzID}, nil).Twice()
+		mockRepo.On("ListReceptions", mock.Anything, mock.MatchedBy(func(f storage.ReceptionFilter) bool {
+			return f.PVZID == pvzID && f.Status == storage.ReceptionClosed && f.Limit == 2 && f.Cursor == nil
+		})).Return([]storage.Reception{{ID: uuid.New(), CreatedAt: now, PVZID: pvzID}, last}, nil).Once()
+
+		w := serve(withEmployee(httptest.NewRequest("GET", path+"?status=closed&limit=2", nil), pvzID))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var page struct {
+			Receptions []storage.Reception `json:"receptions"`
+			NextCursor string              `json:"nextCursor"`
+		}
+		json.NewDecoder(w.Body).Decode(&page)
+		assert.Len(t, page.Receptions, 2)
+		assert.NotEmpty(t, page.NextCursor)
+
+		mockRepo.On("ListReceptions", mock.Anything, mock.MatchedBy(func(f storage.ReceptionFilter) bool {
+			return f.Cursor != nil && f.Cursor.ID == last.ID && f.Cursor.CreatedAt.Equal(last.CreatedAt)
+		})).Return([]storage.Reception{}, nil).Once()
+
+		w = serve(withEmployee(httptest.NewRequest("GET", path+"?status=closed&limit=2&cursor="+page.NextCursor, nil), pvzID))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "nextCursor")
+	})
+
+	t.Run("unknown pvz", func(t *testing.T) {
+		mockRepo.On("GetPVZ", mock.Anything, pvzID).Return(storage.PVZ{}, storage.ErrNotFound).Once()
+
+		w := serve(withAccessAll(httptest.NewRequest("GET", path, nil)))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("unassigned pvz", func(t *testing.T) {
+		w := serve(withEmployee(httptest.NewRequest("GET", path, nil), uuid.New()))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("invalid filters", func(t *testing.T) {
+		for _, query := range []string{"?status=open", "?from=yesterday", "?cursor=bm9wZQ"} {
+			w := serve(withAccessAll(httptest.NewRequest("GET", path+query, nil)))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
+
+func TestGetReception(t *testing.T) {
+	mockRepo := mocks.NewStorage(t)
+	r := chi.NewRouter()
+	r.Get("/receptions/{receptionId}", handler.GetReception(mockRepo))
+
+	receptionID := uuid.New()
+	pvzID := uuid.New()
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	path := "/receptions/" + receptionID.String()
+
+	t.Run("with products", func(t *testing.T) {
+		mockRepo.On("GetReception", mock.Anything, receptionID, true).Return(storage.ReceptionWithProducts{
+			Reception: storage.Reception{ID: receptionID, PVZID: pvzID},
+			Products:  []storage.Product{{ID: uuid.New(), Type: "обувь", ReceptionID: receptionID}},
+		}, nil).Once()
+
+		w := serve(withEmployee(httptest.NewRequest("GET", path+"?includeDeleted=true", nil), pvzID))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "обувь")
+	})
+
+	t.Run("reception of another pvz looks missing", func(t *testing.T) {
+		mockRepo.On("GetReception", mock.Anything, receptionID, false).Return(storage.ReceptionWithProducts{
+			Reception: storage.Reception{ID: receptionID, PVZID: pvzID},
+		}, nil).Once()
+
+		w := serve(withEmployee(httptest.NewRequest("GET", path, nil), uuid.New()))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.JSONEq(t, `{"error": "reception not found"}`, w.Body.String())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockRepo.On("GetReception", mock.Anything, receptionID, false).
+			Return(storage.ReceptionWithProducts{}, storage.ErrNotFound).Once()
+
+		w := serve(withAccessAll(httptest.NewRequest("GET", path, nil)))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		w := serve(httptest.NewRequest("GET", "/receptions/42", nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
 }
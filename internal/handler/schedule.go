@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/storage"
+)
+
+// maxOverrideDuration limits how long a PVZ can be kept open outside its
+// schedule by a single override.
+const maxOverrideDuration = 24 * time.Hour
+
+// ListScheduleExceptions lists the exceptions of the PVZ along with
+// holidays, or only holidays with holidays set. The optional from and to
+// query params limit the dates.
+func ListScheduleExceptions(db storage.Storage, holidays bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pvzID, ok := exceptionPVZ(w, r, holidays)
+		if !ok {
+			return
+		}
+
+		filter := storage.ScheduleExceptionFilter{
+			PVZID: pvzID,
+			From:  r.URL.Query().Get("from"),
+			To:    r.URL.Query().Get("to"),
+		}
+		for _, date := range []string{filter.From, filter.To} {
+			if _, err := time.Parse(time.DateOnly, date); date != "" && err != nil {
+				respondError(w, http.StatusBadRequest, "from and to must be YYYY-MM-DD")
+				return
+			}
+		}
+
+		exceptions, err := db.ListScheduleExceptions(r.Context(), filter)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to list schedule exceptions")
+			return
+		}
+		respondJSON(w, http.StatusOK, exceptions)
+	}
+}
+
+// SetScheduleException sets the hours of the PVZ, or of all PVZs with
+// holidays set, on the date of the URL. Without hours the PVZ is closed
+// all day.
+func SetScheduleException(db storage.Storage, holidays bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pvzID, ok := exceptionPVZ(w, r, holidays)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Hours  *storage.DayHours `json:"hours"`
+			Reason string            `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		exception := storage.ScheduleException{
+			PVZID:  pvzID,
+			Date:   chi.URLParam(r, "date"),
+			Hours:  req.Hours,
+			Reason: strings.TrimSpace(req.Reason),
+		}
+		if err := exception.Validate(); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		exception, err := db.SetScheduleException(r.Context(), exception)
+		if err != nil {
+			respondPVZError(w, err, "failed to set schedule exception")
+			return
+		}
+
+		if holidays {
+			recordAudit(r, db, "holiday.set", auditHoliday, exception.Date, nil, exception)
+		} else {
+			recordAudit(r, db, "pvz.schedule_exception.set", auditPVZ, pvzID.String(), nil, exception)
+		}
+		respondJSON(w, http.StatusOK, exception)
+	}
+}
+
+func DeleteScheduleException(db storage.Storage, holidays bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pvzID, ok := exceptionPVZ(w, r, holidays)
+		if !ok {
+			return
+		}
+
+		date := chi.URLParam(r, "date")
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			respondError(w, http.StatusBadRequest, "date must be YYYY-MM-DD")
+			return
+		}
+
+		if err := db.DeleteScheduleException(r.Context(), pvzID, date); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				respondError(w, http.StatusNotFound, "schedule exception not found")
+				return
+			}
+			respondError(w, http.StatusInternalServerError, "failed to delete schedule exception")
+			return
+		}
+
+		if holidays {
+			recordAudit(r, db, "holiday.delete", auditHoliday, date, nil, nil)
+		} else {
+			recordAudit(r, db, "pvz.schedule_exception.delete", auditPVZ, pvzID.String(), map[string]string{"date": date}, nil)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// CreateScheduleOverride lets the PVZ accept receptions and products
+// outside its schedule until the given time, at most a day ahead. The
+// reason is required and ends up in the audit log.
+func CreateScheduleOverride(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pvzID, ok := accessiblePVZ(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Until  time.Time `json:"until"`
+			Reason string    `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		reason := strings.TrimSpace(req.Reason)
+		if reason == "" {
+			respondError(w, http.StatusBadRequest, "reason is required")
+			return
+		}
+		if utf8.RuneCountInString(reason) > maxStatusReasonLength {
+			respondError(w, http.StatusBadRequest, "reason is too long")
+			return
+		}
+		until := req.Until.UTC().Truncate(time.Microsecond)
+		if now := time.Now(); !until.After(now) || until.After(now.Add(maxOverrideDuration)) {
+			respondError(w, http.StatusBadRequest, "until must be within the next 24 hours")
+			return
+		}
+
+		override, err := db.CreateScheduleOverride(r.Context(), storage.ScheduleOverride{
+			PVZID:     pvzID,
+			Until:     until,
+			Reason:    reason,
+			CreatedBy: actorID(r),
+		})
+		if err != nil {
+			respondPVZError(w, err, "failed to create schedule override")
+			return
+		}
+
+		recordAudit(r, db, "pvz.schedule_override", auditPVZ, pvzID.String(), nil, override)
+		respondJSON(w, http.StatusCreated, override)
+	}
+}
+
+// EndScheduleOverride puts the PVZ back on its schedule before the
+// override runs out.
+func EndScheduleOverride(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pvzID, ok := accessiblePVZ(w, r)
+		if !ok {
+			return
+		}
+
+		if err := db.EndScheduleOverride(r.Context(), pvzID); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				respondError(w, http.StatusNotFound, "no schedule override in effect")
+				return
+			}
+			respondError(w, http.StatusInternalServerError, "failed to end schedule override")
+			return
+		}
+
+		recordAudit(r, db, "pvz.schedule_override.end", auditPVZ, pvzID.String(), nil, nil)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// exceptionPVZ returns the accessible PVZ of the URL, or nil for holidays.
+func exceptionPVZ(w http.ResponseWriter, r *http.Request, holidays bool) (*uuid.UUID, bool) {
+	if holidays {
+		return nil, true
+	}
+	pvzID, ok := accessiblePVZ(w, r)
+	if !ok {
+		return nil, false
+	}
+	return &pvzID, true
+}
@@ -0,0 +1,165 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/handler"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestScheduleExceptions(t *testing.T) {
+	mockRepo := mocks.NewStorage(t)
+	r := chi.NewRouter()
+	r.Get("/holidays", handler.ListScheduleExceptions(mockRepo, true))
+	r.Put("/holidays/{date}", handler.SetScheduleException(mockRepo, true))
+	r.Delete("/holidays/{date}", handler.DeleteScheduleException(mockRepo, true))
+	r.Get("/pvz/{pvzId}/schedule_exceptions", handler.ListScheduleExceptions(mockRepo, false))
+	r.Put("/pvz/{pvzId}/schedule_exceptions/{date}", handler.SetScheduleException(mockRepo, false))
+	r.Delete("/pvz/{pvzId}/schedule_exceptions/{date}", handler.DeleteScheduleException(mockRepo, false))
+
+	pvzID := uuid.New()
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("set holiday", func(t *testing.T) {
+		mockRepo.On("SetScheduleException", mock.Anything, storage.ScheduleException{Date: "2025-05-09", Reason: "День Победы"}).
+			Return(storage.ScheduleException{Date: "2025-05-09", Reason: "День Победы"}, nil).Once()
+		expectAudit(mockRepo, "holiday.set")
+
+		w := serve(withAccessAll(httptest.NewRequest("PUT", "/holidays/2025-05-09", bytes.NewBufferString(`{"reason":"День Победы"}`))))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("list holidays", func(t *testing.T) {
+		mockRepo.On("ListScheduleExceptions", mock.Anything, storage.ScheduleExceptionFilter{From: "2025-01-01"}).
+			Return([]storage.ScheduleException{{Date: "2025-05-09"}}, nil).Once()
+
+		w := serve(httptest.NewRequest("GET", "/holidays?from=2025-01-01", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var exceptions []storage.ScheduleException
+		json.NewDecoder(w.Body).Decode(&exceptions)
+		assert.Len(t, exceptions, 1)
+	})
+
+	t.Run("invalid date filter", func(t *testing.T) {
+		w := serve(httptest.NewRequest("GET", "/holidays?to=09.05.2025", nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("short day at the pvz", func(t *testing.T) {
+		mockRepo.On("SetScheduleException", mock.Anything, mock.MatchedBy(func(e storage.ScheduleException) bool {
+			return *e.PVZID == pvzID && e.Date == "2025-12-31" && *e.Hours == storage.DayHours{Open: "09:00", Close: "15:00"}
+		})).Return(storage.ScheduleException{PVZID: &pvzID, Date: "2025-12-31"}, nil).Once()
+		expectAudit(mockRepo, "pvz.schedule_exception.set")
+
+		body := `{"hours":{"open":"09:00","close":"15:00"},"reason":"сокращённый день"}`
+		w := serve(withAccessAll(httptest.NewRequest("PUT", "/pvz/"+pvzID.String()+"/schedule_exceptions/2025-12-31", bytes.NewBufferString(body))))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("invalid hours", func(t *testing.T) {
+		body := `{"hours":{"open":"15:00","close":"09:00"}}`
+		w := serve(withAccessAll(httptest.NewRequest("PUT", "/pvz/"+pvzID.String()+"/schedule_exceptions/2025-12-31", bytes.NewBufferString(body))))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unassigned pvz", func(t *testing.T) {
+		w := serve(withEmployee(httptest.NewRequest("GET", "/pvz/"+pvzID.String()+"/schedule_exceptions", nil), uuid.New()))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("delete missing exception", func(t *testing.T) {
+		mockRepo.On("DeleteScheduleException", mock.Anything, &pvzID, "2025-12-31").
+			Return(storage.ErrNotFound).Once()
+
+		w := serve(withAccessAll(httptest.NewRequest("DELETE", "/pvz/"+pvzID.String()+"/schedule_exceptions/2025-12-31", nil)))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("delete holiday", func(t *testing.T) {
+		mockRepo.On("DeleteScheduleException", mock.Anything, (*uuid.UUID)(nil), "2025-05-09").
+			Return(nil).Once()
+		expectAudit(mockRepo, "holiday.delete")
+
+		w := serve(withAccessAll(httptest.NewRequest("DELETE", "/holidays/2025-05-09", nil)))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
+
+func TestScheduleOverride(t *testing.T) {
+	mockRepo := mocks.NewStorage(t)
+	r := chi.NewRouter()
+	r.Post("/pvz/{pvzId}/schedule_override", handler.CreateScheduleOverride(mockRepo))
+	r.Delete("/pvz/{pvzId}/schedule_override", handler.EndScheduleOverride(mockRepo))
+
+	pvzID := uuid.New()
+	moderatorID := uuid.New()
+	serve := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/pvz/"+pvzID.String()+"/schedule_override", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, withUser(withAccessAll(req), moderatorID))
+		return w
+	}
+
+	t.Run("open until tonight", func(t *testing.T) {
+		until := time.Now().Add(3 * time.Hour).UTC().Truncate(time.Second)
+		mockRepo.On("CreateScheduleOverride", mock.Anything, mock.MatchedBy(func(o storage.ScheduleOverride) bool {
+			return o.PVZID == pvzID && o.Until.Equal(until) && o.Reason == "задержка поставки" && *o.CreatedBy == moderatorID
+		})).Return(storage.ScheduleOverride{ID: uuid.New(), PVZID: pvzID, Until: until}, nil).Once()
+		expectAudit(mockRepo, "pvz.schedule_override")
+
+		w := serve("POST", `{"until":"`+until.Format(time.RFC3339)+`","reason":"задержка поставки"}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("reason is required", func(t *testing.T) {
+		w := serve("POST", `{"until":"`+time.Now().Add(time.Hour).Format(time.RFC3339)+`"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("more than a day ahead", func(t *testing.T) {
+		w := serve("POST", `{"until":"`+time.Now().Add(48*time.Hour).Format(time.RFC3339)+`","reason":"акция"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("end override", func(t *testing.T) {
+		mockRepo.On("EndScheduleOverride", mock.Anything, pvzID).Return(nil).Once()
+		expectAudit(mockRepo, "pvz.schedule_override.end")
+
+		w := serve("DELETE", "")
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("no override in effect", func(t *testing.T) {
+		mockRepo.On("EndScheduleOverride", mock.Anything, pvzID).Return(storage.ErrNotFound).Once()
+
+		w := serve("DELETE", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/token"
+)
+
+func CreateServiceAccount(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Name        string   `json:"name"`
+			Description string   `json:"description"`
+			Permissions []string `json:"permissions"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" || len(req.Permissions) == 0 {
+			respondError(w, http.StatusBadRequest, "name and permissions are required")
+			return
+		}
+		slices.Sort(req.Permissions)
+		req.Permissions = slices.Compact(req.Permissions)
+
+		createdBy := actorID(r)
+
+		account, err := db.CreateServiceAccount(r.Context(), req.Name, req.Description, req.Permissions, createdBy)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrServiceAccountExists):
+				respondError(w, http.StatusConflict, "service account already exists")
+			case errors.Is(err, storage.ErrInvalidPermission):
+				respondError(w, http.StatusBadRequest, "invalid permission")
+			default:
+				respondError(w, http.StatusInternalServerError, "failed to create service account")
+			}
+			return
+		}
+		recordAudit(r, db, "service_account.create", auditServiceAccount, account.ID.String(), nil, account)
+		respondJSON(w, http.StatusCreated, account)
+	}
+}
+
+func ListServiceAccounts(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accounts, err := db.ListServiceAccounts(r.Context())
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to list service accounts")
+			return
+		}
+		respondJSON(w, http.StatusOK, accounts)
+	}
+}
+
+// DeleteServiceAccount removes the account and, through the foreign key,
+// all of its keys.
+func DeleteServiceAccount(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, ok := serviceAccountID(w, r)
+		if !ok {
+			return
+		}
+
+		before, err := db.GetServiceAccount(r.Context(), accountID)
+		if err != nil {
+			respondServiceAccountError(w, err, "failed to delete service account")
+			return
+		}
+		if err := db.DeleteServiceAccount(r.Context(), accountID); err != nil {
+			respondServiceAccountError(w, err, "failed to delete service account")
+			return
+		}
+		recordAudit(r, db, "service_account.delete", auditServiceAccount, accountID.String(), before, nil)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// CreateAPIKey issues a key for the account. The key is returned once,
+// only its hash is stored.
+func CreateAPIKey(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, ok := serviceAccountID(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			ExpiresAt *time.Time `json:"expiresAt"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			respondError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+			respondError(w, http.StatusBadRequest, "expiresAt must be in the future")
+			return
+		}
+
+		plain, keyID, hash, err := token.NewAPIKey()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to create api key")
+			return
+		}
+
+		key, err := db.CreateAPIKey(r.Context(), accountID, keyID, hash, req.ExpiresAt)
+		if err != nil {
+			respondServiceAccountError(w, err, "failed to create api key")
+			return
+		}
+		recordAudit(r, db, "api_key.create", auditAPIKey, key.ID.String(), nil, key)
+
+		respondJSON(w, http.StatusCreated, struct {
+			storage.APIKey
+			Key string `json:"key"`
+		}{key, plain})
+	}
+}
+
+func ListAPIKeys(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, ok := serviceAccountID(w, r)
+		if !ok {
+			return
+		}
+
+		if _, err := db.GetServiceAccount(r.Context(), accountID); err != nil {
+			respondServiceAccountError(w, err, "failed to list api keys")
+			return
+		}
+
+		keys, err := db.ListAPIKeys(r.Context(), accountID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to list api keys")
+			return
+		}
+		respondJSON(w, http.StatusOK, keys)
+	}
+}
+
+func RevokeAPIKey(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, ok := serviceAccountID(w, r)
+		if !ok {
+			return
+		}
+		keyID, err := uuid.Parse(chi.URLParam(r, "keyId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid key id")
+			return
+		}
+
+		if err := db.RevokeAPIKey(r.Context(), accountID, keyID); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				respondError(w, http.StatusNotFound, "api key not found")
+				return
+			}
+			respondError(w, http.StatusInternalServerError, "failed to revoke api key")
+			return
+		}
+		recordAudit(r, db, "api_key.revoke", auditAPIKey, keyID.String(), nil,
+			map[string]uuid.UUID{"serviceAccountId": accountID, "id": keyID})
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func serviceAccountID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	accountID, err := uuid.Parse(chi.URLParam(r, "accountId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid service account id")
+		return uuid.Nil, false
+	}
+	return accountID, true
+}
+
+func respondServiceAccountError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, storage.ErrNotFound) {
+		respondError(w, http.StatusNotFound, "service account not found")
+		return
+	}
+	respondError(w, http.StatusInternalServerError, message)
+}
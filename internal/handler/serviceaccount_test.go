@@ -0,0 +1,189 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/handler"
+	"github.com/mi4r/avito-pvz/internal/rbac"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/storage/mocks"
+	"github.com/mi4r/avito-pvz/internal/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestServiceAccountAdmin(t *testing.T) {
+	mockRepo := mocks.NewStorage(t)
+	r := chi.NewRouter()
+	r.Get("/admin/service_accounts", handler.ListServiceAccounts(mockRepo))
+	r.Post("/admin/service_accounts", handler.CreateServiceAccount(mockRepo))
+	r.Delete("/admin/service_accounts/{accountId}", handler.DeleteServiceAccount(mockRepo))
+	r.Get("/admin/service_accounts/{accountId}/keys", handler.ListAPIKeys(mockRepo))
+	r.Post("/admin/service_accounts/{accountId}/keys", handler.CreateAPIKey(mockRepo))
+	r.Delete("/admin/service_accounts/{accountId}/keys/{keyId}", handler.RevokeAPIKey(mockRepo))
+
+	adminID := uuid.New()
+	asAdmin := func(req *http.Request) *http.Request {
+		return withPrincipal(req, rbac.Principal{UserID: adminID, Role: "admin", Method: rbac.AuthToken})
+	}
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, asAdmin(req))
+		return w
+	}
+	accountID := uuid.New()
+
+	t.Run("create service account", func(t *testing.T) {
+		mockRepo.On("CreateServiceAccount", mock.Anything, "warehouse", "WMS sync", []string{"pvz:read", "reception:create"}, &adminID).
+			Return(storage.ServiceAccount{ID: accountID, Name: "warehouse", Permissions: []string{"pvz:read", "reception:create"}}, nil).Once()
+		expectAudit(mockRepo, "service_account.create")
+
+		w := serve("POST", "/admin/service_accounts",
+			`{"name":" warehouse ","description":"WMS sync","permissions":["reception:create","pvz:read","pvz:read"]}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var account storage.ServiceAccount
+		json.NewDecoder(w.Body).Decode(&account)
+		assert.Equal(t, accountID, account.ID)
+	})
+
+	t.Run("create service account without permissions", func(t *testing.T) {
+		w := serve("POST", "/admin/service_accounts", `{"name":"warehouse"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("create service account with unknown permission", func(t *testing.T) {
+		mockRepo.On("CreateServiceAccount", mock.Anything, "warehouse", "", []string{"root"}, mock.Anything).
+			Return(storage.ServiceAccount{}, storage.ErrInvalidPermission).Once()
+
+		w := serve("POST", "/admin/service_accounts", `{"name":"warehouse","permissions":["root"]}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("create duplicate service account", func(t *testing.T) {
+		mockRepo.On("CreateServiceAccount", mock.Anything, "warehouse", "", []string{"pvz:read"}, mock.Anything).
+			Return(storage.ServiceAccount{}, storage.ErrServiceAccountExists).Once()
+
+		w := serve("POST", "/admin/service_accounts", `{"name":"warehouse","permissions":["pvz:read"]}`)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("list service accounts", func(t *testing.T) {
+		mockRepo.On("ListServiceAccounts", mock.Anything).
+			Return([]storage.ServiceAccount{{ID: accountID, Name: "warehouse"}}, nil).Once()
+
+		w := serve("GET", "/admin/service_accounts", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var accounts []storage.ServiceAccount
+		json.NewDecoder(w.Body).Decode(&accounts)
+		assert.Len(t, accounts, 1)
+	})
+
+	t.Run("delete missing service account", func(t *testing.T) {
+		missing := uuid.New()
+		mockRepo.On("GetServiceAccount", mock.Anything, missing).Return(storage.ServiceAccount{}, storage.ErrNotFound).Once()
+
+		w := serve("DELETE", "/admin/service_accounts/"+missing.String(), "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("delete service account keeps it in the audit log", func(t *testing.T) {
+		deleted := uuid.New()
+		mockRepo.On("GetServiceAccount", mock.Anything, deleted).
+			Return(storage.ServiceAccount{ID: deleted, Name: "legacy"}, nil).Once()
+		mockRepo.On("DeleteServiceAccount", mock.Anything, deleted).Return(nil).Once()
+		mockRepo.On("AppendAudit", mock.Anything, mock.MatchedBy(func(e storage.AuditEntry) bool {
+			return e.Action == "service_account.delete" && strings.Contains(string(e.Before), `"name":"legacy"`) && e.After == nil
+		})).Return(storage.AuditEntry{}, nil).Once()
+
+		w := serve("DELETE", "/admin/service_accounts/"+deleted.String(), "")
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("create api key", func(t *testing.T) {
+		var keyID, hash string
+		mockRepo.On("CreateAPIKey", mock.Anything, accountID, mock.Anything, mock.Anything, (*time.Time)(nil)).
+			Run(func(args mock.Arguments) {
+				keyID, hash = args.String(2), args.String(3)
+			}).
+			Return(storage.APIKey{ID: uuid.New(), ServiceAccountID: accountID}, nil).Once()
+		expectAudit(mockRepo, "api_key.create")
+
+		w := serve("POST", "/admin/service_accounts/"+accountID.String()+"/keys", "")
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response map[string]any
+		json.NewDecoder(w.Body).Decode(&response)
+		plain, _ := response["key"].(string)
+		assert.True(t, strings.HasPrefix(plain, token.APIKeyPrefix+keyID+"_"))
+		assert.Equal(t, token.HashOpaqueToken(plain), hash)
+	})
+
+	t.Run("create api key with expiry in the past", func(t *testing.T) {
+		w := serve("POST", "/admin/service_accounts/"+accountID.String()+"/keys", `{"expiresAt":"2020-01-01T00:00:00Z"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("create api key for missing account", func(t *testing.T) {
+		missing := uuid.New()
+		mockRepo.On("CreateAPIKey", mock.Anything, missing, mock.Anything, mock.Anything, mock.Anything).
+			Return(storage.APIKey{}, storage.ErrNotFound).Once()
+
+		w := serve("POST", "/admin/service_accounts/"+missing.String()+"/keys", `{}`)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("list api keys", func(t *testing.T) {
+		mockRepo.On("GetServiceAccount", mock.Anything, accountID).Return(storage.ServiceAccount{ID: accountID}, nil).Once()
+		mockRepo.On("ListAPIKeys", mock.Anything, accountID).
+			Return([]storage.APIKey{{ID: uuid.New(), KeyID: "0123456789ab", KeyHash: "secret-hash"}}, nil).Once()
+
+		w := serve("GET", "/admin/service_accounts/"+accountID.String()+"/keys", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "0123456789ab")
+		assert.NotContains(t, w.Body.String(), "secret-hash")
+	})
+
+	t.Run("revoke api key", func(t *testing.T) {
+		keyID := uuid.New()
+		mockRepo.On("RevokeAPIKey", mock.Anything, accountID, keyID).Return(nil).Once()
+		expectAudit(mockRepo, "api_key.revoke")
+
+		w := serve("DELETE", "/admin/service_accounts/"+accountID.String()+"/keys/"+keyID.String(), "")
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("revoke unknown api key", func(t *testing.T) {
+		keyID := uuid.New()
+		mockRepo.On("RevokeAPIKey", mock.Anything, accountID, keyID).Return(storage.ErrNotFound).Once()
+
+		w := serve("DELETE", "/admin/service_accounts/"+accountID.String()+"/keys/"+keyID.String(), "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid account id", func(t *testing.T) {
+		w := serve("DELETE", "/admin/service_accounts/not-a-uuid", "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/middleware"
+	"github.com/mi4r/avito-pvz/internal/rbac"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/token"
+)
+
+const (
+	maxDeviceLength    = 100
+	maxUserAgentLength = 512
+)
+
+// startSession records a new login from the request and issues its first
+// token pair. device is an optional name sent by the client, e.g. the
+// label of a shared scanner.
+func startSession(r *http.Request, db storage.Storage, issuer *token.Issuer, user storage.User, device string) (tokenPair, error) {
+	session, err := db.CreateSession(r.Context(), user.ID,
+		truncate(device, maxDeviceLength), truncate(r.UserAgent(), maxUserAgentLength), middleware.ClientIP(r))
+	if err != nil {
+		return tokenPair{}, err
+	}
+	return issueTokens(r.Context(), db, issuer, user, session.ID)
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// ListSessions returns the active sessions of the current user. The one
+// the request was made with is marked as current.
+func ListSessions(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := sessionOwner(w, r)
+		if !ok {
+			return
+		}
+
+		sessions, err := db.ListUserSessions(r.Context(), p.UserID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to list sessions")
+			return
+		}
+
+		type sessionView struct {
+			storage.Session
+			Current bool `json:"current"`
+		}
+		views := make([]sessionView, 0, len(sessions))
+		for _, session := range sessions {
+			views = append(views, sessionView{session, session.ID == p.SessionID})
+		}
+		respondJSON(w, http.StatusOK, views)
+	}
+}
+
+// RevokeSession signs one of the current user's devices out. Its access
+// tokens stop working immediately.
+func RevokeSession(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := sessionOwner(w, r)
+		if !ok {
+			return
+		}
+		sessionID, err := uuid.Parse(chi.URLParam(r, "sessionId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid session id")
+			return
+		}
+
+		err = db.RevokeSession(r.Context(), p.UserID, sessionID)
+		if errors.Is(err, storage.ErrNotFound) {
+			respondError(w, http.StatusNotFound, "session not found")
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to revoke session")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// RevokeAllSessions signs the current user out everywhere, including the
+// session the request was made with.
+func RevokeAllSessions(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := sessionOwner(w, r)
+		if !ok {
+			return
+		}
+		if err := db.RevokeUserSessions(r.Context(), p.UserID); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to revoke sessions")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ListPVZSessions shows moderators who is signed in on the devices of
+// a PVZ, i.e. the active sessions of its assigned users.
+func ListPVZSessions(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pvzID, err := uuid.Parse(chi.URLParam(r, "pvzId"))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid pvz id")
+			return
+		}
+		if !pvzAccessible(r, pvzID) {
+			respondError(w, http.StatusForbidden, "no access to this PVZ")
+			return
+		}
+
+		sessions, err := db.ListPVZSessions(r.Context(), pvzID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to list sessions")
+			return
+		}
+		respondJSON(w, http.StatusOK, sessions)
+	}
+}
+
+// sessionOwner returns the user behind the access token. Dummy tokens and
+// API keys have no sessions.
+func sessionOwner(w http.ResponseWriter, r *http.Request) (rbac.Principal, bool) {
+	p := principal(r)
+	if !p.IsUser() {
+		respondError(w, http.StatusForbidden, "only user accounts have sessions")
+		return rbac.Principal{}, false
+	}
+	return p, true
+}
@@ -0,0 +1,165 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/handler"
+	"github.com/mi4r/avito-pvz/internal/rbac"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/storage/mocks"
+	"github.com/mi4r/avito-pvz/internal/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoginStartsSession(t *testing.T) {
+	issuer := testIssuer(t)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("correct_password"), testHasher.BcryptCost)
+	user := storage.User{ID: uuid.New(), Email: "user@example.com", PasswordHash: string(hash), Role: "employee"}
+	sessionID := uuid.New()
+
+	mockRepo := mocks.NewStorage(t)
+	mockRepo.On("GetLoginLock", mock.Anything, mock.Anything, mock.Anything).Return(time.Duration(0), nil)
+	mockRepo.On("ClearLoginFailures", mock.Anything, "email", "user@example.com").Return(nil)
+	mockRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(user, nil)
+	mockRepo.On("GetMFA", mock.Anything, user.ID).Return(storage.MFA{}, storage.ErrNotFound)
+	mockRepo.On("IsMFARequired", mock.Anything, "employee").Return(false, nil)
+	mockRepo.On("UpdateLastLogin", mock.Anything, user.ID).Return(nil)
+	mockRepo.On("CreateSession", mock.Anything, user.ID, "scanner-07", "Zebra TC52", "192.0.2.1").
+		Return(storage.Session{ID: sessionID}, nil)
+	mockRepo.On("CreateRefreshToken", mock.Anything, user.ID, sessionID, mock.Anything, mock.Anything).
+		Return(storage.RefreshToken{}, nil)
+
+	req := httptest.NewRequest("POST", "/login",
+		bytes.NewBufferString(`{"email":"user@example.com","password":"correct_password","device":"scanner-07"}`))
+	req.Header.Set("User-Agent", "Zebra TC52")
+	w := httptest.NewRecorder()
+
+	handler.Login(mockRepo, issuer, testGuard(mockRepo), testHasher)(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response map[string]string
+	json.NewDecoder(w.Body).Decode(&response)
+	var claims token.Claims
+	_, err := issuer.Keys().Parse(response["token"], &claims)
+	require.NoError(t, err)
+	assert.Equal(t, sessionID.String(), claims.SessionID)
+}
+
+func TestSessions(t *testing.T) {
+	userID := uuid.New()
+	currentID := uuid.New()
+	otherID := uuid.New()
+
+	mockRepo := mocks.NewStorage(t)
+	r := chi.NewRouter()
+	r.Get("/sessions", handler.ListSessions(mockRepo))
+	r.Delete("/sessions", handler.RevokeAllSessions(mockRepo))
+	r.Delete("/sessions/{sessionId}", handler.RevokeSession(mockRepo))
+
+	asUser := func(req *http.Request) *http.Request {
+		return withPrincipal(req, rbac.Principal{UserID: userID, Role: "employee", Method: rbac.AuthToken, SessionID: currentID})
+	}
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("list marks current session", func(t *testing.T) {
+		mockRepo.On("ListUserSessions", mock.Anything, userID).Return([]storage.Session{
+			{ID: currentID, UserID: userID, Device: "scanner-07"},
+			{ID: otherID, UserID: userID, Device: "scanner-02"},
+		}, nil).Once()
+
+		w := serve(asUser(httptest.NewRequest("GET", "/sessions", nil)))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response []map[string]any
+		json.NewDecoder(w.Body).Decode(&response)
+		require.Len(t, response, 2)
+		assert.Equal(t, true, response[0]["current"])
+		assert.Equal(t, false, response[1]["current"])
+		assert.Equal(t, "scanner-02", response[1]["device"])
+	})
+
+	t.Run("revoke one session", func(t *testing.T) {
+		mockRepo.On("RevokeSession", mock.Anything, userID, otherID).Return(nil).Once()
+
+		w := serve(asUser(httptest.NewRequest("DELETE", "/sessions/"+otherID.String(), nil)))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("revoke someone else's session", func(t *testing.T) {
+		foreignID := uuid.New()
+		mockRepo.On("RevokeSession", mock.Anything, userID, foreignID).Return(storage.ErrNotFound).Once()
+
+		w := serve(asUser(httptest.NewRequest("DELETE", "/sessions/"+foreignID.String(), nil)))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid session id", func(t *testing.T) {
+		w := serve(asUser(httptest.NewRequest("DELETE", "/sessions/abc", nil)))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("revoke all sessions", func(t *testing.T) {
+		mockRepo.On("RevokeUserSessions", mock.Anything, userID).Return(nil).Once()
+
+		w := serve(asUser(httptest.NewRequest("DELETE", "/sessions", nil)))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("dummy token has no sessions", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/sessions", nil)
+		req = withPrincipal(req, rbac.Principal{Role: "employee", Method: rbac.AuthDummy})
+
+		w := serve(req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestListPVZSessions(t *testing.T) {
+	pvzID := uuid.New()
+	mockRepo := mocks.NewStorage(t)
+	r := chi.NewRouter()
+	r.Get("/pvz/{pvzId}/sessions", handler.ListPVZSessions(mockRepo))
+
+	t.Run("moderator sees sessions of assigned employees", func(t *testing.T) {
+		mockRepo.On("ListPVZSessions", mock.Anything, pvzID).Return([]storage.Session{
+			{ID: uuid.New(), UserID: uuid.New(), Email: "employee@example.com", Device: "scanner-07"},
+		}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/pvz/"+pvzID.String()+"/sessions", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, withPermissions(req, rbac.SessionView, rbac.PVZAccessAll))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response []map[string]any
+		json.NewDecoder(w.Body).Decode(&response)
+		require.Len(t, response, 1)
+		assert.Equal(t, "employee@example.com", response[0]["email"])
+	})
+
+	t.Run("pvz outside of assignments", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/pvz/"+pvzID.String()+"/sessions", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, withPermissions(req, rbac.SessionView))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/metrics"
+	"github.com/mi4r/avito-pvz/internal/rbac"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/token"
+)
+
+type tokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+// issueTokens signs an access token for the session and stores a new
+// refresh token in its rotation family.
+func issueTokens(ctx context.Context, db storage.Storage, issuer *token.Issuer, user storage.User, sessionID uuid.UUID) (tokenPair, error) {
+	access, _, err := issuer.AccessToken(user.ID.String(), user.Role, sessionID.String())
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	refresh, hash, err := token.NewOpaqueToken()
+	if err != nil {
+		return tokenPair{}, err
+	}
+	expiresAt := time.Now().Add(issuer.RefreshTTL())
+	if _, err := db.CreateRefreshToken(ctx, user.ID, sessionID, hash, expiresAt); err != nil {
+		return tokenPair{}, err
+	}
+
+	return tokenPair{Token: access, RefreshToken: refresh}, nil
+}
+
+func RefreshToken(db storage.Storage, issuer *token.Issuer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			RefreshToken string `json:"refreshToken"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+			respondError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+
+		stored, err := db.GetRefreshToken(r.Context(), token.HashOpaqueToken(req.RefreshToken))
+		if err != nil {
+			respondError(w, http.StatusUnauthorized, "invalid refresh token")
+			return
+		}
+		if stored.RevokedAt != nil || time.Now().After(stored.ExpiresAt) {
+			respondError(w, http.StatusUnauthorized, "invalid refresh token")
+			return
+		}
+
+		// A refresh token is single-use. Presenting a used one means it was
+		// copied, so the whole family is revoked and the user must log in again.
+		consumed := false
+		if stored.UsedAt == nil {
+			if consumed, err = db.MarkRefreshTokenUsed(r.Context(), stored.ID); err != nil {
+				respondError(w, http.StatusInternalServerError, "failed to refresh token")
+				return
+			}
+		}
+		if !consumed {
+			metrics.RefreshTokenReuse.Inc()
+			if err := db.RevokeRefreshTokenFamily(r.Context(), stored.FamilyID); err != nil {
+				respondError(w, http.StatusInternalServerError, "failed to refresh token")
+				return
+			}
+			respondError(w, http.StatusUnauthorized, "refresh token reuse detected")
+			return
+		}
+
+		user, err := db.GetUserByID(r.Context(), stored.UserID)
+		if err != nil {
+			respondError(w, http.StatusUnauthorized, "invalid refresh token")
+			return
+		}
+		if user.DisabledAt != nil {
+			respondError(w, http.StatusUnauthorized, "user is disabled")
+			return
+		}
+
+		tokens, err := issueTokens(r.Context(), db, issuer, user, stored.FamilyID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to issue token")
+			return
+		}
+		respondJSON(w, http.StatusOK, tokens)
+	}
+}
+
+func Logout(db storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			RefreshToken string `json:"refreshToken"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			respondError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+
+		// API keys aren't revoked by logging out
+		p, ok := rbac.PrincipalFromContext(r.Context())
+		if !ok || p.TokenID == "" {
+			respondError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+
+		if err := db.RevokeToken(r.Context(), p.TokenID, p.TokenExpiresAt); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to logout")
+			return
+		}
+
+		// Ending the session also revokes its refresh tokens and any other
+		// access token issued for it
+		if p.SessionID != uuid.Nil {
+			err := db.RevokeSession(r.Context(), p.UserID, p.SessionID)
+			if err != nil && !errors.Is(err, storage.ErrNotFound) {
+				respondError(w, http.StatusInternalServerError, "failed to logout")
+				return
+			}
+		}
+
+		if req.RefreshToken != "" {
+			stored, err := db.GetRefreshToken(r.Context(), token.HashOpaqueToken(req.RefreshToken))
+			if err == nil && p.IsUser() && stored.UserID == p.UserID {
+				if err := db.RevokeRefreshTokenFamily(r.Context(), stored.FamilyID); err != nil {
+					respondError(w, http.StatusInternalServerError, "failed to logout")
+					return
+				}
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,204 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/handler"
+	"github.com/mi4r/avito-pvz/internal/rbac"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/storage/mocks"
+	"github.com/mi4r/avito-pvz/internal/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRefreshToken(t *testing.T) {
+	issuer := testIssuer(t)
+	userID := uuid.New()
+	familyID := uuid.New()
+	refresh := "refresh-token"
+	hash := token.HashOpaqueToken(refresh)
+	reqBody := []byte(`{"refreshToken":"` + refresh + `"}`)
+
+	t.Run("success rotates token", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		stored := storage.RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: familyID, ExpiresAt: time.Now().Add(time.Hour)}
+
+		mockRepo.On("GetRefreshToken", mock.Anything, hash).Return(stored, nil)
+		mockRepo.On("MarkRefreshTokenUsed", mock.Anything, stored.ID).Return(true, nil)
+		mockRepo.On("GetUserByID", mock.Anything, userID).
+			Return(storage.User{ID: userID, Role: "employee"}, nil)
+		mockRepo.On("CreateRefreshToken", mock.Anything, userID, familyID, mock.Anything, mock.Anything).
+			Return(storage.RefreshToken{}, nil)
+
+		req := httptest.NewRequest("POST", "/token/refresh", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+
+		handler.RefreshToken(mockRepo, issuer)(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]string
+		json.NewDecoder(w.Body).Decode(&response)
+		assert.NotEmpty(t, response["token"])
+		assert.NotEmpty(t, response["refreshToken"])
+		assert.NotEqual(t, refresh, response["refreshToken"])
+	})
+
+	t.Run("reuse revokes family", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		usedAt := time.Now().Add(-time.Minute)
+		stored := storage.RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: familyID, ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt}
+
+		mockRepo.On("GetRefreshToken", mock.Anything, hash).Return(stored, nil)
+		mockRepo.On("RevokeRefreshTokenFamily", mock.Anything, familyID).Return(nil)
+
+		req := httptest.NewRequest("POST", "/token/refresh", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+
+		handler.RefreshToken(mockRepo, issuer)(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "reuse")
+	})
+
+	t.Run("concurrent use revokes family", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		stored := storage.RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: familyID, ExpiresAt: time.Now().Add(time.Hour)}
+
+		mockRepo.On("GetRefreshToken", mock.Anything, hash).Return(stored, nil)
+		mockRepo.On("MarkRefreshTokenUsed", mock.Anything, stored.ID).Return(false, nil)
+		mockRepo.On("RevokeRefreshTokenFamily", mock.Anything, familyID).Return(nil)
+
+		req := httptest.NewRequest("POST", "/token/refresh", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+
+		handler.RefreshToken(mockRepo, issuer)(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		stored := storage.RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: familyID, ExpiresAt: time.Now().Add(-time.Hour)}
+
+		mockRepo.On("GetRefreshToken", mock.Anything, hash).Return(stored, nil)
+
+		req := httptest.NewRequest("POST", "/token/refresh", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+
+		handler.RefreshToken(mockRepo, issuer)(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("GetRefreshToken", mock.Anything, hash).Return(storage.RefreshToken{}, storage.ErrNotFound)
+
+		req := httptest.NewRequest("POST", "/token/refresh", bytes.NewBuffer(reqBody))
+		w := httptest.NewRecorder()
+
+		handler.RefreshToken(mockRepo, issuer)(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("invalid request body", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/token/refresh", bytes.NewBuffer([]byte(`{}`)))
+		w := httptest.NewRecorder()
+
+		handler.RefreshToken(mocks.NewStorage(t), issuer)(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestLogout(t *testing.T) {
+	userID := uuid.New()
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	caller := rbac.Principal{
+		UserID:         userID,
+		Role:           "employee",
+		Method:         rbac.AuthToken,
+		TokenID:        "jti-1",
+		TokenExpiresAt: expiresAt,
+	}
+
+	t.Run("revokes access and refresh tokens", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		familyID := uuid.New()
+
+		mockRepo.On("RevokeToken", mock.Anything, "jti-1", expiresAt).Return(nil)
+		mockRepo.On("GetRefreshToken", mock.Anything, token.HashOpaqueToken("refresh")).
+			Return(storage.RefreshToken{UserID: userID, FamilyID: familyID}, nil)
+		mockRepo.On("RevokeRefreshTokenFamily", mock.Anything, familyID).Return(nil)
+
+		req := httptest.NewRequest("POST", "/logout", bytes.NewBuffer([]byte(`{"refreshToken":"refresh"}`)))
+		req = withPrincipal(req, caller)
+		w := httptest.NewRecorder()
+
+		handler.Logout(mockRepo)(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("empty body revokes access token only", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("RevokeToken", mock.Anything, "jti-1", expiresAt).Return(nil)
+
+		req := httptest.NewRequest("POST", "/logout", nil)
+		req = withPrincipal(req, caller)
+		w := httptest.NewRecorder()
+
+		handler.Logout(mockRepo)(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("ends the session", func(t *testing.T) {
+		sessionID := uuid.New()
+		withSession := caller
+		withSession.SessionID = sessionID
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("RevokeToken", mock.Anything, "jti-1", expiresAt).Return(nil)
+		mockRepo.On("RevokeSession", mock.Anything, userID, sessionID).Return(nil)
+
+		req := httptest.NewRequest("POST", "/logout", nil)
+		req = withPrincipal(req, withSession)
+		w := httptest.NewRecorder()
+
+		handler.Logout(mockRepo)(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("foreign refresh token is ignored", func(t *testing.T) {
+		mockRepo := mocks.NewStorage(t)
+		mockRepo.On("RevokeToken", mock.Anything, "jti-1", expiresAt).Return(nil)
+		mockRepo.On("GetRefreshToken", mock.Anything, token.HashOpaqueToken("refresh")).
+			Return(storage.RefreshToken{UserID: uuid.New(), FamilyID: uuid.New()}, nil)
+
+		req := httptest.NewRequest("POST", "/logout", bytes.NewBuffer([]byte(`{"refreshToken":"refresh"}`)))
+		req = withPrincipal(req, caller)
+		w := httptest.NewRecorder()
+
+		handler.Logout(mockRepo)(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+	t.Run("api key has nothing to log out", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/logout", nil)
+		req = withPrincipal(req, rbac.Principal{ServiceAccountID: uuid.New(), Role: rbac.RoleServiceAccount, Method: rbac.AuthAPIKey})
+		w := httptest.NewRecorder()
+
+		handler.Logout(mocks.NewStorage(t))(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
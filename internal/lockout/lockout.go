@@ -0,0 +1,102 @@
+package lockout
+
+import (
+	"context"
+	"time"
+
+	"github.com/mi4r/avito-pvz/internal/metrics"
+	"github.com/mi4r/avito-pvz/internal/storage"
+)
+
+// Login failures are counted separately per account and per source IP.
+const (
+	ScopeEmail = "email"
+	ScopeIP    = "ip"
+)
+
+// Policy configures backoff and lockout. Every failure below the limit
+// blocks further attempts for BaseDelay * 2^(failures-1); reaching the
+// limit locks the key for LockoutDuration.
+type Policy struct {
+	MaxFailures     int
+	MaxIPFailures   int
+	BaseDelay       time.Duration
+	LockoutDuration time.Duration
+	// Window after which an idle failure counter starts over
+	Window time.Duration
+}
+
+type Guard struct {
+	db     storage.Storage
+	policy Policy
+}
+
+func NewGuard(db storage.Storage, policy Policy) *Guard {
+	return &Guard{db: db, policy: policy}
+}
+
+// Check returns how long the caller has to wait before the next attempt,
+// or zero if the login may proceed.
+func (g *Guard) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	var wait time.Duration
+	for _, k := range g.keys(email, ip) {
+		d, err := g.db.GetLoginLock(ctx, k.scope, k.key)
+		if err != nil {
+			return 0, err
+		}
+		wait = max(wait, d)
+	}
+	return wait, nil
+}
+
+// Fail records a failed attempt and locks the keys that hit the backoff.
+func (g *Guard) Fail(ctx context.Context, email, ip string) error {
+	for _, k := range g.keys(email, ip) {
+		failures, err := g.db.RecordLoginFailure(ctx, k.scope, k.key, g.policy.Window)
+		if err != nil {
+			return err
+		}
+		d := g.delay(failures, k.limit)
+		if d == 0 {
+			continue
+		}
+		if failures >= k.limit {
+			metrics.LoginLockouts.WithLabelValues(k.scope).Inc()
+		}
+		if err := g.db.LockLogin(ctx, k.scope, k.key, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Succeed resets the account counter. The IP counter is kept, otherwise
+// an attacker could reset it by logging into an account of their own.
+func (g *Guard) Succeed(ctx context.Context, email string) error {
+	return g.db.ClearLoginFailures(ctx, ScopeEmail, email)
+}
+
+func (g *Guard) delay(failures, limit int) time.Duration {
+	if failures >= limit {
+		return g.policy.LockoutDuration
+	}
+	d := g.policy.BaseDelay
+	for i := 1; i < failures && d < g.policy.LockoutDuration; i++ {
+		d *= 2
+	}
+	return min(d, g.policy.LockoutDuration)
+}
+
+type key struct {
+	scope string
+	key   string
+	limit int
+}
+
+func (g *Guard) keys(email, ip string) []key {
+	keys := []key{{ScopeEmail, email, g.policy.MaxFailures}}
+	if ip != "" {
+		keys = append(keys, key{ScopeIP, ip, g.policy.MaxIPFailures})
+	}
+	return keys
+}
@@ -53,6 +53,26 @@ var (
 		Help: "Total number of product addition errors",
 	})
 
+	RefreshTokenReuse = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "refresh_token_reuse_total",
+		Help: "Total number of reused refresh tokens detected",
+	})
+
+	LoginLockouts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "login_lockouts_total",
+		Help: "Total number of login lockouts after too many failed attempts",
+	}, []string{"scope"})
+
+	AuditFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "audit_failures_total",
+		Help: "Total number of mutations that could not be written to the audit log",
+	})
+
+	NotificationFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "notification_failures_total",
+		Help: "Total number of emails that could not be sent",
+	})
+
 	// gRPC metrics
 	GRPCRequestCount = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "grpc_requests_total",
@@ -2,42 +2,232 @@ package middleware
 
 import (
 	"context"
-	"fmt"
+	"crypto/subtle"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/rbac"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/token"
 )
 
-func Auth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "authorization header required", http.StatusUnauthorized)
-			return
-		}
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenRevoked = errors.New("token revoked")
+	ErrUserDisabled = errors.New("user is disabled")
+	ErrDummyToken   = errors.New("dummy tokens are not accepted")
+	ErrKeyExpired   = errors.New("api key expired")
+	ErrSessionEnded = errors.New("session ended")
+)
 
-		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte("secret"), nil
-		})
+// IsUnauthenticated reports whether err means the credentials were
+// rejected, as opposed to a failure while checking them.
+func IsUnauthenticated(err error) bool {
+	return errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrTokenRevoked) ||
+		errors.Is(err, ErrUserDisabled) || errors.Is(err, ErrDummyToken) ||
+		errors.Is(err, ErrKeyExpired) || errors.Is(err, ErrSessionEnded)
+}
+
+type options struct {
+	rejectDummy bool
+}
+
+type Option func(*options)
+
+// RejectDummyTokens makes authentication fail for tokens issued by
+// /dummyLogin. It is enabled in prod.
+func RejectDummyTokens() Option {
+	return func(o *options) {
+		o.rejectDummy = true
+	}
+}
+
+// Authenticate verifies an access token and returns a context carrying the
+// caller as an rbac.Principal. It is shared by the HTTP middleware and the
+// gRPC interceptor.
+func Authenticate(ctx context.Context, keys *token.KeySet, db storage.Storage, tokenStr string, opts ...Option) (context.Context, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var claims token.Claims
+	parsed, err := keys.Parse(tokenStr, &claims)
+	if err != nil || !parsed.Valid {
+		return nil, ErrInvalidToken
+	}
+	if claims.Dummy && o.rejectDummy {
+		return nil, ErrDummyToken
+	}
+	if claims.MFAPending {
+		return nil, ErrInvalidToken
+	}
+
+	// Tokens without jti or exp can't be revoked
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil, ErrInvalidToken
+	}
+
+	revoked, err := db.IsTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	principal := rbac.Principal{
+		Role:           claims.Role,
+		PVZIDs:         []uuid.UUID{},
+		Method:         rbac.AuthDummy,
+		TokenID:        claims.ID,
+		TokenExpiresAt: claims.ExpiresAt.Time,
+	}
+
+	// Tokens issued before sessions were introduced have no sid and are
+	// only checked against the denylist
+	if claims.SessionID != "" {
+		sessionID, err := uuid.Parse(claims.SessionID)
+		if err != nil {
+			return nil, ErrInvalidToken
+		}
+		principal.SessionID = sessionID
+		active, err := db.TouchSession(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			return nil, ErrSessionEnded
+		}
+	}
 
-		if err != nil || !token.Valid {
-			http.Error(w, "invalid token", http.StatusUnauthorized)
-			return
+	// Dummy tokens have no subject and no assigned PVZs. For real users the
+	// stored role wins over the claim so role changes apply immediately.
+	if userID, err := uuid.Parse(claims.Subject); err == nil {
+		user, err := db.GetUserByID(ctx, userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrInvalidToken
 		}
+		if err != nil {
+			return nil, err
+		}
+		if user.DisabledAt != nil {
+			return nil, ErrUserDisabled
+		}
+		principal.UserID = userID
+		principal.Role = user.Role
+		principal.Method = rbac.AuthToken
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			http.Error(w, "invalid token claims", http.StatusUnauthorized)
-			return
+		if principal.PVZIDs, err = db.GetUserPVZIDs(ctx, userID); err != nil {
+			return nil, err
 		}
+	}
+
+	names, err := db.GetRolePermissions(ctx, principal.Role)
+	if err != nil {
+		return nil, err
+	}
+	principal.Permissions = toPermissions(names)
+
+	return rbac.WithPrincipal(ctx, principal), nil
+}
+
+// AuthenticateAPIKey verifies a service account key. The caller gets the
+// account's own permissions and no assigned PVZs, so PVZ data is only
+// visible with pvz:access_all.
+func AuthenticateAPIKey(ctx context.Context, db storage.Storage, plain string) (context.Context, error) {
+	keyID, ok := token.ParseAPIKey(plain)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	key, err := db.GetAPIKey(ctx, keyID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(token.HashOpaqueToken(plain))) != 1 {
+		return nil, ErrInvalidToken
+	}
+	if key.RevokedAt != nil {
+		return nil, ErrTokenRevoked
+	}
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now()) {
+		return nil, ErrKeyExpired
+	}
+
+	account, err := db.GetServiceAccount(ctx, key.ServiceAccountID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := db.TouchAPIKey(ctx, key.ID); err != nil {
+		return nil, err
+	}
+
+	return rbac.WithPrincipal(ctx, rbac.Principal{
+		ServiceAccountID: account.ID,
+		Role:             rbac.RoleServiceAccount,
+		Permissions:      toPermissions(account.Permissions),
+		PVZIDs:           []uuid.UUID{},
+		Method:           rbac.AuthAPIKey,
+	}), nil
+}
 
-		role, _ := claims["role"].(string)
-		ctx := context.WithValue(r.Context(), "role", role)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+func toPermissions(names []string) []rbac.Permission {
+	permissions := make([]rbac.Permission, 0, len(names))
+	for _, name := range names {
+		permissions = append(permissions, rbac.Permission(name))
+	}
+	return permissions
+}
+
+func Auth(keys *token.KeySet, db storage.Storage, opts ...Option) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var ctx context.Context
+			var err error
+			if apiKey := r.Header.Get("X-Api-Key"); apiKey != "" {
+				ctx, err = AuthenticateAPIKey(r.Context(), db, apiKey)
+			} else {
+				authHeader := r.Header.Get("Authorization")
+				if authHeader == "" {
+					http.Error(w, "authorization header required", http.StatusUnauthorized)
+					return
+				}
+				tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
+				ctx, err = Authenticate(r.Context(), keys, db, tokenStr, opts...)
+			}
+			if err != nil {
+				if IsUnauthenticated(err) {
+					http.Error(w, err.Error(), http.StatusUnauthorized)
+					return
+				}
+				http.Error(w, "failed to verify token", http.StatusInternalServerError)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequirePermission rejects requests whose caller lacks the permission.
+// It must be mounted after Auth.
+func RequirePermission(permission rbac.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := rbac.Authorize(r.Context(), permission); err != nil {
+				http.Error(w, "permission "+string(permission)+" required", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
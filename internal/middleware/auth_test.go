@@ -1,24 +1,45 @@
 package middleware_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/mi4r/avito-pvz/internal/middleware"
+	"github.com/mi4r/avito-pvz/internal/rbac"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/storage/mocks"
+	"github.com/mi4r/avito-pvz/internal/token"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestAuth(t *testing.T) {
+	keys, err := token.NewKeySet("current",
+		token.NewHMACKey("current", []byte("secret")),
+		token.NewHMACKey("previous", []byte("old-secret")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := mocks.NewStorage(t)
+	store.On("IsTokenRevoked", mock.Anything, "revoked-jti").Return(true, nil)
+	store.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	store.On("GetRolePermissions", mock.Anything, "employee").Return([]string{"pvz:read", "reception:create"}, nil)
+	store.On("GetRolePermissions", mock.Anything, mock.Anything).Return([]string{}, nil)
+	auth := middleware.Auth(keys, store)
+
 	// Helper function to create a valid JWT token
 	createToken := func(role string) string {
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		tokenString, _ := keys.Sign(jwt.MapClaims{
+			"jti":  uuid.NewString(),
 			"role": role,
 			"exp":  time.Now().Add(time.Hour).Unix(),
 		})
-		tokenString, _ := token.SignedString([]byte("secret"))
 		return tokenString
 	}
 
@@ -31,10 +52,9 @@ func TestAuth(t *testing.T) {
 		return req
 	}
 
-	// Test handler that checks for role in context
+	// Test handler that checks for the principal in context
 	roleCheckHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		role := r.Context().Value("role")
-		if role == nil {
+		if _, ok := rbac.PrincipalFromContext(r.Context()); !ok {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
@@ -45,7 +65,7 @@ func TestAuth(t *testing.T) {
 		req := createRequest("")
 		w := httptest.NewRecorder()
 
-		middleware.Auth(roleCheckHandler).ServeHTTP(w, req)
+		auth(roleCheckHandler).ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 		assert.Contains(t, w.Body.String(), "authorization header required")
@@ -55,7 +75,7 @@ func TestAuth(t *testing.T) {
 		req := createRequest("invalid.token.format")
 		w := httptest.NewRecorder()
 
-		middleware.Auth(roleCheckHandler).ServeHTTP(w, req)
+		auth(roleCheckHandler).ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 		assert.Contains(t, w.Body.String(), "invalid token")
@@ -72,7 +92,7 @@ func TestAuth(t *testing.T) {
 		req := createRequest(tokenString)
 		w := httptest.NewRecorder()
 
-		middleware.Auth(roleCheckHandler).ServeHTTP(w, req)
+		auth(roleCheckHandler).ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 		assert.Contains(t, w.Body.String(), "invalid token")
@@ -83,7 +103,7 @@ func TestAuth(t *testing.T) {
 		req := createRequest(tokenString)
 		w := httptest.NewRecorder()
 
-		middleware.Auth(roleCheckHandler).ServeHTTP(w, req)
+		auth(roleCheckHandler).ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
 	})
@@ -93,12 +113,13 @@ func TestAuth(t *testing.T) {
 			"role": "admin",
 			"exp":  time.Now().Add(-time.Hour).Unix(),
 		})
+		token.Header["kid"] = "current"
 		tokenString, _ := token.SignedString([]byte("secret"))
 
 		req := createRequest(tokenString)
 		w := httptest.NewRecorder()
 
-		middleware.Auth(roleCheckHandler).ServeHTTP(w, req)
+		auth(roleCheckHandler).ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 		assert.Contains(t, w.Body.String(), "invalid token")
@@ -106,15 +127,15 @@ func TestAuth(t *testing.T) {
 
 	t.Run("token without role claim", func(t *testing.T) {
 		// Create token without role claim
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		tokenString, _ := keys.Sign(jwt.MapClaims{
+			"jti": uuid.NewString(),
 			"exp": time.Now().Add(time.Hour).Unix(),
 		})
-		tokenString, _ := token.SignedString([]byte("secret"))
 
 		req := createRequest(tokenString)
 		w := httptest.NewRecorder()
 
-		middleware.Auth(roleCheckHandler).ServeHTTP(w, req)
+		auth(roleCheckHandler).ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code) // Empty string is the default value for missing role
 	})
@@ -130,8 +151,391 @@ func TestAuth(t *testing.T) {
 		req := createRequest(tokenString)
 		w := httptest.NewRecorder()
 
-		middleware.Auth(roleCheckHandler).ServeHTTP(w, req)
+		auth(roleCheckHandler).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("token signed with rotated key", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"jti":  uuid.NewString(),
+			"role": "employee",
+			"exp":  time.Now().Add(time.Hour).Unix(),
+		})
+		token.Header["kid"] = "previous"
+		tokenString, _ := token.SignedString([]byte("old-secret"))
+
+		req := createRequest(tokenString)
+		w := httptest.NewRecorder()
+
+		auth(roleCheckHandler).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("unknown key id", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"role": "employee",
+			"exp":  time.Now().Add(time.Hour).Unix(),
+		})
+		token.Header["kid"] = "forged"
+		tokenString, _ := token.SignedString([]byte("secret"))
+
+		req := createRequest(tokenString)
+		w := httptest.NewRecorder()
+
+		auth(roleCheckHandler).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("revoked token", func(t *testing.T) {
+		tokenString, _ := keys.Sign(jwt.MapClaims{
+			"jti":  "revoked-jti",
+			"role": "employee",
+			"exp":  time.Now().Add(time.Hour).Unix(),
+		})
+
+		req := createRequest(tokenString)
+		w := httptest.NewRecorder()
+
+		auth(roleCheckHandler).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "token revoked")
+	})
+
+	t.Run("active session", func(t *testing.T) {
+		sessionID := uuid.New()
+		store.On("TouchSession", mock.Anything, sessionID).Return(true, nil).Once()
+		tokenString, _ := keys.Sign(jwt.MapClaims{
+			"jti":  uuid.NewString(),
+			"role": "employee",
+			"sid":  sessionID.String(),
+			"exp":  time.Now().Add(time.Hour).Unix(),
+		})
+
+		w := httptest.NewRecorder()
+		auth(roleCheckHandler).ServeHTTP(w, createRequest(tokenString))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("revoked session", func(t *testing.T) {
+		sessionID := uuid.New()
+		store.On("TouchSession", mock.Anything, sessionID).Return(false, nil).Once()
+		tokenString, _ := keys.Sign(jwt.MapClaims{
+			"jti":  uuid.NewString(),
+			"role": "employee",
+			"sid":  sessionID.String(),
+			"exp":  time.Now().Add(time.Hour).Unix(),
+		})
+
+		w := httptest.NewRecorder()
+		auth(roleCheckHandler).ServeHTTP(w, createRequest(tokenString))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "session ended")
+	})
+
+	t.Run("pending mfa token", func(t *testing.T) {
+		tokenString, _ := keys.Sign(jwt.MapClaims{
+			"jti":         uuid.NewString(),
+			"sub":         uuid.NewString(),
+			"mfa_pending": true,
+			"exp":         time.Now().Add(time.Hour).Unix(),
+		})
+
+		req := createRequest(tokenString)
+		w := httptest.NewRecorder()
+
+		auth(roleCheckHandler).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("token without jti", func(t *testing.T) {
+		tokenString, _ := keys.Sign(jwt.MapClaims{
+			"role": "employee",
+			"exp":  time.Now().Add(time.Hour).Unix(),
+		})
+
+		req := createRequest(tokenString)
+		w := httptest.NewRecorder()
+
+		auth(roleCheckHandler).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("assigned pvzs are loaded into context", func(t *testing.T) {
+		userID, pvzID := uuid.New(), uuid.New()
+		store.On("GetUserByID", mock.Anything, userID).Return(storage.User{ID: userID, Role: "employee"}, nil)
+		store.On("GetUserPVZIDs", mock.Anything, userID).Return([]uuid.UUID{pvzID}, nil)
+
+		tokenString, _ := keys.Sign(jwt.MapClaims{
+			"jti":  uuid.NewString(),
+			"sub":  userID.String(),
+			"role": "employee",
+			"exp":  time.Now().Add(time.Hour).Unix(),
+		})
+
+		var got rbac.Principal
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, _ = rbac.PrincipalFromContext(r.Context())
+		})
+
+		req := createRequest(tokenString)
+		w := httptest.NewRecorder()
+
+		auth(next).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []uuid.UUID{pvzID}, got.PVZIDs)
+		assert.Equal(t, userID, got.UserID)
+		assert.Equal(t, rbac.AuthToken, got.Method)
+	})
+
+	t.Run("disabled user", func(t *testing.T) {
+		userID := uuid.New()
+		disabledAt := time.Now()
+		store.On("GetUserByID", mock.Anything, userID).
+			Return(storage.User{ID: userID, Role: "employee", DisabledAt: &disabledAt}, nil)
+
+		tokenString, _ := keys.Sign(jwt.MapClaims{
+			"jti":  uuid.NewString(),
+			"sub":  userID.String(),
+			"role": "employee",
+			"exp":  time.Now().Add(time.Hour).Unix(),
+		})
+		req := createRequest(tokenString)
+		w := httptest.NewRecorder()
+
+		auth(roleCheckHandler).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "disabled")
+	})
+
+	t.Run("deleted user", func(t *testing.T) {
+		userID := uuid.New()
+		store.On("GetUserByID", mock.Anything, userID).Return(storage.User{}, storage.ErrNotFound)
+
+		tokenString, _ := keys.Sign(jwt.MapClaims{
+			"jti":  uuid.NewString(),
+			"sub":  userID.String(),
+			"role": "employee",
+			"exp":  time.Now().Add(time.Hour).Unix(),
+		})
+		req := createRequest(tokenString)
+		w := httptest.NewRecorder()
+
+		auth(roleCheckHandler).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("stored role overrides claim", func(t *testing.T) {
+		userID := uuid.New()
+		store.On("GetUserByID", mock.Anything, userID).Return(storage.User{ID: userID, Role: "moderator"}, nil)
+		store.On("GetUserPVZIDs", mock.Anything, userID).Return([]uuid.UUID{}, nil)
+
+		tokenString, _ := keys.Sign(jwt.MapClaims{
+			"jti":  uuid.NewString(),
+			"sub":  userID.String(),
+			"role": "employee",
+			"exp":  time.Now().Add(time.Hour).Unix(),
+		})
+
+		var role string
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, _ := rbac.PrincipalFromContext(r.Context())
+			role = p.Role
+		})
+		req := createRequest(tokenString)
+		w := httptest.NewRecorder()
+
+		auth(next).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "moderator", role)
+	})
+}
+
+func TestRequirePermission(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	createRequest := func(permissions ...rbac.Permission) *http.Request {
+		req := httptest.NewRequest("POST", "/pvz", nil)
+		return req.WithContext(rbac.WithPrincipal(req.Context(), rbac.Principal{Permissions: permissions}))
+	}
+
+	t.Run("permission granted", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		middleware.RequirePermission(rbac.PVZCreate)(ok).ServeHTTP(w, createRequest(rbac.PVZRead, rbac.PVZCreate))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("permission missing", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		middleware.RequirePermission(rbac.PVZCreate)(ok).ServeHTTP(w, createRequest(rbac.PVZRead))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "pvz:create")
+	})
+
+	t.Run("unauthenticated request", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		middleware.RequirePermission(rbac.PVZRead)(ok).ServeHTTP(w, httptest.NewRequest("GET", "/pvz", nil))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestAuthLoadsPermissions(t *testing.T) {
+	keys, _ := token.NewKeySet("k", token.NewHMACKey("k", []byte("secret")))
+	store := mocks.NewStorage(t)
+	store.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	store.On("GetRolePermissions", mock.Anything, "moderator").Return([]string{"pvz:create", "pvz:read"}, nil)
+
+	tokenString, _ := keys.Sign(jwt.MapClaims{
+		"jti":  uuid.NewString(),
+		"role": "moderator",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	})
+	req := httptest.NewRequest("POST", "/pvz", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenString)
+	w := httptest.NewRecorder()
+
+	var allowed bool
+	next := middleware.RequirePermission(rbac.PVZCreate)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed = true
+	}))
+	middleware.Auth(keys, store)(next).ServeHTTP(w, req)
+
+	assert.True(t, allowed)
+}
+
+func TestAuthDummyTokens(t *testing.T) {
+	keys, _ := token.NewKeySet("k", token.NewHMACKey("k", []byte("secret")))
+	issuer := token.NewIssuer(keys, time.Hour, time.Hour)
+	store := mocks.NewStorage(t)
+	store.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil).Maybe()
+	store.On("GetRolePermissions", mock.Anything, "moderator").Return([]string{"pvz:create"}, nil).Maybe()
+
+	dummy, _, _ := issuer.DummyToken("moderator")
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("accepted by default", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+dummy)
+		w := httptest.NewRecorder()
+
+		middleware.Auth(keys, store)(ok).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("rejected in prod", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+dummy)
+		w := httptest.NewRecorder()
+
+		middleware.Auth(keys, store, middleware.RejectDummyTokens())(ok).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "dummy")
+	})
+}
+
+func TestAuthAPIKey(t *testing.T) {
+	keys, _ := token.NewKeySet("k", token.NewHMACKey("k", []byte("secret")))
+	plain, keyID, hash, _ := token.NewAPIKey()
+	accountID := uuid.New()
+	account := storage.ServiceAccount{ID: accountID, Name: "warehouse", Permissions: []string{"pvz:read"}}
+
+	serve := func(store storage.Storage, key string) (*httptest.ResponseRecorder, context.Context) {
+		var ctx context.Context
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx = r.Context()
+		})
+		req := httptest.NewRequest("GET", "/pvz", nil)
+		req.Header.Set("X-Api-Key", key)
+		w := httptest.NewRecorder()
+		middleware.Auth(keys, store)(next).ServeHTTP(w, req)
+		return w, ctx
+	}
+
+	t.Run("valid key", func(t *testing.T) {
+		store := mocks.NewStorage(t)
+		apiKey := storage.APIKey{ID: uuid.New(), ServiceAccountID: accountID, KeyID: keyID, KeyHash: hash}
+		store.On("GetAPIKey", mock.Anything, keyID).Return(apiKey, nil)
+		store.On("GetServiceAccount", mock.Anything, accountID).Return(account, nil)
+		store.On("TouchAPIKey", mock.Anything, apiKey.ID).Return(nil)
+
+		w, ctx := serve(store, plain)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		p, _ := rbac.PrincipalFromContext(ctx)
+		assert.Equal(t, rbac.RoleServiceAccount, p.Role)
+		assert.Equal(t, rbac.AuthAPIKey, p.Method)
+		assert.Equal(t, accountID, p.ServiceAccountID)
+		assert.False(t, p.IsUser())
+		assert.Equal(t, []uuid.UUID{}, p.PVZIDs)
+		assert.True(t, rbac.HasPermission(ctx, rbac.PVZRead))
+		assert.False(t, rbac.HasPermission(ctx, rbac.PVZCreate))
+	})
+
+	t.Run("malformed key", func(t *testing.T) {
+		w, _ := serve(mocks.NewStorage(t), "not-a-key")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		store := mocks.NewStorage(t)
+		store.On("GetAPIKey", mock.Anything, keyID).Return(storage.APIKey{}, storage.ErrNotFound)
+
+		w, _ := serve(store, plain)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		store := mocks.NewStorage(t)
+		store.On("GetAPIKey", mock.Anything, keyID).
+			Return(storage.APIKey{ServiceAccountID: accountID, KeyID: keyID, KeyHash: hash}, nil)
+
+		w, _ := serve(store, token.APIKeyPrefix+keyID+"_forged")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("revoked key", func(t *testing.T) {
+		revokedAt := time.Now().Add(-time.Minute)
+		store := mocks.NewStorage(t)
+		store.On("GetAPIKey", mock.Anything, keyID).
+			Return(storage.APIKey{ServiceAccountID: accountID, KeyID: keyID, KeyHash: hash, RevokedAt: &revokedAt}, nil)
+
+		w, _ := serve(store, plain)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "revoked")
+	})
+
+	t.Run("expired key", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Minute)
+		store := mocks.NewStorage(t)
+		store.On("GetAPIKey", mock.Anything, keyID).
+			Return(storage.APIKey{ServiceAccountID: accountID, KeyID: keyID, KeyHash: hash, ExpiresAt: &expiresAt}, nil)
+
+		w, _ := serve(store, plain)
 
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "expired")
 	})
 }
@@ -0,0 +1,64 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mi4r/avito-pvz/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIPNets(t *testing.T) {
+	nets, err := middleware.ParseIPNets([]string{"192.0.2.1", "10.0.0.0/8", "2001:db8::1"})
+
+	assert.NoError(t, err)
+	assert.Len(t, nets, 3)
+	assert.Equal(t, "192.0.2.1/32", nets[0].String())
+	assert.Equal(t, "2001:db8::1/128", nets[2].String())
+
+	_, err = middleware.ParseIPNets([]string{"not-an-ip"})
+	assert.Error(t, err)
+	_, err = middleware.ParseIPNets([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
+
+func TestAllowIPs(t *testing.T) {
+	nets, _ := middleware.ParseIPNets([]string{"10.0.0.0/8", "192.0.2.1"})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		allowed    bool
+		remoteAddr string
+		want       int
+	}{
+		{"ip in cidr", true, "10.1.2.3:5000", http.StatusOK},
+		{"exact ip", true, "192.0.2.1:5000", http.StatusOK},
+		{"other ip", true, "192.0.2.2:5000", http.StatusForbidden},
+		{"empty allowlist", false, "10.1.2.3:5000", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed := nets
+			if !tt.allowed {
+				allowed = nil
+			}
+			req := httptest.NewRequest("POST", "/dummyLogin", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", "10.0.0.1")
+			w := httptest.NewRecorder()
+
+			middleware.AllowIPs(allowed)(ok).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Code)
+			if tt.want == http.StatusForbidden {
+				assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+				assert.JSONEq(t, `{"error": "forbidden"}`, w.Body.String())
+			}
+		})
+	}
+}
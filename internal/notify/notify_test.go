@@ -0,0 +1,131 @@
+package notify_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/mi4r/avito-pvz/internal/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriterNotifier(t *testing.T) {
+	var buf bytes.Buffer
+	n := notify.NewWriterNotifier(&buf)
+
+	err := n.Send(context.Background(), notify.Message{To: "user@example.com", Subject: "Hi", Body: "line 1\nline 2"})
+	require.NoError(t, err)
+
+	var got map[string]string
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "user@example.com", got["to"])
+	assert.Equal(t, "Hi", got["subject"])
+	assert.Equal(t, "line 1\nline 2", got["body"])
+	assert.True(t, strings.HasSuffix(buf.String(), "}\n"))
+}
+
+// fakeSMTP accepts one message without TLS or authentication and
+// returns the envelope and data it received.
+func fakeSMTP(t *testing.T) (string, <-chan []string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("loopback listener unavailable: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var lines []string
+		r := bufio.NewReader(conn)
+		reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+		reply("220 fake ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 fake")
+			case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"):
+				lines = append(lines, strings.TrimSpace(line))
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				for {
+					data, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if data == ".\r\n" {
+						break
+					}
+					lines = append(lines, strings.TrimRight(data, "\r\n"))
+				}
+				reply("250 OK")
+			case cmd == "QUIT":
+				reply("221 bye")
+				received <- lines
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	addr, received := fakeSMTP(t)
+	n, err := notify.NewSMTPNotifier(notify.SMTPConfig{Addr: addr, From: "PVZ <noreply@example.com>"})
+	require.NoError(t, err)
+
+	err = n.Send(context.Background(), notify.Message{
+		To:      "user@example.com",
+		Subject: "Сброс пароля",
+		Body:    "Ссылка: https://example.com/reset?token=abc",
+	})
+	require.NoError(t, err)
+
+	lines := <-received
+	assert.Equal(t, "MAIL FROM:<noreply@example.com>", strings.Split(lines[0], " BODY")[0])
+	assert.Equal(t, "RCPT TO:<user@example.com>", lines[1])
+
+	msg, err := mail.ReadMessage(strings.NewReader(strings.Join(lines[2:], "\r\n")))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Сброс пароля", subject)
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	require.NoError(t, err)
+	assert.Equal(t, "Ссылка: https://example.com/reset?token=abc", string(body))
+}
+
+func TestSMTPNotifierRejectsInvalidAddresses(t *testing.T) {
+	_, err := notify.NewSMTPNotifier(notify.SMTPConfig{Addr: "localhost", From: "noreply@example.com"})
+	assert.Error(t, err)
+
+	_, err = notify.NewSMTPNotifier(notify.SMTPConfig{Addr: "localhost:25", From: "not an address"})
+	assert.ErrorIs(t, err, notify.ErrInvalidAddress)
+
+	n, err := notify.NewSMTPNotifier(notify.SMTPConfig{Addr: "localhost:25", From: "noreply@example.com"})
+	require.NoError(t, err)
+	err = n.Send(context.Background(), notify.Message{To: "user@example.com\r\nBcc: x@example.com"})
+	assert.ErrorIs(t, err, notify.ErrInvalidAddress)
+}
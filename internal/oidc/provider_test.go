@@ -0,0 +1,173 @@
+package oidc_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mi4r/avito-pvz/internal/oidc"
+	"github.com/mi4r/avito-pvz/internal/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURL = "https://pvz.test/auth/oidc/callback"
+
+func discover(t *testing.T, idp *oidctest.Server) *oidc.Provider {
+	t.Helper()
+	provider, err := oidc.Discover(context.Background(), oidc.Config{
+		Issuer:       oidctest.Issuer,
+		ClientID:     idp.ClientID,
+		ClientSecret: idp.ClientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"email"},
+		HTTPClient:   idp.Client(),
+	})
+	require.NoError(t, err)
+	return provider
+}
+
+// authorize follows the provider's redirect and returns the code.
+func authorize(t *testing.T, idp *oidctest.Server, authURL string) url.Values {
+	t.Helper()
+	resp, err := idp.Client().Get(authURL)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return location.Query()
+}
+
+func TestDiscover(t *testing.T) {
+	idp := oidctest.NewServer("pvz", "secret")
+
+	t.Run("issuer mismatch", func(t *testing.T) {
+		_, err := oidc.Discover(context.Background(), oidc.Config{
+			Issuer:     oidctest.Issuer + "/tenant",
+			ClientID:   "pvz",
+			HTTPClient: idp.Client(),
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("auth code url", func(t *testing.T) {
+		provider := discover(t, idp)
+
+		authURL, err := url.Parse(provider.AuthCodeURL("state", "nonce", "verifier"))
+		require.NoError(t, err)
+		q := authURL.Query()
+		assert.Equal(t, oidctest.Issuer+"/authorize", authURL.Scheme+"://"+authURL.Host+authURL.Path)
+		assert.Equal(t, "openid email", q.Get("scope"))
+		assert.Equal(t, redirectURL, q.Get("redirect_uri"))
+		assert.Equal(t, oidc.CodeChallenge("verifier"), q.Get("code_challenge"))
+		assert.Equal(t, "S256", q.Get("code_challenge_method"))
+	})
+}
+
+func TestExchange(t *testing.T) {
+	idp := oidctest.NewServer("pvz", "secret")
+	provider := discover(t, idp)
+	idp.SignIn(oidctest.User{
+		Subject:       "staff-1",
+		Email:         "Staff@Example.com",
+		EmailVerified: true,
+		Groups:        []string{"pvz-staff", "everyone"},
+	})
+
+	t.Run("code flow with pkce", func(t *testing.T) {
+		verifier, err := oidc.NewVerifier()
+		require.NoError(t, err)
+		params := authorize(t, idp, provider.AuthCodeURL("state-1", "nonce-1", verifier))
+		assert.Equal(t, "state-1", params.Get("state"))
+
+		identity, err := provider.Exchange(context.Background(), params.Get("code"), verifier, "nonce-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, oidctest.Issuer, identity.Issuer)
+		assert.Equal(t, "staff-1", identity.Subject)
+		assert.Equal(t, "Staff@Example.com", identity.Email)
+		require.NotNil(t, identity.EmailVerified)
+		assert.True(t, *identity.EmailVerified)
+		assert.Equal(t, []string{"pvz-staff", "everyone"}, identity.Groups)
+	})
+
+	t.Run("wrong verifier", func(t *testing.T) {
+		params := authorize(t, idp, provider.AuthCodeURL("state", "nonce", "verifier"))
+
+		_, err := provider.Exchange(context.Background(), params.Get("code"), "other-verifier", "nonce")
+
+		assert.ErrorIs(t, err, oidc.ErrExchange)
+	})
+
+	t.Run("code can't be reused", func(t *testing.T) {
+		params := authorize(t, idp, provider.AuthCodeURL("state", "nonce", "verifier"))
+		_, err := provider.Exchange(context.Background(), params.Get("code"), "verifier", "nonce")
+		require.NoError(t, err)
+
+		_, err = provider.Exchange(context.Background(), params.Get("code"), "verifier", "nonce")
+
+		assert.ErrorIs(t, err, oidc.ErrExchange)
+	})
+
+	t.Run("nonce mismatch", func(t *testing.T) {
+		params := authorize(t, idp, provider.AuthCodeURL("state", "nonce", "verifier"))
+
+		_, err := provider.Exchange(context.Background(), params.Get("code"), "verifier", "other-nonce")
+
+		assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+	})
+}
+
+func TestVerify(t *testing.T) {
+	idp := oidctest.NewServer("pvz", "secret")
+	provider := discover(t, idp)
+
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"iss":   oidctest.Issuer,
+			"sub":   "staff-1",
+			"aud":   "pvz",
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"nonce": "nonce",
+		}
+		for k, v := range overrides {
+			c[k] = v
+		}
+		return c
+	}
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+	}{
+		{"wrong audience", claims(jwt.MapClaims{"aud": "other-client"})},
+		{"wrong issuer", claims(jwt.MapClaims{"iss": "https://evil.test"})},
+		{"expired", claims(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})},
+		{"missing subject", claims(jwt.MapClaims{"sub": ""})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.Verify(context.Background(), idp.IDToken(tt.claims), "nonce")
+			assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+		})
+	}
+
+	t.Run("unsigned token", func(t *testing.T) {
+		unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, claims(nil)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+		_, err := provider.Verify(context.Background(), unsigned, "nonce")
+
+		assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+	})
+
+	t.Run("valid token", func(t *testing.T) {
+		identity, err := provider.Verify(context.Background(), idp.IDToken(claims(nil)), "nonce")
+
+		require.NoError(t, err)
+		assert.Equal(t, "staff-1", identity.Subject)
+		assert.Nil(t, identity.EmailVerified)
+	})
+}
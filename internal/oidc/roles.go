@@ -0,0 +1,44 @@
+package oidc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GroupRole maps an IdP group to a role of the service.
+type GroupRole struct {
+	Group string
+	Role  string
+}
+
+// GroupRoles is an ordered mapping; the first entry whose group the user
+// belongs to decides the role, so list privileged groups first.
+type GroupRoles []GroupRole
+
+// ParseGroupRoles parses "group:role" pairs, e.g. "pvz-admins:admin".
+// Group names may contain colons, the role is taken after the last one.
+func ParseGroupRoles(specs []string) (GroupRoles, error) {
+	mapping := make(GroupRoles, 0, len(specs))
+	for _, spec := range specs {
+		i := strings.LastIndex(spec, ":")
+		if i <= 0 || i == len(spec)-1 {
+			return nil, fmt.Errorf("invalid group role mapping %q, expected group:role", spec)
+		}
+		mapping = append(mapping, GroupRole{Group: spec[:i], Role: spec[i+1:]})
+	}
+	return mapping, nil
+}
+
+// Role returns the role for the user's groups, or false if none is mapped.
+func (m GroupRoles) Role(groups []string) (string, bool) {
+	member := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		member[g] = true
+	}
+	for _, entry := range m {
+		if member[entry.Group] {
+			return entry.Role, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,41 @@
+package oidc_test
+
+import (
+	"testing"
+
+	"github.com/mi4r/avito-pvz/internal/oidc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupRoles(t *testing.T) {
+	roles, err := oidc.ParseGroupRoles([]string{"pvz-admins:admin", "cn=mods,ou=pvz:moderator", "pvz-staff:employee"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		groups []string
+		role   string
+		ok     bool
+	}{
+		{"single group", []string{"pvz-staff"}, "employee", true},
+		{"first mapping wins", []string{"pvz-staff", "pvz-admins"}, "admin", true},
+		{"group with colon", []string{"cn=mods,ou=pvz"}, "moderator", true},
+		{"no mapped group", []string{"sales"}, "", false},
+		{"no groups", nil, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role, ok := roles.Role(tt.groups)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.role, role)
+		})
+	}
+}
+
+func TestParseGroupRolesInvalid(t *testing.T) {
+	for _, spec := range []string{"admins", ":admin", "admins:"} {
+		_, err := oidc.ParseGroupRoles([]string{spec})
+		assert.Error(t, err, spec)
+	}
+}
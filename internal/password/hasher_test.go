@@ -0,0 +1,93 @@
+package password_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mi4r/avito-pvz/internal/password"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testArgon2 = password.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestHasherArgon2id(t *testing.T) {
+	h := password.Hasher{Algorithm: password.Argon2id, Argon2: testArgon2}
+
+	hash, err := h.Hash("pass1234")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"), hash)
+	assert.NoError(t, h.Verify("pass1234", hash))
+	assert.ErrorIs(t, h.Verify("pass12345", hash), password.ErrMismatch)
+	assert.False(t, h.NeedsRehash(hash))
+
+	other, _ := h.Hash("pass1234")
+	assert.NotEqual(t, hash, other, "salt must be random")
+}
+
+func TestHasherBcrypt(t *testing.T) {
+	h := password.Hasher{Algorithm: password.Bcrypt, BcryptCost: bcrypt.MinCost}
+
+	hash, err := h.Hash("pass1234")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(hash, "$2a$04$"), hash)
+	assert.NoError(t, h.Verify("pass1234", hash))
+	assert.ErrorIs(t, h.Verify("wrong", hash), password.ErrMismatch)
+	assert.False(t, h.NeedsRehash(hash))
+}
+
+func TestHasherNeedsRehash(t *testing.T) {
+	argon := password.Hasher{Algorithm: password.Argon2id, Argon2: testArgon2}
+	bcryptHash, _ := bcrypt.GenerateFromPassword([]byte("pass1234"), bcrypt.MinCost)
+	argonHash, _ := argon.Hash("pass1234")
+
+	stronger := testArgon2
+	stronger.Iterations = 2
+
+	tests := []struct {
+		name   string
+		hasher password.Hasher
+		hash   string
+		want   bool
+	}{
+		{"bcrypt to argon2id", argon, string(bcryptHash), true},
+		{"argon2id to bcrypt", password.Hasher{Algorithm: password.Bcrypt, BcryptCost: bcrypt.MinCost}, argonHash, true},
+		{"bcrypt cost raised", password.Hasher{Algorithm: password.Bcrypt, BcryptCost: bcrypt.MinCost + 1}, string(bcryptHash), true},
+		{"argon2id iterations raised", password.Hasher{Algorithm: password.Argon2id, Argon2: stronger}, argonHash, true},
+		{"current parameters", argon, argonHash, false},
+		{"unknown format", argon, "plain", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.hasher.NeedsRehash(tt.hash))
+		})
+	}
+
+	// Old hashes keep working whatever is configured now
+	assert.NoError(t, argon.Verify("pass1234", string(bcryptHash)))
+}
+
+func TestHasherVerifyMalformed(t *testing.T) {
+	h := password.Hasher{Algorithm: password.Argon2id, Argon2: testArgon2}
+
+	for _, hash := range []string{
+		"",
+		"$argon2i$v=19$m=1024,t=1,p=1$c2FsdA$aGFzaA",
+		"$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=1024,t=0,p=1$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=1024,t=1,p=1$!!$aGFzaA",
+	} {
+		assert.ErrorIs(t, h.Verify("pass1234", hash), password.ErrUnknownHash, hash)
+	}
+}
+
+func TestHasherValidate(t *testing.T) {
+	assert.NoError(t, password.Hasher{Algorithm: password.Argon2id, Argon2: password.DefaultArgon2Params}.Validate())
+	assert.NoError(t, password.Hasher{Algorithm: password.Bcrypt, BcryptCost: bcrypt.DefaultCost}.Validate())
+	assert.Error(t, password.Hasher{Algorithm: password.Bcrypt, BcryptCost: 40}.Validate())
+	assert.Error(t, password.Hasher{Algorithm: password.Argon2id}.Validate())
+	assert.Error(t, password.Hasher{Algorithm: "md5"}.Validate())
+}
@@ -0,0 +1,52 @@
+package password_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mi4r/avito-pvz/internal/password"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	policy := password.Policy{
+		MinLength:     8,
+		MaxLength:     72,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  string
+	}{
+		{"valid", "Passw0rd!", ""},
+		{"valid cyrillic", "Пароль12!", ""},
+		{"empty", "", "at least 8 characters"},
+		{"too short", "Pa0!", "at least 8 characters"},
+		{"too long", strings.Repeat("Aa1!", 19), "at most 72 bytes"},
+		{"no uppercase", "passw0rd!", "uppercase"},
+		{"no lowercase", "PASSW0RD!", "lowercase"},
+		{"no digit", "Password!", "digit"},
+		{"no symbol", "Passw0rdd", "symbol"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, password.ErrWeakPassword)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	t.Run("zero policy accepts anything", func(t *testing.T) {
+		assert.NoError(t, password.Policy{}.Validate(""))
+	})
+}
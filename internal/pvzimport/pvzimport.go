@@ -0,0 +1,181 @@
+package pvzimport
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mi4r/avito-pvz/internal/storage"
+)
+
+// Formats of import files.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// MaxRows limits the number of PVZs in one import.
+const MaxRows = 10_000
+
+var ErrInvalidFile = errors.New("invalid import file")
+
+// Parse reads the PVZs of a file in format. A malformed file fails as a
+// whole with ErrInvalidFile, a row that can't be read gets its own error
+// so that it's reported along with the others.
+func Parse(format string, r io.Reader) ([]storage.ImportRow, error) {
+	switch format {
+	case FormatCSV:
+		return ParseCSV(r)
+	case FormatJSON:
+		return ParseJSON(r)
+	}
+	return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidFile, format)
+}
+
+// csvColumns are the columns a CSV file can have besides the weekdays,
+// which hold working hours as HH:MM-HH:MM. Only city is required.
+var csvColumns = []string{"city", "code", "address", "latitude", "longitude", "timezone", "phone"}
+
+// ParseCSV reads a CSV file with a header row. Columns can go in any
+// order, empty cells are left unset.
+func ParseCSV(r io.Reader) ([]storage.ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read header: %w", ErrInvalidFile, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheets often save UTF-8 with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !slices.Contains(csvColumns, name) && !slices.Contains(storage.Weekdays, name) {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidFile, name)
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("%w: duplicate column %q", ErrInvalidFile, name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["city"]; !ok {
+		return nil, fmt.Errorf("%w: city column is required", ErrInvalidFile)
+	}
+
+	var rows []storage.ImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if len(rows) == MaxRows {
+			return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidFile, MaxRows)
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount) {
+			rows = append(rows, storage.ImportRow{Err: fmt.Errorf("%w: expected %d fields", storage.ErrInvalidPVZ, len(header))})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+		}
+
+		cell := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		pvz, err := csvPVZ(cell)
+		rows = append(rows, storage.ImportRow{PVZ: pvz, Err: err})
+	}
+	return rows, nil
+}
+
+func csvPVZ(cell func(name string) string) (storage.PVZ, error) {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: "+format, append([]any{storage.ErrInvalidPVZ}, args...)...)
+	}
+
+	pvz := storage.PVZ{
+		City:     cell("city"),
+		Address:  cell("address"),
+		Timezone: cell("timezone"),
+		Phone:    cell("phone"),
+	}
+	if code := cell("code"); code != "" {
+		pvz.Code = &code
+	}
+	for _, coord := range []struct {
+		name string
+		dest **float64
+	}{{"latitude", &pvz.Latitude}, {"longitude", &pvz.Longitude}} {
+		if v := cell(coord.name); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return storage.PVZ{}, invalid("%s must be a number", coord.name)
+			}
+			*coord.dest = &f
+		}
+	}
+	for _, day := range storage.Weekdays {
+		v := cell(day)
+		if v == "" {
+			continue
+		}
+		open, closing, ok := strings.Cut(v, "-")
+		if !ok {
+			return storage.PVZ{}, invalid("%s: hours must be HH:MM-HH:MM", day)
+		}
+		if pvz.WorkingHours == nil {
+			pvz.WorkingHours = storage.WorkingHours{}
+		}
+		pvz.WorkingHours[day] = storage.DayHours{Open: strings.TrimSpace(open), Close: strings.TrimSpace(closing)}
+	}
+	return pvz, nil
+}
+
+// ParseJSON reads a JSON array of PVZs in the format of POST /pvz.
+func ParseJSON(r io.Reader) ([]storage.ImportRow, error) {
+	var items []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, fmt.Errorf("%w: expected a JSON array: %w", ErrInvalidFile, err)
+	}
+	if len(items) > MaxRows {
+		return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidFile, MaxRows)
+	}
+
+	rows := make([]storage.ImportRow, 0, len(items))
+	for _, item := range items {
+		var v struct {
+			City         string               `json:"city"`
+			Code         *string              `json:"code"`
+			Address      string               `json:"address"`
+			Latitude     *float64             `json:"latitude"`
+			Longitude    *float64             `json:"longitude"`
+			Timezone     string               `json:"timezone"`
+			Phone        string               `json:"phone"`
+			WorkingHours storage.WorkingHours `json:"workingHours"`
+		}
+		if err := json.Unmarshal(item, &v); err != nil {
+			rows = append(rows, storage.ImportRow{Err: fmt.Errorf("%w: %v", storage.ErrInvalidPVZ, err)})
+			continue
+		}
+		rows = append(rows, storage.ImportRow{PVZ: storage.PVZ{
+			City:         v.City,
+			Code:         v.Code,
+			Address:      strings.TrimSpace(v.Address),
+			Latitude:     v.Latitude,
+			Longitude:    v.Longitude,
+			Timezone:     v.Timezone,
+			Phone:        v.Phone,
+			WorkingHours: v.WorkingHours,
+		}})
+	}
+	return rows, nil
+}
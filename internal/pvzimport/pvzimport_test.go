@@ -0,0 +1,81 @@
+package pvzimport_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mi4r/avito-pvz/internal/pvzimport"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSV(t *testing.T) {
+	file := "\ufeffCity,code,address,latitude,longitude,mon,sat\n" +
+		"Казань,KZN-7,\"ул. Баумана, 1\",55.7887,49.1221,09:00-21:00,10:00-18:00\n" +
+		"Казань,,,,,,\n" +
+		"Казань,KZN-8,,север,49.1,,\n" +
+		"Казань,KZN-9\n" +
+		"Казань,KZN-10,,,,с 9 до 21,\n"
+
+	rows, err := pvzimport.ParseCSV(strings.NewReader(file))
+
+	require.NoError(t, err)
+	require.Len(t, rows, 5)
+
+	first := rows[0].PVZ
+	assert.NoError(t, rows[0].Err)
+	assert.Equal(t, "Казань", first.City)
+	assert.Equal(t, "KZN-7", *first.Code)
+	assert.Equal(t, "ул. Баумана, 1", first.Address)
+	assert.Equal(t, 49.1221, *first.Longitude)
+	assert.Equal(t, storage.WorkingHours{
+		"mon": {Open: "09:00", Close: "21:00"},
+		"sat": {Open: "10:00", Close: "18:00"},
+	}, first.WorkingHours)
+
+	assert.NoError(t, rows[1].Err)
+	assert.Nil(t, rows[1].PVZ.Code)
+	assert.Nil(t, rows[1].PVZ.Latitude)
+
+	assert.ErrorIs(t, rows[2].Err, storage.ErrInvalidPVZ)
+	assert.ErrorIs(t, rows[3].Err, storage.ErrInvalidPVZ)
+	assert.ErrorContains(t, rows[4].Err, "HH:MM-HH:MM")
+}
+
+func TestParseCSVInvalidFile(t *testing.T) {
+	for name, file := range map[string]string{
+		"empty":            "",
+		"unknown column":   "city,region\nКазань,Татарстан\n",
+		"no city column":   "code,address\nKZN-7,ул. Баумана\n",
+		"duplicate column": "city,City\nКазань,Казань\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := pvzimport.ParseCSV(strings.NewReader(file))
+
+			assert.ErrorIs(t, err, pvzimport.ErrInvalidFile)
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	file := `[
+		{"city": "Москва", "code": "MSK-1", "address": " ул. Тверская, 1 ", "workingHours": {"mon": {"open": "09:00", "close": "21:00"}}},
+		{"city": "Москва", "latitude": "55.75"}
+	]`
+
+	rows, err := pvzimport.ParseJSON(strings.NewReader(file))
+
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.NoError(t, rows[0].Err)
+	assert.Equal(t, "ул. Тверская, 1", rows[0].PVZ.Address)
+	assert.Equal(t, "21:00", rows[0].PVZ.WorkingHours["mon"].Close)
+	assert.ErrorIs(t, rows[1].Err, storage.ErrInvalidPVZ)
+
+	_, err = pvzimport.ParseJSON(strings.NewReader(`{"city": "Москва"}`))
+	assert.ErrorIs(t, err, pvzimport.ErrInvalidFile)
+
+	_, err = pvzimport.Parse("xlsx", strings.NewReader(""))
+	assert.ErrorIs(t, err, pvzimport.ErrInvalidFile)
+}
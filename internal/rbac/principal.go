@@ -0,0 +1,69 @@
+package rbac
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuthMethod is how the caller proved its identity.
+type AuthMethod string
+
+const (
+	// AuthToken is an access token of a user account
+	AuthToken AuthMethod = "token"
+	// AuthDummy is a token from /dummyLogin. It carries only a role.
+	AuthDummy AuthMethod = "dummy"
+	// AuthAPIKey is a service account key
+	AuthAPIKey AuthMethod = "api_key"
+)
+
+// Principal is the authenticated caller of a request. The auth middleware
+// and the gRPC interceptor build it once and everything downstream reads it
+// from the context instead of looking at the credentials again.
+type Principal struct {
+	// UserID is uuid.Nil unless Method is AuthToken
+	UserID uuid.UUID
+	// ServiceAccountID is set only for AuthAPIKey
+	ServiceAccountID uuid.UUID
+	Role             string
+	Permissions      []Permission
+	// PVZIDs are the PVZs the user is assigned to. Without pvz:access_all
+	// the caller is limited to them.
+	PVZIDs []uuid.UUID
+	Method AuthMethod
+
+	// TokenID, TokenExpiresAt and SessionID describe the access token the
+	// request was made with, so it can be revoked. They are empty for API
+	// keys and SessionID also for tokens issued before sessions existed.
+	TokenID        string
+	TokenExpiresAt time.Time
+	SessionID      uuid.UUID
+}
+
+// IsUser reports whether the caller is a user account, i.e. a write can be
+// attributed to it.
+func (p Principal) IsUser() bool {
+	return p.UserID != uuid.Nil
+}
+
+func (p Principal) HasPermission(permission Permission) bool {
+	return slices.Contains(p.Permissions, permission)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the caller.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the caller stored by WithPrincipal. ok is
+// false for unauthenticated requests, whose zero Principal has no
+// permissions.
+func PrincipalFromContext(ctx context.Context) (p Principal, ok bool) {
+	p, ok = ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
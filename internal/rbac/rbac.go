@@ -0,0 +1,56 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+)
+
+type Permission string
+
+const (
+	PVZCreate            Permission = "pvz:create"
+	PVZRead              Permission = "pvz:read"
+	PVZUpdate            Permission = "pvz:update"
+	PVZStatus            Permission = "pvz:status"
+	PVZAccessAll         Permission = "pvz:access_all"
+	ReceptionCreate      Permission = "reception:create"
+	ReceptionClose       Permission = "reception:close"
+	ProductCreate        Permission = "product:create"
+	ProductDelete        Permission = "product:delete"
+	AssignmentManage     Permission = "assignment:manage"
+	UserManage           Permission = "user:manage"
+	InviteCreate         Permission = "invite:create"
+	ServiceAccountManage Permission = "service_account:manage"
+	SessionView          Permission = "session:view"
+	AuditView            Permission = "audit:view"
+	CityManage           Permission = "city:manage"
+	ScheduleManage       Permission = "schedule:manage"
+)
+
+const (
+	RoleAdmin          = "admin"
+	RoleModerator      = "moderator"
+	RoleEmployee       = "employee"
+	RoleAuditor        = "auditor"
+	RoleFranchiseOwner = "franchise_owner"
+	// RoleServiceAccount is set for callers authenticated with an API key.
+	// It is not stored in the roles table.
+	RoleServiceAccount = "service_account"
+)
+
+var ErrForbidden = errors.New("permission denied")
+
+// HasPermission reports whether the caller stored in ctx by the auth
+// middleware or gRPC interceptor holds the permission.
+func HasPermission(ctx context.Context, permission Permission) bool {
+	p, _ := PrincipalFromContext(ctx)
+	return p.HasPermission(permission)
+}
+
+// Authorize is the single check used by both HTTP and gRPC transports.
+func Authorize(ctx context.Context, permission Permission) error {
+	if !HasPermission(ctx, permission) {
+		return ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package rbac_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/rbac"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorize(t *testing.T) {
+	ctx := rbac.WithPrincipal(context.Background(), rbac.Principal{
+		Permissions: []rbac.Permission{rbac.PVZRead, rbac.ReceptionClose},
+	})
+
+	assert.NoError(t, rbac.Authorize(ctx, rbac.ReceptionClose))
+	assert.ErrorIs(t, rbac.Authorize(ctx, rbac.ProductDelete), rbac.ErrForbidden)
+	assert.ErrorIs(t, rbac.Authorize(context.Background(), rbac.PVZRead), rbac.ErrForbidden)
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	userID := uuid.New()
+	ctx := rbac.WithPrincipal(context.Background(), rbac.Principal{UserID: userID, Role: rbac.RoleEmployee, Method: rbac.AuthToken})
+
+	p, ok := rbac.PrincipalFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, userID, p.UserID)
+	assert.True(t, p.IsUser())
+
+	// Plain string keys must not be mistaken for the principal
+	_, ok = rbac.PrincipalFromContext(context.WithValue(context.Background(), "principal", p))
+	assert.False(t, ok)
+
+	p, ok = rbac.PrincipalFromContext(context.Background())
+	assert.False(t, ok)
+	assert.False(t, p.IsUser())
+}
@@ -0,0 +1,54 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	pvz_v1 "github.com/mi4r/avito-pvz/api/pvz/v1"
+	"github.com/mi4r/avito-pvz/internal/middleware"
+	"github.com/mi4r/avito-pvz/internal/rbac"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// methodPermissions lists the permission required by every RPC.
+// Methods missing from the map are denied.
+var methodPermissions = map[string]rbac.Permission{
+	pvz_v1.PVZService_GetPVZList_FullMethodName:    rbac.PVZRead,
+	pvz_v1.PVZService_GetNearbyPVZs_FullMethodName: rbac.PVZRead,
+}
+
+func (s *Server) authInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var err error
+	if keys := md.Get("x-api-key"); len(keys) > 0 {
+		ctx, err = middleware.AuthenticateAPIKey(ctx, s.store, keys[0])
+	} else {
+		values := md.Get("authorization")
+		if len(values) == 0 {
+			return nil, status.Error(codes.Unauthenticated, "authorization metadata required")
+		}
+		tokenStr := strings.TrimPrefix(values[0], "Bearer ")
+		ctx, err = middleware.Authenticate(ctx, s.keys, s.store, tokenStr, s.authOpts...)
+	}
+	if err != nil {
+		if middleware.IsUnauthenticated(err) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to verify token")
+	}
+	return handler(ctx, req)
+}
+
+func permissionInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	permission, ok := methodPermissions[info.FullMethod]
+	if !ok {
+		return nil, status.Error(codes.PermissionDenied, "method is not allowed")
+	}
+	if err := rbac.Authorize(ctx, permission); err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "permission %s required", permission)
+	}
+	return handler(ctx, req)
+}
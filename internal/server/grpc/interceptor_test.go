@@ -0,0 +1,105 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	pvz_v1 "github.com/mi4r/avito-pvz/api/pvz/v1"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/storage/mocks"
+	"github.com/mi4r/avito-pvz/internal/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestServer_Interceptors(t *testing.T) {
+	keys, err := token.NewKeySet("k", token.NewHMACKey("k", []byte("secret")))
+	require.NoError(t, err)
+
+	mockStore := &mocks.Storage{}
+	mockStore.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	mockStore.On("GetRolePermissions", mock.Anything, "auditor").Return([]string{"pvz:read", "pvz:access_all"}, nil)
+	mockStore.On("GetRolePermissions", mock.Anything, mock.Anything).Return([]string{}, nil)
+	mockStore.On("GetPVZsWithReceptions", mock.Anything, mock.MatchedBy(func(f storage.PVZFilter) bool {
+		return f.PVZIDs == nil
+	})).Return([]storage.PVZWithReceptions{}, nil)
+
+	plainKey, keyID, keyHash, err := token.NewAPIKey()
+	require.NoError(t, err)
+	accountID := uuid.New()
+	apiKey := storage.APIKey{ID: uuid.New(), ServiceAccountID: accountID, KeyID: keyID, KeyHash: keyHash}
+	mockStore.On("GetAPIKey", mock.Anything, keyID).Return(apiKey, nil)
+	mockStore.On("GetAPIKey", mock.Anything, mock.Anything).Return(storage.APIKey{}, storage.ErrNotFound)
+	mockStore.On("GetServiceAccount", mock.Anything, accountID).
+		Return(storage.ServiceAccount{ID: accountID, Permissions: []string{"pvz:read", "pvz:access_all"}}, nil)
+	mockStore.On("TouchAPIKey", mock.Anything, apiKey.ID).Return(nil)
+
+	server := NewServer(mockStore, keys)
+	lis := bufconn.Listen(bufSize)
+	grpcServer := grpc.NewServer(server.ServerOptions()...)
+	pvz_v1.RegisterPVZServiceServer(grpcServer, server)
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet", grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pvz_v1.NewPVZServiceClient(conn)
+
+	withToken := func(role string) context.Context {
+		tokenStr, _ := keys.Sign(jwt.MapClaims{
+			"jti":  uuid.NewString(),
+			"role": role,
+			"exp":  time.Now().Add(time.Hour).Unix(),
+		})
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+tokenStr)
+	}
+
+	t.Run("missing token", func(t *testing.T) {
+		_, err := client.GetPVZList(context.Background(), &pvz_v1.GetPVZListRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer invalid")
+		_, err := client.GetPVZList(ctx, &pvz_v1.GetPVZListRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("missing permission", func(t *testing.T) {
+		_, err := client.GetPVZList(withToken("nobody"), &pvz_v1.GetPVZListRequest{})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("permission granted", func(t *testing.T) {
+		resp, err := client.GetPVZList(withToken("auditor"), &pvz_v1.GetPVZListRequest{})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Pvzs)
+	})
+
+	t.Run("api key", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", plainKey)
+		resp, err := client.GetPVZList(ctx, &pvz_v1.GetPVZListRequest{})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Pvzs)
+	})
+
+	t.Run("unknown api key", func(t *testing.T) {
+		other, _, _, _ := token.NewAPIKey()
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", other)
+		_, err := client.GetPVZList(ctx, &pvz_v1.GetPVZListRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
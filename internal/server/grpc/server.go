@@ -5,20 +5,30 @@ import (
 	"net"
 	"time"
 
+	"github.com/google/uuid"
 	pvz_v1 "github.com/mi4r/avito-pvz/api/pvz/v1"
+	"github.com/mi4r/avito-pvz/internal/middleware"
+	"github.com/mi4r/avito-pvz/internal/rbac"
 	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/mi4r/avito-pvz/internal/token"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type Server struct {
 	pvz_v1.UnimplementedPVZServiceServer
-	store storage.Storage
+	store    storage.Storage
+	keys     *token.KeySet
+	authOpts []middleware.Option
 }
 
-func NewServer(store storage.Storage) *Server {
+func NewServer(store storage.Storage, keys *token.KeySet, authOpts ...middleware.Option) *Server {
 	return &Server{
-		store: store,
+		store:    store,
+		keys:     keys,
+		authOpts: authOpts,
 	}
 }
 
@@ -28,15 +38,31 @@ func (s *Server) Start(port string) error {
 		return err
 	}
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(s.ServerOptions()...)
 	pvz_v1.RegisterPVZServiceServer(grpcServer, s)
 
 	return grpcServer.Serve(lis)
 }
 
+// ServerOptions returns the interceptors that authenticate callers and
+// enforce RBAC permissions, in that order.
+func (s *Server) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.authInterceptor, permissionInterceptor),
+	}
+}
+
 func (s *Server) GetPVZList(ctx context.Context, req *pvz_v1.GetPVZListRequest) (*pvz_v1.GetPVZListResponse, error) {
 	// Get all PVZs from storage
-	pvzs, err := s.store.GetPVZsWithReceptions(ctx, time.Now().Add(-24*time.Hour), time.Now(), 1, 1000)
+	filter := storage.PVZFilter{
+		StartDate: time.Now().Add(-24 * time.Hour),
+		EndDate:   time.Now(),
+		Page:      1,
+		Limit:     1000,
+	}
+	filter.PVZIDs = scopedPVZs(ctx)
+
+	pvzs, err := s.store.GetPVZsWithReceptions(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -44,14 +70,83 @@ func (s *Server) GetPVZList(ctx context.Context, req *pvz_v1.GetPVZListRequest)
 	// Convert storage PVZs to gRPC PVZs
 	grpcPVZs := make([]*pvz_v1.PVZ, 0, len(pvzs))
 	for _, pvz := range pvzs {
-		grpcPVZs = append(grpcPVZs, &pvz_v1.PVZ{
-			Id:               pvz.PVZ.ID.String(),
-			RegistrationDate: timestamppb.New(pvz.PVZ.RegistrationDate),
-			City:             pvz.PVZ.City,
-		})
+		grpcPVZs = append(grpcPVZs, toProtoPVZ(pvz.PVZ))
 	}
 
 	return &pvz_v1.GetPVZListResponse{
 		Pvzs: grpcPVZs,
 	}, nil
 }
+
+func (s *Server) GetNearbyPVZs(ctx context.Context, req *pvz_v1.GetNearbyPVZsRequest) (*pvz_v1.GetNearbyPVZsResponse, error) {
+	filter := storage.NearbyFilter{
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+		Radius:    req.Radius,
+		Limit:     int(req.Limit),
+		City:      req.City,
+		Status:    req.Status,
+		PVZIDs:    scopedPVZs(ctx),
+	}
+	if filter.Radius == 0 {
+		filter.Radius = storage.DefaultNearbyRadius
+	}
+	if filter.Limit == 0 {
+		filter.Limit = storage.DefaultNearbyLimit
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	pvzs, err := s.store.FindNearbyPVZs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pvz_v1.GetNearbyPVZsResponse{Pvzs: make([]*pvz_v1.NearbyPVZ, 0, len(pvzs))}
+	for _, pvz := range pvzs {
+		resp.Pvzs = append(resp.Pvzs, &pvz_v1.NearbyPVZ{
+			Pvz:      toProtoPVZ(pvz.PVZ),
+			Distance: pvz.Distance,
+		})
+	}
+	return resp, nil
+}
+
+// scopedPVZs returns the PVZs the caller is limited to, nil if the caller
+// has pvz:access_all. Same scoping as GET /pvz.
+func scopedPVZs(ctx context.Context) []uuid.UUID {
+	p, _ := rbac.PrincipalFromContext(ctx)
+	if p.HasPermission(rbac.PVZAccessAll) {
+		return nil
+	}
+	if p.PVZIDs == nil {
+		return []uuid.UUID{}
+	}
+	return p.PVZIDs
+}
+
+func toProtoPVZ(pvz storage.PVZ) *pvz_v1.PVZ {
+	hours := make(map[string]*pvz_v1.DayHours, len(pvz.WorkingHours))
+	for day, h := range pvz.WorkingHours {
+		hours[day] = &pvz_v1.DayHours{Open: h.Open, Close: h.Close}
+	}
+
+	result := &pvz_v1.PVZ{
+		Id:               pvz.ID.String(),
+		RegistrationDate: timestamppb.New(pvz.RegistrationDate),
+		City:             pvz.City,
+		Address:          pvz.Address,
+		Latitude:         pvz.Latitude,
+		Longitude:        pvz.Longitude,
+		Timezone:         pvz.Timezone,
+		Phone:            pvz.Phone,
+		WorkingHours:     hours,
+		Status:           pvz.Status,
+		IsOpenNow:        pvz.IsOpenNow,
+	}
+	if pvz.Code != nil {
+		result.Code = *pvz.Code
+	}
+	return result
+}
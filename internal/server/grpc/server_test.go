@@ -8,12 +8,15 @@ import (
 
 	"github.com/google/uuid"
 	pvz_v1 "github.com/mi4r/avito-pvz/api/pvz/v1"
+	"github.com/mi4r/avito-pvz/internal/rbac"
 	"github.com/mi4r/avito-pvz/internal/storage"
 	"github.com/mi4r/avito-pvz/internal/storage/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -64,11 +67,11 @@ func TestServer_GetPVZList(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Setup mock expectations
 			mockStore.ExpectedCalls = nil
-			mockStore.On("GetPVZsWithReceptions", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			mockStore.On("GetPVZsWithReceptions", mock.Anything, mock.Anything).
 				Return(tt.mockPVZs, tt.mockError)
 
 			// Create server
-			server := NewServer(mockStore)
+			server := NewServer(mockStore, nil)
 
 			// Create a test context
 			ctx := context.Background()
@@ -94,16 +97,102 @@ func TestServer_GetPVZList(t *testing.T) {
 	}
 }
 
+func TestToProtoPVZ(t *testing.T) {
+	code := "MSK-1"
+	lat, lon := 55.757, 37.615
+
+	pvz := toProtoPVZ(storage.PVZ{
+		ID:           uuid.New(),
+		City:         "Москва",
+		Code:         &code,
+		Address:      "ул. Тверская, 1",
+		Latitude:     &lat,
+		Longitude:    &lon,
+		Timezone:     "Europe/Moscow",
+		Phone:        "+74951234567",
+		WorkingHours: storage.WorkingHours{"mon": {Open: "09:00", Close: "21:00"}},
+		Status:       storage.PVZTemporarilyClosed,
+	})
+
+	assert.Equal(t, "MSK-1", pvz.Code)
+	assert.Equal(t, lat, pvz.GetLatitude())
+	assert.Equal(t, "Europe/Moscow", pvz.Timezone)
+	assert.Equal(t, "21:00", pvz.WorkingHours["mon"].Close)
+	assert.Equal(t, storage.PVZTemporarilyClosed, pvz.Status)
+
+	assert.False(t, pvz.IsOpenNow)
+
+	empty := toProtoPVZ(storage.PVZ{ID: uuid.New(), City: "Казань", IsOpenNow: true})
+	assert.Nil(t, empty.Latitude)
+	assert.Empty(t, empty.Code)
+	assert.True(t, empty.IsOpenNow)
+}
+
+func TestServer_GetPVZListScope(t *testing.T) {
+	assigned := uuid.New()
+	mockStore := mocks.NewStorage(t)
+	mockStore.On("GetPVZsWithReceptions", mock.Anything, mock.MatchedBy(func(f storage.PVZFilter) bool {
+		return len(f.PVZIDs) == 1 && f.PVZIDs[0] == assigned
+	})).Return([]storage.PVZWithReceptions{}, nil)
+
+	ctx := rbac.WithPrincipal(context.Background(), rbac.Principal{
+		Role:        rbac.RoleEmployee,
+		Permissions: []rbac.Permission{rbac.PVZRead},
+		PVZIDs:      []uuid.UUID{assigned},
+	})
+	_, err := NewServer(mockStore, nil).GetPVZList(ctx, &pvz_v1.GetPVZListRequest{})
+
+	require.NoError(t, err)
+}
+
+func TestServer_GetNearbyPVZs(t *testing.T) {
+	assigned := uuid.New()
+	ctx := rbac.WithPrincipal(context.Background(), rbac.Principal{
+		Role:        rbac.RoleEmployee,
+		Permissions: []rbac.Permission{rbac.PVZRead},
+		PVZIDs:      []uuid.UUID{assigned},
+	})
+
+	t.Run("defaults and scope", func(t *testing.T) {
+		mockStore := mocks.NewStorage(t)
+		mockStore.On("FindNearbyPVZs", mock.Anything, storage.NearbyFilter{
+			Latitude:  59.93,
+			Longitude: 30.31,
+			Radius:    storage.DefaultNearbyRadius,
+			Limit:     storage.DefaultNearbyLimit,
+			Status:    storage.PVZActive,
+			PVZIDs:    []uuid.UUID{assigned},
+		}).Return([]storage.NearbyPVZ{{PVZ: storage.PVZ{ID: assigned, City: "Санкт-Петербург"}, Distance: 750}}, nil)
+
+		resp, err := NewServer(mockStore, nil).GetNearbyPVZs(ctx, &pvz_v1.GetNearbyPVZsRequest{
+			Latitude:  59.93,
+			Longitude: 30.31,
+			Status:    storage.PVZActive,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, resp.Pvzs, 1)
+		assert.Equal(t, assigned.String(), resp.Pvzs[0].Pvz.Id)
+		assert.Equal(t, float64(750), resp.Pvzs[0].Distance)
+	})
+
+	t.Run("invalid point", func(t *testing.T) {
+		_, err := NewServer(mocks.NewStorage(t), nil).GetNearbyPVZs(ctx, &pvz_v1.GetNearbyPVZsRequest{Latitude: 100})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
 func TestServer_Start(t *testing.T) {
 	// Create a mock storage
 	mockStore := &mocks.Storage{}
 
 	// Setup mock expectations
-	mockStore.On("GetPVZsWithReceptions", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+	mockStore.On("GetPVZsWithReceptions", mock.Anything, mock.Anything).
 		Return([]storage.PVZWithReceptions{}, nil)
 
 	// Create a test server
-	server := NewServer(mockStore)
+	server := NewServer(mockStore, nil)
 
 	// Create a listener
 	lis := bufconn.Listen(bufSize)
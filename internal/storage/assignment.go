@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func (s *PostgresStorage) AssignUserToPVZ(ctx context.Context, userID, pvzID uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO user_pvz (user_id, pvz_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, pvz_id) DO NOTHING`,
+		userID, pvzID,
+	)
+	if isForeignKeyViolation(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *PostgresStorage) UnassignUserFromPVZ(ctx context.Context, userID, pvzID uuid.UUID) error {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM user_pvz 
+		WHERE user_id = $1 AND pvz_id = $2`,
+		userID, pvzID,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStorage) GetUserPVZIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT pvz_id 
+		FROM user_pvz 
+		WHERE user_id = $1
+		ORDER BY assigned_at`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,71 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserPVZAssignments(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+
+	t.Run("assign", func(t *testing.T) {
+		userID, pvzID := uuid.New(), uuid.New()
+		mock.ExpectExec(`INSERT INTO user_pvz \(user_id, pvz_id\) VALUES \(\$1, \$2\) ON CONFLICT`).
+			WithArgs(userID, pvzID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.AssignUserToPVZ(context.Background(), userID, pvzID)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("assign unknown pvz", func(t *testing.T) {
+		userID, pvzID := uuid.New(), uuid.New()
+		mock.ExpectExec(`INSERT INTO user_pvz`).
+			WithArgs(userID, pvzID).
+			WillReturnError(&pq.Error{Code: "23503"})
+
+		err := store.AssignUserToPVZ(context.Background(), userID, pvzID)
+
+		assert.Equal(t, storage.ErrNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unassign missing", func(t *testing.T) {
+		userID, pvzID := uuid.New(), uuid.New()
+		mock.ExpectExec(`DELETE FROM user_pvz WHERE user_id = \$1 AND pvz_id = \$2`).
+			WithArgs(userID, pvzID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.UnassignUserFromPVZ(context.Background(), userID, pvzID)
+
+		assert.Equal(t, storage.ErrNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("list", func(t *testing.T) {
+		userID, pvzID := uuid.New(), uuid.New()
+		mock.ExpectQuery(`SELECT pvz_id FROM user_pvz WHERE user_id = \$1`).
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows([]string{"pvz_id"}).AddRow(pvzID))
+
+		ids, err := store.GetUserPVZIDs(context.Background(), userID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{pvzID}, ids)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
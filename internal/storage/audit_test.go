@@ -0,0 +1,188 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+	actorID := uuid.New()
+	columns := []string{"id", "occurred_at", "actor_id", "service_account_id", "actor_role", "action",
+		"entity_type", "entity_id", "before", "after", "request_id", "ip", "prev_hash", "hash"}
+
+	appendEntry := func(t *testing.T, id int64, lastHash *string, entry storage.AuditEntry) storage.AuditEntry {
+		mock.ExpectBegin()
+		mock.ExpectExec(`LOCK TABLE audit_log IN SHARE ROW EXCLUSIVE MODE`).WillReturnResult(sqlmock.NewResult(0, 0))
+		last := mock.ExpectQuery(`SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`)
+		if lastHash == nil {
+			last.WillReturnError(sql.ErrNoRows)
+		} else {
+			last.WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow(*lastHash))
+		}
+		mock.ExpectQuery(`INSERT INTO audit_log`).
+			WithArgs(sqlmock.AnyArg(), entry.ActorID, entry.ServiceAccountID, entry.ActorRole, entry.Action,
+				entry.EntityType, entry.EntityID, sqlmock.AnyArg(), sqlmock.AnyArg(),
+				entry.RequestID, entry.IP, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+		mock.ExpectCommit()
+
+		stored, err := store.AppendAudit(context.Background(), entry)
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+		return stored
+	}
+
+	row := func(rows *sqlmock.Rows, e storage.AuditEntry) *sqlmock.Rows {
+		var before, after []byte
+		if e.Before != nil {
+			before = e.Before
+		}
+		if e.After != nil {
+			after = e.After
+		}
+		return rows.AddRow(e.ID, e.OccurredAt, e.ActorID, e.ServiceAccountID, e.ActorRole, e.Action,
+			e.EntityType, e.EntityID, before, after, e.RequestID, e.IP, e.PrevHash, e.Hash)
+	}
+
+	var first, second storage.AuditEntry
+
+	t.Run("first entry starts the chain", func(t *testing.T) {
+		first = appendEntry(t, 1, nil, storage.AuditEntry{
+			ActorID:    &actorID,
+			ActorRole:  "employee",
+			Action:     "reception.create",
+			EntityType: "reception",
+			EntityID:   uuid.NewString(),
+			After:      json.RawMessage(`{"status":"in_progress"}`),
+			RequestID:  "req-1",
+			IP:         "192.0.2.1",
+		})
+
+		assert.Equal(t, int64(1), first.ID)
+		assert.Empty(t, first.PrevHash)
+		assert.Len(t, first.Hash, 64)
+		assert.Equal(t, first.OccurredAt, first.OccurredAt.Truncate(time.Microsecond))
+	})
+
+	t.Run("next entry links to the previous hash", func(t *testing.T) {
+		second = appendEntry(t, 2, &first.Hash, storage.AuditEntry{
+			ActorRole:  "moderator",
+			Action:     "pvz.create",
+			EntityType: "pvz",
+			EntityID:   uuid.NewString(),
+			After:      json.RawMessage(`{"city":"Москва"}`),
+		})
+
+		assert.Equal(t, first.Hash, second.PrevHash)
+		assert.NotEqual(t, first.Hash, second.Hash)
+	})
+
+	t.Run("batch chains entries in one transaction", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`LOCK TABLE audit_log`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT hash FROM audit_log`).WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow(second.Hash))
+		mock.ExpectQuery(`INSERT INTO audit_log`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectQuery(`INSERT INTO audit_log`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+		mock.ExpectCommit()
+
+		entries, err := store.AppendAuditBatch(context.Background(), []storage.AuditEntry{
+			{ActorRole: "cli", Action: "pvz.import", EntityType: "pvz", EntityID: uuid.NewString()},
+			{ActorRole: "cli", Action: "pvz.import", EntityType: "pvz", EntityID: uuid.NewString()},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, second.Hash, entries[0].PrevHash)
+		assert.Equal(t, entries[0].Hash, entries[1].PrevHash)
+		assert.Equal(t, int64(4), entries[1].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("append rolls back on insert error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`LOCK TABLE audit_log`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT hash FROM audit_log`).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(`INSERT INTO audit_log`).WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+
+		_, err := store.AppendAudit(context.Background(), storage.AuditEntry{Action: "pvz.create", EntityType: "pvz"})
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("verify intact chain", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, occurred_at, .* FROM audit_log ORDER BY id`).
+			WillReturnRows(row(row(sqlmock.NewRows(columns), first), second))
+
+		result, err := store.VerifyAuditLog(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, storage.AuditVerification{Valid: true, Checked: 2}, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("verify detects edited entry", func(t *testing.T) {
+		tampered := first
+		tampered.After = json.RawMessage(`{"status":"closed"}`)
+		mock.ExpectQuery(`SELECT id, occurred_at, .* FROM audit_log ORDER BY id`).
+			WillReturnRows(row(row(sqlmock.NewRows(columns), tampered), second))
+
+		result, err := store.VerifyAuditLog(context.Background())
+
+		assert.NoError(t, err)
+		assert.False(t, result.Valid)
+		assert.Equal(t, int64(1), *result.BrokenAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("verify detects removed entry", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, occurred_at, .* FROM audit_log ORDER BY id`).
+			WillReturnRows(row(sqlmock.NewRows(columns), second))
+
+		result, err := store.VerifyAuditLog(context.Background())
+
+		assert.NoError(t, err)
+		assert.False(t, result.Valid)
+		assert.Equal(t, int64(2), *result.BrokenAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("list with filters", func(t *testing.T) {
+		from := time.Now().Add(-time.Hour)
+		mock.ExpectQuery(`WHERE \(\$1::uuid IS NULL OR actor_id = \$1 OR service_account_id = \$1\) .* AND \(\$7 = 0 OR id < \$7\) ORDER BY id DESC LIMIT \$8`).
+			WithArgs(&actorID, "reception.create", "", "", &from, nil, int64(10), 20).
+			WillReturnRows(row(sqlmock.NewRows(columns), first))
+
+		entries, err := store.ListAuditEntries(context.Background(), storage.AuditFilter{
+			ActorID: &actorID,
+			Action:  "reception.create",
+			From:    &from,
+			Cursor:  10,
+			Limit:   20,
+		})
+
+		assert.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, first.Hash, entries[0].Hash)
+		assert.JSONEq(t, `{"status":"in_progress"}`, string(entries[0].After))
+		assert.Nil(t, entries[0].Before)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrCityExists = errors.New("city already exists")
+	ErrCityInUse  = errors.New("city has PVZs")
+)
+
+// City is an entry of the city directory. PVZs can only be created in
+// active cities; deactivating a city keeps its existing PVZs.
+type City struct {
+	Name      string    `json:"name"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func scanCity(row rowScanner) (City, error) {
+	var city City
+	err := row.Scan(&city.Name, &city.Active, &city.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return City{}, ErrNotFound
+	}
+	return city, err
+}
+
+// ListCities returns active cities, or all of them with includeInactive.
+func (s *PostgresStorage) ListCities(ctx context.Context, includeInactive bool) ([]City, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT name, active, created_at
+		FROM cities
+		WHERE $1 OR active
+		ORDER BY name`,
+		includeInactive,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cities := []City{}
+	for rows.Next() {
+		city, err := scanCity(rows)
+		if err != nil {
+			return nil, err
+		}
+		cities = append(cities, city)
+	}
+	return cities, rows.Err()
+}
+
+func (s *PostgresStorage) GetCity(ctx context.Context, name string) (City, error) {
+	return scanCity(s.db.QueryRowContext(ctx,
+		`SELECT name, active, created_at FROM cities WHERE name = $1`,
+		name,
+	))
+}
+
+func (s *PostgresStorage) CreateCity(ctx context.Context, name string) (City, error) {
+	city, err := scanCity(s.db.QueryRowContext(ctx,
+		`INSERT INTO cities (name) VALUES ($1)
+		RETURNING name, active, created_at`,
+		name,
+	))
+	if isUniqueViolation(err) {
+		return City{}, ErrCityExists
+	}
+	return city, err
+}
+
+func (s *PostgresStorage) SetCityActive(ctx context.Context, name string, active bool) (City, error) {
+	return scanCity(s.db.QueryRowContext(ctx,
+		`UPDATE cities SET active = $2 WHERE name = $1
+		RETURNING name, active, created_at`,
+		name, active,
+	))
+}
+
+// DeleteCity removes a city without PVZs. Cities in use can only be
+// deactivated.
+func (s *PostgresStorage) DeleteCity(ctx context.Context, name string) error {
+	err := s.execOne(ctx,
+		`DELETE FROM cities WHERE name = $1`,
+		name,
+	)
+	if isForeignKeyViolation(err) {
+		return ErrCityInUse
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCities(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+	columns := []string{"name", "active", "created_at"}
+	now := time.Now()
+
+	t.Run("list active cities", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT name, active, created_at FROM cities WHERE \$1 OR active ORDER BY name`).
+			WithArgs(false).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("Казань", true, now).AddRow("Москва", true, now))
+
+		cities, err := store.ListCities(context.Background(), false)
+
+		assert.NoError(t, err)
+		assert.Len(t, cities, 2)
+		assert.Equal(t, "Казань", cities[0].Name)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("create city", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO cities \(name\) VALUES \(\$1\) RETURNING name, active, created_at`).
+			WithArgs("Новосибирск").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("Новосибирск", true, now))
+
+		city, err := store.CreateCity(context.Background(), "Новосибирск")
+
+		assert.NoError(t, err)
+		assert.True(t, city.Active)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("create existing city", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO cities`).
+			WithArgs("Москва").
+			WillReturnError(&pq.Error{Code: "23505"})
+
+		_, err := store.CreateCity(context.Background(), "Москва")
+
+		assert.ErrorIs(t, err, storage.ErrCityExists)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("deactivate city", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE cities SET active = \$2 WHERE name = \$1 RETURNING name, active, created_at`).
+			WithArgs("Казань", false).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("Казань", false, now))
+
+		city, err := store.SetCityActive(context.Background(), "Казань", false)
+
+		assert.NoError(t, err)
+		assert.False(t, city.Active)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("update unknown city", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE cities`).
+			WithArgs("Атлантида", true).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := store.SetCityActive(context.Background(), "Атлантида", true)
+
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("delete city in use", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM cities WHERE name = \$1`).
+			WithArgs("Москва").
+			WillReturnError(&pq.Error{Code: "23503"})
+
+		err := store.DeleteCity(context.Background(), "Москва")
+
+		assert.ErrorIs(t, err, storage.ErrCityInUse)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("delete unused city", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM cities WHERE name = \$1`).
+			WithArgs("Новосибирск").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.DeleteCity(context.Background(), "Новосибирск")
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidInvite = errors.New("invalid invite")
+
+type Invite struct {
+	ID        uuid.UUID  `json:"id"`
+	Role      string     `json:"role"`
+	CreatedBy *uuid.UUID `json:"createdBy,omitempty"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// CreateInvite stores a registration invite. createdBy is nil for callers
+// without an account, e.g. dummy tokens.
+func (s *PostgresStorage) CreateInvite(ctx context.Context, tokenHash, role string, createdBy *uuid.UUID, expiresAt time.Time) (Invite, error) {
+	var invite Invite
+	err := s.db.QueryRowContext(ctx,
+		`INSERT INTO invites (token_hash, role, created_by, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, role, created_by, expires_at, created_at`,
+		tokenHash, role, createdBy, expiresAt,
+	).Scan(&invite.ID, &invite.Role, &invite.CreatedBy, &invite.ExpiresAt, &invite.CreatedAt)
+	if isForeignKeyViolation(err) {
+		return Invite{}, ErrInvalidRole
+	}
+	return invite, err
+}
+
+// CreateUserWithInvite consumes the invite and creates a user with its role
+// in one statement, so a failed insert leaves the invite unused.
+func (s *PostgresStorage) CreateUserWithInvite(ctx context.Context, email, passwordHash, inviteHash string) (User, error) {
+	user := User{ID: uuid.New()}
+	err := s.db.QueryRowContext(ctx,
+		`WITH invite AS (
+			UPDATE invites
+			SET used_at = NOW(), used_by = $4
+			WHERE token_hash = $3 AND used_at IS NULL AND expires_at > NOW()
+			RETURNING role
+		)
+		INSERT INTO users (id, email, password_hash, role)
+		SELECT $4, $1, $2, role FROM invite
+		RETURNING id, email, role, created_at`,
+		email, passwordHash, inviteHash, user.ID,
+	).Scan(&user.ID, &user.Email, &user.Role, &user.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrInvalidInvite
+	}
+	if err != nil {
+		return User{}, classifyUserError(err)
+	}
+	return user, nil
+}
@@ -0,0 +1,83 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvites(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+
+	t.Run("create invite", func(t *testing.T) {
+		createdBy := uuid.New()
+		expiresAt := time.Now().Add(time.Hour)
+		mock.ExpectQuery(`INSERT INTO invites \(token_hash, role, created_by, expires_at\)`).
+			WithArgs("hash", "employee", createdBy, expiresAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "role", "created_by", "expires_at", "created_at"}).
+				AddRow(uuid.New(), "employee", createdBy, expiresAt, time.Now()))
+
+		invite, err := store.CreateInvite(context.Background(), "hash", "employee", &createdBy, expiresAt)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "employee", invite.Role)
+		assert.Equal(t, &createdBy, invite.CreatedBy)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("create invite for unknown role", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO invites`).
+			WillReturnError(&pq.Error{Code: "23503"})
+
+		_, err := store.CreateInvite(context.Background(), "hash", "root", nil, time.Now())
+
+		assert.Equal(t, storage.ErrInvalidRole, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("register with invite", func(t *testing.T) {
+		mock.ExpectQuery(`WITH invite AS \( UPDATE invites SET used_at = NOW\(\), used_by = \$4 WHERE token_hash = \$3 AND used_at IS NULL AND expires_at > NOW\(\) RETURNING role \) INSERT INTO users`).
+			WithArgs("mod@example.com", "pwhash", "invitehash", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role", "created_at"}).
+				AddRow(uuid.New(), "mod@example.com", "moderator", time.Now()))
+
+		user, err := store.CreateUserWithInvite(context.Background(), "mod@example.com", "pwhash", "invitehash")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "moderator", user.Role)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("used or expired invite", func(t *testing.T) {
+		mock.ExpectQuery(`WITH invite AS`).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := store.CreateUserWithInvite(context.Background(), "mod@example.com", "pwhash", "invitehash")
+
+		assert.Equal(t, storage.ErrInvalidInvite, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("register with invite for existing email", func(t *testing.T) {
+		mock.ExpectQuery(`WITH invite AS`).
+			WillReturnError(&pq.Error{Code: "23505"})
+
+		_, err := store.CreateUserWithInvite(context.Background(), "mod@example.com", "pwhash", "invitehash")
+
+		assert.Equal(t, storage.ErrUserExists, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// RecordLoginFailure counts a failed login for the key and returns the
+// number of failures in a row. Failures older than window are forgotten.
+func (s *PostgresStorage) RecordLoginFailure(ctx context.Context, scope, key string, window time.Duration) (int, error) {
+	var failures int
+	err := s.db.QueryRowContext(ctx,
+		`INSERT INTO login_failures (scope, key, failures, last_failure_at)
+		VALUES ($1, $2, 1, NOW())
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failures = CASE
+				WHEN login_failures.last_failure_at < NOW() - make_interval(secs => $3) THEN 1
+				ELSE login_failures.failures + 1
+			END,
+			last_failure_at = NOW()
+		RETURNING failures`,
+		scope, key, window.Seconds(),
+	).Scan(&failures)
+	return failures, err
+}
+
+func (s *PostgresStorage) LockLogin(ctx context.Context, scope, key string, duration time.Duration) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE login_failures
+		SET locked_until = NOW() + make_interval(secs => $3)
+		WHERE scope = $1 AND key = $2`,
+		scope, key, duration.Seconds(),
+	)
+	return err
+}
+
+// GetLoginLock returns how long the key stays locked, or zero if it isn't.
+func (s *PostgresStorage) GetLoginLock(ctx context.Context, scope, key string) (time.Duration, error) {
+	var seconds float64
+	err := s.db.QueryRowContext(ctx,
+		`SELECT EXTRACT(EPOCH FROM locked_until - NOW())
+		FROM login_failures
+		WHERE scope = $1 AND key = $2 AND locked_until > NOW()`,
+		scope, key,
+	).Scan(&seconds)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func (s *PostgresStorage) ClearLoginFailures(ctx context.Context, scope, key string) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM login_failures WHERE scope = $1 AND key = $2`,
+		scope, key,
+	)
+	return err
+}
+
+// PurgeLoginFailures drops counters that are neither recent nor locked.
+func (s *PostgresStorage) PurgeLoginFailures(ctx context.Context, olderThan time.Duration) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM login_failures
+		WHERE last_failure_at < NOW() - make_interval(secs => $1)
+		AND (locked_until IS NULL OR locked_until < NOW())`,
+		olderThan.Seconds(),
+	)
+	return err
+}
@@ -0,0 +1,80 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginFailures(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+
+	t.Run("record failure", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO login_failures .* ON CONFLICT \(scope, key\) DO UPDATE`).
+			WithArgs("email", "user@example.com", float64(900)).
+			WillReturnRows(sqlmock.NewRows([]string{"failures"}).AddRow(3))
+
+		failures, err := store.RecordLoginFailure(context.Background(), "email", "user@example.com", 15*time.Minute)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, failures)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("lock", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE login_failures SET locked_until = NOW\(\) \+ make_interval\(secs => \$3\) WHERE scope = \$1 AND key = \$2`).
+			WithArgs("ip", "192.0.2.1", float64(60)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.LockLogin(context.Background(), "ip", "192.0.2.1", time.Minute)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("locked key", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT EXTRACT\(EPOCH FROM locked_until - NOW\(\)\) FROM login_failures`).
+			WithArgs("email", "user@example.com").
+			WillReturnRows(sqlmock.NewRows([]string{"extract"}).AddRow(1.5))
+
+		wait, err := store.GetLoginLock(context.Background(), "email", "user@example.com")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1500*time.Millisecond, wait)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unlocked key", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT EXTRACT`).
+			WithArgs("email", "other@example.com").
+			WillReturnError(sql.ErrNoRows)
+
+		wait, err := store.GetLoginLock(context.Background(), "email", "other@example.com")
+
+		assert.NoError(t, err)
+		assert.Zero(t, wait)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM login_failures WHERE scope = \$1 AND key = \$2`).
+			WithArgs("email", "user@example.com").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.ClearLoginFailures(context.Background(), "email", "user@example.com")
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
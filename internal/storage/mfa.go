@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var ErrMFAEnabled = errors.New("two-factor authentication is already enabled")
+
+// MFA is the TOTP enrollment of a user. It only protects logins once
+// ConfirmedAt is set.
+type MFA struct {
+	UserID       uuid.UUID
+	Secret       string
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+}
+
+func (m MFA) Enabled() bool {
+	return m.ConfirmedAt != nil
+}
+
+func (s *PostgresStorage) GetMFA(ctx context.Context, userID uuid.UUID) (MFA, error) {
+	var mfa MFA
+	err := s.db.QueryRowContext(ctx,
+		`SELECT user_id, secret, confirmed_at, last_used_step
+		FROM user_mfa WHERE user_id = $1`,
+		userID,
+	).Scan(&mfa.UserID, &mfa.Secret, &mfa.ConfirmedAt, &mfa.LastUsedStep)
+	if errors.Is(err, sql.ErrNoRows) {
+		return MFA{}, ErrNotFound
+	}
+	return mfa, err
+}
+
+// StartMFAEnrollment stores a new unconfirmed secret, replacing an earlier
+// unfinished enrollment. A confirmed secret is never replaced.
+func (s *PostgresStorage) StartMFAEnrollment(ctx context.Context, userID uuid.UUID, secret string) error {
+	err := s.execOne(ctx,
+		`INSERT INTO user_mfa (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = 0, created_at = NOW()
+		WHERE user_mfa.confirmed_at IS NULL`,
+		userID, secret,
+	)
+	if errors.Is(err, ErrNotFound) {
+		return ErrMFAEnabled
+	}
+	return err
+}
+
+// ConfirmMFA enables the pending enrollment and replaces the recovery
+// codes in one statement. step is the TOTP step of the confirming code.
+func (s *PostgresStorage) ConfirmMFA(ctx context.Context, userID uuid.UUID, step int64, codeHashes []string) error {
+	return s.execOne(ctx,
+		`WITH confirmed AS (
+			UPDATE user_mfa
+			SET confirmed_at = NOW(), last_used_step = $2
+			WHERE user_id = $1 AND confirmed_at IS NULL AND last_used_step < $2
+			RETURNING user_id
+		), cleared AS (
+			DELETE FROM mfa_recovery_codes
+			WHERE user_id IN (SELECT user_id FROM confirmed)
+		)
+		INSERT INTO mfa_recovery_codes (user_id, code_hash)
+		SELECT user_id, unnest($3::text[]) FROM confirmed`,
+		userID, step, pq.StringArray(codeHashes),
+	)
+}
+
+// UseMFAStep records a successfully checked TOTP step. It returns false
+// if that step or a later one was already used.
+func (s *PostgresStorage) UseMFAStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	err := s.execOne(ctx,
+		`UPDATE user_mfa
+		SET last_used_step = $2
+		WHERE user_id = $1 AND last_used_step < $2`,
+		userID, step,
+	)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// UseRecoveryCode marks an unused recovery code as used. It returns false
+// if the code doesn't exist or was used before.
+func (s *PostgresStorage) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	err := s.execOne(ctx,
+		`UPDATE mfa_recovery_codes
+		SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`,
+		userID, codeHash,
+	)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *PostgresStorage) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	_, err := s.db.ExecContext(ctx,
+		`WITH cleared AS (
+			DELETE FROM mfa_recovery_codes WHERE user_id = $1
+		)
+		INSERT INTO mfa_recovery_codes (user_id, code_hash)
+		SELECT $1, unnest($2::text[])`,
+		userID, pq.StringArray(codeHashes),
+	)
+	return err
+}
+
+// DeleteMFA turns two-factor authentication off and drops the recovery codes.
+func (s *PostgresStorage) DeleteMFA(ctx context.Context, userID uuid.UUID) error {
+	return s.execOne(ctx,
+		`WITH codes AS (
+			DELETE FROM mfa_recovery_codes WHERE user_id = $1
+		)
+		DELETE FROM user_mfa WHERE user_id = $1`,
+		userID,
+	)
+}
+
+func (s *PostgresStorage) IsMFARequired(ctx context.Context, role string) (bool, error) {
+	var required bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT mfa_required FROM roles WHERE name = $1`,
+		role,
+	).Scan(&required)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return required, err
+}
+
+func (s *PostgresStorage) SetRoleMFARequired(ctx context.Context, role string, required bool) error {
+	return s.execOne(ctx,
+		`UPDATE roles SET mfa_required = $2 WHERE name = $1`,
+		role, required,
+	)
+}
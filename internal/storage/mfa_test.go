@@ -0,0 +1,165 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMFA(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+	userID := uuid.New()
+
+	t.Run("get mfa", func(t *testing.T) {
+		confirmedAt := time.Now()
+		mock.ExpectQuery(`SELECT user_id, secret, confirmed_at, last_used_step FROM user_mfa WHERE user_id = \$1`).
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "secret", "confirmed_at", "last_used_step"}).
+				AddRow(userID, "SECRET", confirmedAt, 42))
+
+		mfa, err := store.GetMFA(context.Background(), userID)
+
+		assert.NoError(t, err)
+		assert.True(t, mfa.Enabled())
+		assert.Equal(t, int64(42), mfa.LastUsedStep)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get missing mfa", func(t *testing.T) {
+		mock.ExpectQuery(`FROM user_mfa`).
+			WithArgs(userID).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := store.GetMFA(context.Background(), userID)
+
+		assert.Equal(t, storage.ErrNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("start enrollment", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO user_mfa \(user_id, secret\) VALUES \(\$1, \$2\) ON CONFLICT \(user_id\) DO UPDATE .+ WHERE user_mfa.confirmed_at IS NULL`).
+			WithArgs(userID, "SECRET").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, store.StartMFAEnrollment(context.Background(), userID, "SECRET"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("start enrollment when enabled", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO user_mfa`).
+			WithArgs(userID, "SECRET").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Equal(t, storage.ErrMFAEnabled, store.StartMFAEnrollment(context.Background(), userID, "SECRET"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("confirm enrollment", func(t *testing.T) {
+		hashes := []string{"h1", "h2"}
+		mock.ExpectExec(`WITH confirmed AS \( UPDATE user_mfa SET confirmed_at = NOW\(\), last_used_step = \$2 WHERE user_id = \$1 AND confirmed_at IS NULL AND last_used_step < \$2`).
+			WithArgs(userID, int64(100), pq.StringArray(hashes)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		assert.NoError(t, store.ConfirmMFA(context.Background(), userID, 100, hashes))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("confirm without pending enrollment", func(t *testing.T) {
+		mock.ExpectExec(`WITH confirmed AS`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Equal(t, storage.ErrNotFound, store.ConfirmMFA(context.Background(), userID, 100, []string{"h1"}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("use step", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE user_mfa SET last_used_step = \$2 WHERE user_id = \$1 AND last_used_step < \$2`).
+			WithArgs(userID, int64(101)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		ok, err := store.UseMFAStep(context.Background(), userID, 101)
+
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("replayed step", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE user_mfa SET last_used_step`).
+			WithArgs(userID, int64(101)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		ok, err := store.UseMFAStep(context.Background(), userID, 101)
+
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("use recovery code", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE mfa_recovery_codes SET used_at = NOW\(\) WHERE user_id = \$1 AND code_hash = \$2 AND used_at IS NULL`).
+			WithArgs(userID, "h1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		ok, err := store.UseRecoveryCode(context.Background(), userID, "h1")
+
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("used recovery code", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE mfa_recovery_codes`).
+			WithArgs(userID, "h1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		ok, err := store.UseRecoveryCode(context.Background(), userID, "h1")
+
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("delete mfa", func(t *testing.T) {
+		mock.ExpectExec(`WITH codes AS \( DELETE FROM mfa_recovery_codes WHERE user_id = \$1 \) DELETE FROM user_mfa WHERE user_id = \$1`).
+			WithArgs(userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, store.DeleteMFA(context.Background(), userID))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("role requirement", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT mfa_required FROM roles WHERE name = \$1`).
+			WithArgs("moderator").
+			WillReturnRows(sqlmock.NewRows([]string{"mfa_required"}).AddRow(true))
+
+		required, err := store.IsMFARequired(context.Background(), "moderator")
+
+		assert.NoError(t, err)
+		assert.True(t, required)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("require mfa for unknown role", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE roles SET mfa_required = \$2 WHERE name = \$1`).
+			WithArgs("root", true).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Equal(t, storage.ErrNotFound, store.SetRoleMFARequired(context.Background(), "root", true))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
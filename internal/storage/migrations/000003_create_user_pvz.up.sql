@@ -0,0 +1,9 @@
+-- Закрепление сотрудников за ПВЗ
+CREATE TABLE user_pvz (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    pvz_id UUID NOT NULL REFERENCES pvz(id) ON DELETE CASCADE,
+    assigned_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, pvz_id)
+);
+
+CREATE INDEX idx_user_pvz_pvz ON user_pvz(pvz_id);
@@ -0,0 +1,55 @@
+-- Роли пользователей
+CREATE TABLE roles (
+    name VARCHAR(20) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+-- Права доступа
+CREATE TABLE permissions (
+    name VARCHAR(50) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+-- Права, выданные ролям
+CREATE TABLE role_permissions (
+    role VARCHAR(20) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission VARCHAR(50) NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Администратор'),
+    ('moderator', 'Модератор'),
+    ('employee', 'Сотрудник ПВЗ'),
+    ('auditor', 'Аудитор (только чтение)'),
+    ('franchise_owner', 'Владелец франшизы');
+
+INSERT INTO permissions (name, description) VALUES
+    ('pvz:create', 'Заведение ПВЗ'),
+    ('pvz:read', 'Просмотр ПВЗ'),
+    ('pvz:access_all', 'Доступ ко всем ПВЗ, а не только к закреплённым'),
+    ('reception:create', 'Создание приёмки'),
+    ('reception:close', 'Закрытие приёмки'),
+    ('product:create', 'Добавление товара'),
+    ('product:delete', 'Удаление товара'),
+    ('assignment:manage', 'Закрепление пользователей за ПВЗ');
+
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', name FROM permissions;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('moderator', 'pvz:create'),
+    ('moderator', 'pvz:read'),
+    ('moderator', 'pvz:access_all'),
+    ('moderator', 'assignment:manage'),
+    ('employee', 'pvz:read'),
+    ('employee', 'reception:create'),
+    ('employee', 'reception:close'),
+    ('employee', 'product:create'),
+    ('employee', 'product:delete'),
+    ('auditor', 'pvz:read'),
+    ('auditor', 'pvz:access_all'),
+    ('franchise_owner', 'pvz:read');
+
+ALTER TABLE users DROP CONSTRAINT users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_fkey FOREIGN KEY (role) REFERENCES roles(name);
@@ -0,0 +1,6 @@
+DELETE FROM permissions WHERE name = 'user:manage';
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS password_reset_required,
+    DROP COLUMN IF EXISTS last_login_at,
+    DROP COLUMN IF EXISTS disabled_at;
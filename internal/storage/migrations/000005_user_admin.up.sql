@@ -0,0 +1,10 @@
+ALTER TABLE users
+    ADD COLUMN disabled_at TIMESTAMP,
+    ADD COLUMN last_login_at TIMESTAMP,
+    ADD COLUMN password_reset_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+INSERT INTO permissions (name, description) VALUES
+    ('user:manage', 'Администрирование пользователей');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'user:manage');
@@ -0,0 +1,11 @@
+-- Неудачные попытки входа по email и по IP, общие для всех реплик
+CREATE TABLE login_failures (
+    scope VARCHAR(10) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX idx_login_failures_last_failure ON login_failures(last_failure_at);
@@ -0,0 +1,4 @@
+DELETE FROM permissions WHERE name = 'invite:create';
+
+DROP INDEX IF EXISTS idx_users_email_lower;
+DROP TABLE IF EXISTS invites;
@@ -0,0 +1,21 @@
+-- Одноразовые приглашения на регистрацию с заданной ролью (хранится только хеш токена)
+CREATE TABLE invites (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    role VARCHAR(20) NOT NULL REFERENCES roles(name),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    used_at TIMESTAMP,
+    used_by UUID
+);
+
+-- Email сравнивается без учёта регистра
+CREATE INDEX idx_users_email_lower ON users(lower(email));
+
+INSERT INTO permissions (name, description) VALUES
+    ('invite:create', 'Выдача приглашений на регистрацию');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'invite:create'),
+    ('moderator', 'invite:create');
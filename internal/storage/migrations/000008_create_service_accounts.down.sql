@@ -0,0 +1,5 @@
+DELETE FROM permissions WHERE name = 'service_account:manage';
+
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS service_account_permissions;
+DROP TABLE IF EXISTS service_accounts;
@@ -0,0 +1,35 @@
+-- Сервисные учётные записи для интеграций
+CREATE TABLE service_accounts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Права сервисной учётной записи задаются явно, без ролей
+CREATE TABLE service_account_permissions (
+    service_account_id UUID NOT NULL REFERENCES service_accounts(id) ON DELETE CASCADE,
+    permission VARCHAR(50) NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (service_account_id, permission)
+);
+
+-- API-ключи (хранится только хеш, key_id — публичная часть ключа для поиска)
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    service_account_id UUID NOT NULL REFERENCES service_accounts(id) ON DELETE CASCADE,
+    key_id VARCHAR(16) UNIQUE NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_api_keys_service_account ON api_keys(service_account_id);
+
+INSERT INTO permissions (name, description) VALUES
+    ('service_account:manage', 'Управление сервисными учётными записями и API-ключами');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'service_account:manage');
@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE name = 'session:view';
+
+DROP TABLE IF EXISTS sessions;
@@ -0,0 +1,20 @@
+-- Сессии: одна на вход, id совпадает с family_id refresh-токенов
+CREATE TABLE sessions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    device VARCHAR(100) NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_sessions_user ON sessions(user_id);
+
+INSERT INTO permissions (name, description) VALUES
+    ('session:view', 'Просмотр сессий сотрудников ПВЗ');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'session:view'),
+    ('moderator', 'session:view');
@@ -0,0 +1,10 @@
+DELETE FROM products WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE products
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS deleted_by,
+    DROP COLUMN IF EXISTS created_by;
+
+ALTER TABLE receptions
+    DROP COLUMN IF EXISTS closed_by,
+    DROP COLUMN IF EXISTS created_by;
@@ -0,0 +1,14 @@
+-- Кто открыл и закрыл приёмку, добавил и удалил товар.
+-- NULL — действие выполнено до появления колонок, dummy-токеном или API-ключом
+ALTER TABLE receptions
+    ADD COLUMN created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN closed_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- Товары удаляются мягко, чтобы было видно, кто удалил
+ALTER TABLE products
+    ADD COLUMN created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN deleted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_receptions_created_by ON receptions(created_by);
+CREATE INDEX idx_products_created_by ON products(created_by);
@@ -0,0 +1,46 @@
+-- Журнал аудита: только добавление, каждая запись хранит хеш предыдущей.
+-- before/after хранятся как JSON, а не JSONB, чтобы текст совпадал с тем,
+-- из которого посчитан хеш. actor_id без внешнего ключа: удаление
+-- пользователя не должно менять записи журнала.
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMP NOT NULL,
+    actor_id UUID,
+    service_account_id UUID,
+    actor_role VARCHAR(20) NOT NULL DEFAULT '',
+    action VARCHAR(50) NOT NULL,
+    entity_type VARCHAR(30) NOT NULL,
+    entity_id VARCHAR(100) NOT NULL DEFAULT '',
+    before JSON,
+    after JSON,
+    request_id VARCHAR(100) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    prev_hash VARCHAR(64) NOT NULL DEFAULT '',
+    hash VARCHAR(64) NOT NULL
+);
+
+CREATE INDEX idx_audit_log_actor ON audit_log(actor_id);
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id);
+CREATE INDEX idx_audit_log_occurred_at ON audit_log(occurred_at);
+
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+INSERT INTO permissions (name, description) VALUES
+    ('audit:view', 'Просмотр журнала аудита');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'audit:view'),
+    ('moderator', 'audit:view'),
+    ('auditor', 'audit:view');
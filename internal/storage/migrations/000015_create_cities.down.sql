@@ -0,0 +1,7 @@
+DELETE FROM permissions WHERE name = 'city:manage';
+
+-- Откат не пройдёт, пока есть ПВЗ в городах не из исходного списка
+ALTER TABLE pvz DROP CONSTRAINT pvz_city_fkey;
+ALTER TABLE pvz ADD CONSTRAINT pvz_city_check CHECK (city IN ('Москва', 'Санкт-Петербург', 'Казань'));
+
+DROP TABLE IF EXISTS cities;
@@ -0,0 +1,21 @@
+-- Справочник городов вместо CHECK в pvz. Отключённый город остаётся
+-- у существующих ПВЗ, но новые ПВЗ в нём не заводятся.
+CREATE TABLE cities (
+    name VARCHAR(50) PRIMARY KEY,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO cities (name) VALUES
+    ('Москва'),
+    ('Санкт-Петербург'),
+    ('Казань');
+
+ALTER TABLE pvz DROP CONSTRAINT pvz_city_check;
+ALTER TABLE pvz ADD CONSTRAINT pvz_city_fkey FOREIGN KEY (city) REFERENCES cities(name);
+
+INSERT INTO permissions (name, description) VALUES
+    ('city:manage', 'Управление справочником городов');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'city:manage');
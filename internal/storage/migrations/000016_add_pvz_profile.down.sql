@@ -0,0 +1,10 @@
+DELETE FROM permissions WHERE name = 'pvz:update';
+
+ALTER TABLE pvz
+    DROP COLUMN code,
+    DROP COLUMN address,
+    DROP COLUMN latitude,
+    DROP COLUMN longitude,
+    DROP COLUMN timezone,
+    DROP COLUMN phone,
+    DROP COLUMN working_hours;
@@ -0,0 +1,19 @@
+-- Профиль ПВЗ. code уникален, но у ПВЗ, заведённых раньше, его нет.
+-- working_hours — часы работы по дням недели: {"mon": {"open": "09:00", "close": "21:00"}, ...},
+-- дня нет в объекте — ПВЗ в этот день закрыт
+ALTER TABLE pvz
+    ADD COLUMN code VARCHAR(20) UNIQUE,
+    ADD COLUMN address VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN latitude DOUBLE PRECISION CHECK (latitude BETWEEN -90 AND 90),
+    ADD COLUMN longitude DOUBLE PRECISION CHECK (longitude BETWEEN -180 AND 180),
+    ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'Europe/Moscow',
+    ADD COLUMN phone VARCHAR(16) NOT NULL DEFAULT '',
+    ADD COLUMN working_hours JSONB NOT NULL DEFAULT '{}',
+    ADD CONSTRAINT pvz_coordinates_check CHECK ((latitude IS NULL) = (longitude IS NULL));
+
+INSERT INTO permissions (name, description) VALUES
+    ('pvz:update', 'Изменение профиля ПВЗ');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'pvz:update'),
+    ('moderator', 'pvz:update');
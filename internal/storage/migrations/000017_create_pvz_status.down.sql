@@ -0,0 +1,4 @@
+DELETE FROM permissions WHERE name = 'pvz:status';
+
+DROP VIEW IF EXISTS pvz_current_status;
+DROP TABLE IF EXISTS pvz_status_changes;
@@ -0,0 +1,27 @@
+-- Жизненный цикл ПВЗ: active -> temporarily_closed -> active, любой -> decommissioned.
+-- Смена статуса вступает в силу с effective_from и может быть запланирована заранее.
+-- Текущий статус — последняя вступившая в силу смена, ПВЗ без смен работает
+CREATE TABLE pvz_status_changes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    pvz_id UUID NOT NULL REFERENCES pvz(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('active', 'temporarily_closed', 'decommissioned')),
+    reason VARCHAR(500) NOT NULL DEFAULT '',
+    effective_from TIMESTAMP NOT NULL,
+    changed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_pvz_status_changes_pvz_id ON pvz_status_changes(pvz_id, effective_from);
+
+CREATE VIEW pvz_current_status AS
+SELECT DISTINCT ON (pvz_id) pvz_id, status, reason, effective_from
+FROM pvz_status_changes
+WHERE effective_from <= NOW()
+ORDER BY pvz_id, effective_from DESC, created_at DESC;
+
+INSERT INTO permissions (name, description) VALUES
+    ('pvz:status', 'Смена статуса ПВЗ');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'pvz:status'),
+    ('moderator', 'pvz:status');
@@ -0,0 +1,2 @@
+-- Поиск ближайших ПВЗ сначала отсекает ПВЗ по широте
+CREATE INDEX idx_pvz_latitude ON pvz(latitude) WHERE latitude IS NOT NULL;
@@ -0,0 +1,5 @@
+DELETE FROM permissions WHERE name = 'schedule:manage';
+
+DROP FUNCTION IF EXISTS pvz_is_open(UUID, VARCHAR, JSONB, TIMESTAMPTZ);
+DROP TABLE IF EXISTS pvz_schedule_overrides;
+DROP TABLE IF EXISTS pvz_schedule_exceptions;
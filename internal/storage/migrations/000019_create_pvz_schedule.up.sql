@@ -0,0 +1,62 @@
+-- Исключения из расписания: праздники (pvz_id NULL — для всех ПВЗ) и особые дни
+-- отдельного ПВЗ. Без open_time и close_time ПВЗ закрыт весь день.
+-- Исключение ПВЗ важнее праздника в тот же день
+CREATE TABLE pvz_schedule_exceptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    pvz_id UUID REFERENCES pvz(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    open_time TIME,
+    close_time TIME,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    CONSTRAINT pvz_schedule_exceptions_hours_check
+        CHECK ((open_time IS NULL) = (close_time IS NULL) AND (open_time IS NULL OR open_time < close_time))
+);
+
+CREATE UNIQUE INDEX idx_pvz_schedule_exceptions_pvz_date ON pvz_schedule_exceptions(pvz_id, date) WHERE pvz_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_pvz_schedule_exceptions_holiday ON pvz_schedule_exceptions(date) WHERE pvz_id IS NULL;
+
+-- Разрешение модератора принимать товары вне расписания до until
+CREATE TABLE pvz_schedule_overrides (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    pvz_id UUID NOT NULL REFERENCES pvz(id) ON DELETE CASCADE,
+    until TIMESTAMP NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_pvz_schedule_overrides_pvz_id ON pvz_schedule_overrides(pvz_id, until);
+
+-- Открыт ли ПВЗ в момент p_at по местному времени: разрешение модератора,
+-- иначе исключение на этот день, иначе недельное расписание.
+-- ПВЗ без расписания работает круглосуточно
+CREATE FUNCTION pvz_is_open(p_id UUID, p_timezone VARCHAR, p_hours JSONB, p_at TIMESTAMPTZ) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+    SELECT CASE
+        WHEN EXISTS (
+            SELECT 1 FROM pvz_schedule_overrides o
+            WHERE o.pvz_id = p_id AND p_at >= o.created_at AND p_at < o.until
+        ) THEN TRUE
+        WHEN e.date IS NOT NULL THEN
+            e.open_time IS NOT NULL AND l.t::time >= e.open_time AND l.t::time < e.close_time
+        WHEN p_hours = '{}'::jsonb THEN TRUE
+        ELSE p_hours -> l.day IS NOT NULL
+            AND l.t::time >= (p_hours -> l.day ->> 'open')::time
+            AND l.t::time < (p_hours -> l.day ->> 'close')::time
+    END
+    FROM (SELECT p_at AT TIME ZONE p_timezone AS t, to_char(p_at AT TIME ZONE p_timezone, 'dy') AS day) l
+    LEFT JOIN LATERAL (
+        SELECT date, open_time, close_time
+        FROM pvz_schedule_exceptions
+        WHERE date = l.t::date AND (pvz_id = p_id OR pvz_id IS NULL)
+        ORDER BY pvz_id NULLS LAST
+        LIMIT 1
+    ) e ON TRUE
+$$;
+
+INSERT INTO permissions (name, description) VALUES
+    ('schedule:manage', 'Праздники, особые дни и работа ПВЗ вне расписания');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'schedule:manage'),
+    ('moderator', 'schedule:manage');
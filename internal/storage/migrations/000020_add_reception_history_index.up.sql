@@ -0,0 +1,2 @@
+-- История приёмок ПВЗ листается курсором от новых к старым
+CREATE INDEX idx_receptions_pvz_created_at ON receptions(pvz_id, created_at DESC, id DESC);
@@ -0,0 +1,7 @@
+-- Роль синхронизируется с группами провайдера только у пользователей, которых он создал.
+-- Локальные учётные записи, связанные по email, сохраняют свою роль
+ALTER TABLE user_identities ADD COLUMN sync_role BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE user_identities SET sync_role = TRUE
+FROM users
+WHERE users.id = user_identities.user_id AND users.password_hash = '';
@@ -0,0 +1,25 @@
+ALTER TABLE pvz_schedule_overrides ALTER COLUMN created_at SET DEFAULT NOW();
+
+CREATE OR REPLACE FUNCTION pvz_is_open(p_id UUID, p_timezone VARCHAR, p_hours JSONB, p_at TIMESTAMPTZ) RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+    SELECT CASE
+        WHEN EXISTS (
+            SELECT 1 FROM pvz_schedule_overrides o
+            WHERE o.pvz_id = p_id AND p_at >= o.created_at AND p_at < o.until
+        ) THEN TRUE
+        WHEN e.date IS NOT NULL THEN
+            e.open_time IS NOT NULL AND l.t::time >= e.open_time AND l.t::time < e.close_time
+        WHEN p_hours = '{}'::jsonb THEN TRUE
+        ELSE p_hours -> l.day IS NOT NULL
+            AND l.t::time >= (p_hours -> l.day ->> 'open')::time
+            AND l.t::time < (p_hours -> l.day ->> 'close')::time
+    END
+    FROM (SELECT p_at AT TIME ZONE p_timezone AS t, to_char(p_at AT TIME ZONE p_timezone, 'dy') AS day) l
+    LEFT JOIN LATERAL (
+        SELECT date, open_time, close_time
+        FROM pvz_schedule_exceptions
+        WHERE date = l.t::date AND (pvz_id = p_id OR pvz_id IS NULL)
+        ORDER BY pvz_id NULLS LAST
+        LIMIT 1
+    ) e ON TRUE
+$$;
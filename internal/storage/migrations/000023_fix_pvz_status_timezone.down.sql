@@ -0,0 +1,7 @@
+ALTER TABLE pvz_status_changes ALTER COLUMN created_at SET DEFAULT NOW();
+
+CREATE OR REPLACE VIEW pvz_current_status AS
+SELECT DISTINCT ON (pvz_id) pvz_id, status, reason, effective_from
+FROM pvz_status_changes
+WHERE effective_from <= NOW()
+ORDER BY pvz_id, effective_from DESC, created_at DESC;
@@ -0,0 +1,10 @@
+-- effective_from хранится в UTC, как и остальные TIMESTAMP: NOW() приводится
+-- к UTC явно, чтобы запланированная смена статуса не вступала в силу раньше
+-- или позже срока при TimeZone сессии, отличной от UTC
+ALTER TABLE pvz_status_changes ALTER COLUMN created_at SET DEFAULT (NOW() AT TIME ZONE 'UTC');
+
+CREATE OR REPLACE VIEW pvz_current_status AS
+SELECT DISTINCT ON (pvz_id) pvz_id, status, reason, effective_from
+FROM pvz_status_changes
+WHERE effective_from <= (NOW() AT TIME ZONE 'UTC')
+ORDER BY pvz_id, effective_from DESC, created_at DESC;
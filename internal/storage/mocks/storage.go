@@ -18,9 +18,9 @@ type Storage struct {
 	mock.Mock
 }
 
-// AddProduct provides a mock function with given fields: ctx, receptionID, productType
-func (_m *Storage) AddProduct(ctx context.Context, receptionID uuid.UUID, productType string) (storage.Product, error) {
-	ret := _m.Called(ctx, receptionID, productType)
+// AddProduct provides a mock function with given fields: ctx, receptionID, productType, createdBy
+func (_m *Storage) AddProduct(ctx context.Context, receptionID uuid.UUID, productType string, createdBy *uuid.UUID) (storage.Product, error) {
+	ret := _m.Called(ctx, receptionID, productType, createdBy)
 
 	if len(ret) == 0 {
 		panic("no return value specified for AddProduct")
@@ -28,17 +28,17 @@ func (_m *Storage) AddProduct(ctx context.Context, receptionID uuid.UUID, produc
 
 	var r0 storage.Product
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) (storage.Product, error)); ok {
-		return rf(ctx, receptionID, productType)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, *uuid.UUID) (storage.Product, error)); ok {
+		return rf(ctx, receptionID, productType, createdBy)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) storage.Product); ok {
-		r0 = rf(ctx, receptionID, productType)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, *uuid.UUID) storage.Product); ok {
+		r0 = rf(ctx, receptionID, productType, createdBy)
 	} else {
 		r0 = ret.Get(0).(storage.Product)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string) error); ok {
-		r1 = rf(ctx, receptionID, productType)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string, *uuid.UUID) error); ok {
+		r1 = rf(ctx, receptionID, productType, createdBy)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -46,17 +46,141 @@ func (_m *Storage) AddProduct(ctx context.Context, receptionID uuid.UUID, produc
 	return r0, r1
 }
 
-// CloseReception provides a mock function with given fields: ctx, receptionID
-func (_m *Storage) CloseReception(ctx context.Context, receptionID uuid.UUID) error {
-	ret := _m.Called(ctx, receptionID)
+// AppendAudit provides a mock function with given fields: ctx, entry
+func (_m *Storage) AppendAudit(ctx context.Context, entry storage.AuditEntry) (storage.AuditEntry, error) {
+	ret := _m.Called(ctx, entry)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AppendAudit")
+	}
+
+	var r0 storage.AuditEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.AuditEntry) (storage.AuditEntry, error)); ok {
+		return rf(ctx, entry)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.AuditEntry) storage.AuditEntry); ok {
+		r0 = rf(ctx, entry)
+	} else {
+		r0 = ret.Get(0).(storage.AuditEntry)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.AuditEntry) error); ok {
+		r1 = rf(ctx, entry)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AppendAuditBatch provides a mock function with given fields: ctx, entries
+func (_m *Storage) AppendAuditBatch(ctx context.Context, entries []storage.AuditEntry) ([]storage.AuditEntry, error) {
+	ret := _m.Called(ctx, entries)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AppendAuditBatch")
+	}
+
+	var r0 []storage.AuditEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []storage.AuditEntry) ([]storage.AuditEntry, error)); ok {
+		return rf(ctx, entries)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []storage.AuditEntry) []storage.AuditEntry); ok {
+		r0 = rf(ctx, entries)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.AuditEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []storage.AuditEntry) error); ok {
+		r1 = rf(ctx, entries)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AssignUserToPVZ provides a mock function with given fields: ctx, userID, pvzID
+func (_m *Storage) AssignUserToPVZ(ctx context.Context, userID uuid.UUID, pvzID uuid.UUID) error {
+	ret := _m.Called(ctx, userID, pvzID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AssignUserToPVZ")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r0 = rf(ctx, userID, pvzID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ChangePVZStatus provides a mock function with given fields: ctx, pvzID, changes
+func (_m *Storage) ChangePVZStatus(ctx context.Context, pvzID uuid.UUID, changes []storage.PVZStatusChange) ([]storage.PVZStatusChange, error) {
+	ret := _m.Called(ctx, pvzID, changes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePVZStatus")
+	}
+
+	var r0 []storage.PVZStatusChange
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, []storage.PVZStatusChange) ([]storage.PVZStatusChange, error)); ok {
+		return rf(ctx, pvzID, changes)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, []storage.PVZStatusChange) []storage.PVZStatusChange); ok {
+		r0 = rf(ctx, pvzID, changes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.PVZStatusChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, []storage.PVZStatusChange) error); ok {
+		r1 = rf(ctx, pvzID, changes)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClearLoginFailures provides a mock function with given fields: ctx, scope, key
+func (_m *Storage) ClearLoginFailures(ctx context.Context, scope string, key string) error {
+	ret := _m.Called(ctx, scope, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClearLoginFailures")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, scope, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CloseReception provides a mock function with given fields: ctx, receptionID, closedBy
+func (_m *Storage) CloseReception(ctx context.Context, receptionID uuid.UUID, closedBy *uuid.UUID) error {
+	ret := _m.Called(ctx, receptionID, closedBy)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CloseReception")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
-		r0 = rf(ctx, receptionID)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, *uuid.UUID) error); ok {
+		r0 = rf(ctx, receptionID, closedBy)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -64,27 +188,45 @@ func (_m *Storage) CloseReception(ctx context.Context, receptionID uuid.UUID) er
 	return r0
 }
 
-// CreatePVZ provides a mock function with given fields: ctx, city
-func (_m *Storage) CreatePVZ(ctx context.Context, city string) (storage.PVZ, error) {
-	ret := _m.Called(ctx, city)
+// ConfirmMFA provides a mock function with given fields: ctx, userID, step, codeHashes
+func (_m *Storage) ConfirmMFA(ctx context.Context, userID uuid.UUID, step int64, codeHashes []string) error {
+	ret := _m.Called(ctx, userID, step, codeHashes)
 
 	if len(ret) == 0 {
-		panic("no return value specified for CreatePVZ")
+		panic("no return value specified for ConfirmMFA")
 	}
 
-	var r0 storage.PVZ
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, int64, []string) error); ok {
+		r0 = rf(ctx, userID, step, codeHashes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConsumeOIDCState provides a mock function with given fields: ctx, stateHash
+func (_m *Storage) ConsumeOIDCState(ctx context.Context, stateHash string) (storage.OIDCState, error) {
+	ret := _m.Called(ctx, stateHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConsumeOIDCState")
+	}
+
+	var r0 storage.OIDCState
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.PVZ, error)); ok {
-		return rf(ctx, city)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.OIDCState, error)); ok {
+		return rf(ctx, stateHash)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) storage.PVZ); ok {
-		r0 = rf(ctx, city)
+	if rf, ok := ret.Get(0).(func(context.Context, string) storage.OIDCState); ok {
+		r0 = rf(ctx, stateHash)
 	} else {
-		r0 = ret.Get(0).(storage.PVZ)
+		r0 = ret.Get(0).(storage.OIDCState)
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, city)
+		r1 = rf(ctx, stateHash)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -92,27 +234,27 @@ func (_m *Storage) CreatePVZ(ctx context.Context, city string) (storage.PVZ, err
 	return r0, r1
 }
 
-// CreateReception provides a mock function with given fields: ctx, pvzID
-func (_m *Storage) CreateReception(ctx context.Context, pvzID uuid.UUID) (storage.Reception, error) {
-	ret := _m.Called(ctx, pvzID)
+// CreateAPIKey provides a mock function with given fields: ctx, serviceAccountID, keyID, keyHash, expiresAt
+func (_m *Storage) CreateAPIKey(ctx context.Context, serviceAccountID uuid.UUID, keyID string, keyHash string, expiresAt *time.Time) (storage.APIKey, error) {
+	ret := _m.Called(ctx, serviceAccountID, keyID, keyHash, expiresAt)
 
 	if len(ret) == 0 {
-		panic("no return value specified for CreateReception")
+		panic("no return value specified for CreateAPIKey")
 	}
 
-	var r0 storage.Reception
+	var r0 storage.APIKey
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (storage.Reception, error)); ok {
-		return rf(ctx, pvzID)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, string, *time.Time) (storage.APIKey, error)); ok {
+		return rf(ctx, serviceAccountID, keyID, keyHash, expiresAt)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) storage.Reception); ok {
-		r0 = rf(ctx, pvzID)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, string, *time.Time) storage.APIKey); ok {
+		r0 = rf(ctx, serviceAccountID, keyID, keyHash, expiresAt)
 	} else {
-		r0 = ret.Get(0).(storage.Reception)
+		r0 = ret.Get(0).(storage.APIKey)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
-		r1 = rf(ctx, pvzID)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string, string, *time.Time) error); ok {
+		r1 = rf(ctx, serviceAccountID, keyID, keyHash, expiresAt)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -120,27 +262,27 @@ func (_m *Storage) CreateReception(ctx context.Context, pvzID uuid.UUID) (storag
 	return r0, r1
 }
 
-// CreateUser provides a mock function with given fields: ctx, email, passwordHash, role
-func (_m *Storage) CreateUser(ctx context.Context, email string, passwordHash string, role string) (storage.User, error) {
-	ret := _m.Called(ctx, email, passwordHash, role)
+// CreateCity provides a mock function with given fields: ctx, name
+func (_m *Storage) CreateCity(ctx context.Context, name string) (storage.City, error) {
+	ret := _m.Called(ctx, name)
 
 	if len(ret) == 0 {
-		panic("no return value specified for CreateUser")
+		panic("no return value specified for CreateCity")
 	}
 
-	var r0 storage.User
+	var r0 storage.City
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (storage.User, error)); ok {
-		return rf(ctx, email, passwordHash, role)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.City, error)); ok {
+		return rf(ctx, name)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) storage.User); ok {
-		r0 = rf(ctx, email, passwordHash, role)
+	if rf, ok := ret.Get(0).(func(context.Context, string) storage.City); ok {
+		r0 = rf(ctx, name)
 	} else {
-		r0 = ret.Get(0).(storage.User)
+		r0 = ret.Get(0).(storage.City)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
-		r1 = rf(ctx, email, passwordHash, role)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -148,17 +290,45 @@ func (_m *Storage) CreateUser(ctx context.Context, email string, passwordHash st
 	return r0, r1
 }
 
-// DeleteProduct provides a mock function with given fields: ctx, productID
-func (_m *Storage) DeleteProduct(ctx context.Context, productID uuid.UUID) error {
-	ret := _m.Called(ctx, productID)
+// CreateInvite provides a mock function with given fields: ctx, tokenHash, role, createdBy, expiresAt
+func (_m *Storage) CreateInvite(ctx context.Context, tokenHash string, role string, createdBy *uuid.UUID, expiresAt time.Time) (storage.Invite, error) {
+	ret := _m.Called(ctx, tokenHash, role, createdBy, expiresAt)
 
 	if len(ret) == 0 {
-		panic("no return value specified for DeleteProduct")
+		panic("no return value specified for CreateInvite")
+	}
+
+	var r0 storage.Invite
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *uuid.UUID, time.Time) (storage.Invite, error)); ok {
+		return rf(ctx, tokenHash, role, createdBy, expiresAt)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *uuid.UUID, time.Time) storage.Invite); ok {
+		r0 = rf(ctx, tokenHash, role, createdBy, expiresAt)
+	} else {
+		r0 = ret.Get(0).(storage.Invite)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *uuid.UUID, time.Time) error); ok {
+		r1 = rf(ctx, tokenHash, role, createdBy, expiresAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateOIDCState provides a mock function with given fields: ctx, stateHash, nonce, codeVerifier, expiresAt
+func (_m *Storage) CreateOIDCState(ctx context.Context, stateHash string, nonce string, codeVerifier string, expiresAt time.Time) error {
+	ret := _m.Called(ctx, stateHash, nonce, codeVerifier, expiresAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOIDCState")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
-		r0 = rf(ctx, productID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, time.Time) error); ok {
+		r0 = rf(ctx, stateHash, nonce, codeVerifier, expiresAt)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -166,27 +336,27 @@ func (_m *Storage) DeleteProduct(ctx context.Context, productID uuid.UUID) error
 	return r0
 }
 
-// GetLastProduct provides a mock function with given fields: ctx, receptionID
-func (_m *Storage) GetLastProduct(ctx context.Context, receptionID uuid.UUID) (storage.Product, error) {
-	ret := _m.Called(ctx, receptionID)
+// CreatePVZ provides a mock function with given fields: ctx, pvz
+func (_m *Storage) CreatePVZ(ctx context.Context, pvz storage.PVZ) (storage.PVZ, error) {
+	ret := _m.Called(ctx, pvz)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetLastProduct")
+		panic("no return value specified for CreatePVZ")
 	}
 
-	var r0 storage.Product
+	var r0 storage.PVZ
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (storage.Product, error)); ok {
-		return rf(ctx, receptionID)
+	if rf, ok := ret.Get(0).(func(context.Context, storage.PVZ) (storage.PVZ, error)); ok {
+		return rf(ctx, pvz)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) storage.Product); ok {
-		r0 = rf(ctx, receptionID)
+	if rf, ok := ret.Get(0).(func(context.Context, storage.PVZ) storage.PVZ); ok {
+		r0 = rf(ctx, pvz)
 	} else {
-		r0 = ret.Get(0).(storage.Product)
+		r0 = ret.Get(0).(storage.PVZ)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
-		r1 = rf(ctx, receptionID)
+	if rf, ok := ret.Get(1).(func(context.Context, storage.PVZ) error); ok {
+		r1 = rf(ctx, pvz)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -194,27 +364,45 @@ func (_m *Storage) GetLastProduct(ctx context.Context, receptionID uuid.UUID) (s
 	return r0, r1
 }
 
-// GetOpenReception provides a mock function with given fields: ctx, pvzID
-func (_m *Storage) GetOpenReception(ctx context.Context, pvzID uuid.UUID) (storage.Reception, error) {
-	ret := _m.Called(ctx, pvzID)
+// CreatePasswordReset provides a mock function with given fields: ctx, userID, tokenHash, expiresAt
+func (_m *Storage) CreatePasswordReset(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	ret := _m.Called(ctx, userID, tokenHash, expiresAt)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetOpenReception")
+		panic("no return value specified for CreatePasswordReset")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, time.Time) error); ok {
+		r0 = rf(ctx, userID, tokenHash, expiresAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateReception provides a mock function with given fields: ctx, pvzID, createdBy
+func (_m *Storage) CreateReception(ctx context.Context, pvzID uuid.UUID, createdBy *uuid.UUID) (storage.Reception, error) {
+	ret := _m.Called(ctx, pvzID, createdBy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateReception")
 	}
 
 	var r0 storage.Reception
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (storage.Reception, error)); ok {
-		return rf(ctx, pvzID)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, *uuid.UUID) (storage.Reception, error)); ok {
+		return rf(ctx, pvzID, createdBy)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) storage.Reception); ok {
-		r0 = rf(ctx, pvzID)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, *uuid.UUID) storage.Reception); ok {
+		r0 = rf(ctx, pvzID, createdBy)
 	} else {
 		r0 = ret.Get(0).(storage.Reception)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
-		r1 = rf(ctx, pvzID)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, *uuid.UUID) error); ok {
+		r1 = rf(ctx, pvzID, createdBy)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -222,29 +410,27 @@ func (_m *Storage) GetOpenReception(ctx context.Context, pvzID uuid.UUID) (stora
 	return r0, r1
 }
 
-// GetPVZsWithReceptions provides a mock function with given fields: ctx, startDate, endDate, page, limit
-func (_m *Storage) GetPVZsWithReceptions(ctx context.Context, startDate time.Time, endDate time.Time, page int, limit int) ([]storage.PVZWithReceptions, error) {
-	ret := _m.Called(ctx, startDate, endDate, page, limit)
+// CreateRefreshToken provides a mock function with given fields: ctx, userID, familyID, tokenHash, expiresAt
+func (_m *Storage) CreateRefreshToken(ctx context.Context, userID uuid.UUID, familyID uuid.UUID, tokenHash string, expiresAt time.Time) (storage.RefreshToken, error) {
+	ret := _m.Called(ctx, userID, familyID, tokenHash, expiresAt)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetPVZsWithReceptions")
+		panic("no return value specified for CreateRefreshToken")
 	}
 
-	var r0 []storage.PVZWithReceptions
+	var r0 storage.RefreshToken
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time, int, int) ([]storage.PVZWithReceptions, error)); ok {
-		return rf(ctx, startDate, endDate, page, limit)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID, string, time.Time) (storage.RefreshToken, error)); ok {
+		return rf(ctx, userID, familyID, tokenHash, expiresAt)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time, int, int) []storage.PVZWithReceptions); ok {
-		r0 = rf(ctx, startDate, endDate, page, limit)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID, string, time.Time) storage.RefreshToken); ok {
+		r0 = rf(ctx, userID, familyID, tokenHash, expiresAt)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]storage.PVZWithReceptions)
-		}
+		r0 = ret.Get(0).(storage.RefreshToken)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Time, int, int) error); ok {
-		r1 = rf(ctx, startDate, endDate, page, limit)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uuid.UUID, string, time.Time) error); ok {
+		r1 = rf(ctx, userID, familyID, tokenHash, expiresAt)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -252,27 +438,1721 @@ func (_m *Storage) GetPVZsWithReceptions(ctx context.Context, startDate time.Tim
 	return r0, r1
 }
 
-// GetUserByEmail provides a mock function with given fields: ctx, email
-func (_m *Storage) GetUserByEmail(ctx context.Context, email string) (storage.User, error) {
-	ret := _m.Called(ctx, email)
+// CreateScheduleOverride provides a mock function with given fields: ctx, o
+func (_m *Storage) CreateScheduleOverride(ctx context.Context, o storage.ScheduleOverride) (storage.ScheduleOverride, error) {
+	ret := _m.Called(ctx, o)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUserByEmail")
+		panic("no return value specified for CreateScheduleOverride")
+	}
+
+	var r0 storage.ScheduleOverride
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.ScheduleOverride) (storage.ScheduleOverride, error)); ok {
+		return rf(ctx, o)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.ScheduleOverride) storage.ScheduleOverride); ok {
+		r0 = rf(ctx, o)
+	} else {
+		r0 = ret.Get(0).(storage.ScheduleOverride)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.ScheduleOverride) error); ok {
+		r1 = rf(ctx, o)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateServiceAccount provides a mock function with given fields: ctx, name, description, permissions, createdBy
+func (_m *Storage) CreateServiceAccount(ctx context.Context, name string, description string, permissions []string, createdBy *uuid.UUID) (storage.ServiceAccount, error) {
+	ret := _m.Called(ctx, name, description, permissions, createdBy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateServiceAccount")
+	}
+
+	var r0 storage.ServiceAccount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string, *uuid.UUID) (storage.ServiceAccount, error)); ok {
+		return rf(ctx, name, description, permissions, createdBy)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string, *uuid.UUID) storage.ServiceAccount); ok {
+		r0 = rf(ctx, name, description, permissions, createdBy)
+	} else {
+		r0 = ret.Get(0).(storage.ServiceAccount)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, []string, *uuid.UUID) error); ok {
+		r1 = rf(ctx, name, description, permissions, createdBy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateSession provides a mock function with given fields: ctx, userID, device, userAgent, ip
+func (_m *Storage) CreateSession(ctx context.Context, userID uuid.UUID, device string, userAgent string, ip string) (storage.Session, error) {
+	ret := _m.Called(ctx, userID, device, userAgent, ip)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateSession")
+	}
+
+	var r0 storage.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, string, string) (storage.Session, error)); ok {
+		return rf(ctx, userID, device, userAgent, ip)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, string, string) storage.Session); ok {
+		r0 = rf(ctx, userID, device, userAgent, ip)
+	} else {
+		r0 = ret.Get(0).(storage.Session)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string, string, string) error); ok {
+		r1 = rf(ctx, userID, device, userAgent, ip)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateUser provides a mock function with given fields: ctx, email, passwordHash, role
+func (_m *Storage) CreateUser(ctx context.Context, email string, passwordHash string, role string) (storage.User, error) {
+	ret := _m.Called(ctx, email, passwordHash, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateUser")
 	}
 
 	var r0 storage.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.User, error)); ok {
-		return rf(ctx, email)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (storage.User, error)); ok {
+		return rf(ctx, email, passwordHash, role)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) storage.User); ok {
-		r0 = rf(ctx, email)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) storage.User); ok {
+		r0 = rf(ctx, email, passwordHash, role)
 	} else {
 		r0 = ret.Get(0).(storage.User)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, email)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, email, passwordHash, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateUserWithInvite provides a mock function with given fields: ctx, email, passwordHash, inviteHash
+func (_m *Storage) CreateUserWithInvite(ctx context.Context, email string, passwordHash string, inviteHash string) (storage.User, error) {
+	ret := _m.Called(ctx, email, passwordHash, inviteHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateUserWithInvite")
+	}
+
+	var r0 storage.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (storage.User, error)); ok {
+		return rf(ctx, email, passwordHash, inviteHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) storage.User); ok {
+		r0 = rf(ctx, email, passwordHash, inviteHash)
+	} else {
+		r0 = ret.Get(0).(storage.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, email, passwordHash, inviteHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteCity provides a mock function with given fields: ctx, name
+func (_m *Storage) DeleteCity(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteCity")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteMFA provides a mock function with given fields: ctx, userID
+func (_m *Storage) DeleteMFA(ctx context.Context, userID uuid.UUID) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteMFA")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteProduct provides a mock function with given fields: ctx, productID, deletedBy
+func (_m *Storage) DeleteProduct(ctx context.Context, productID uuid.UUID, deletedBy *uuid.UUID) error {
+	ret := _m.Called(ctx, productID, deletedBy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteProduct")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, *uuid.UUID) error); ok {
+		r0 = rf(ctx, productID, deletedBy)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteScheduleException provides a mock function with given fields: ctx, pvzID, date
+func (_m *Storage) DeleteScheduleException(ctx context.Context, pvzID *uuid.UUID, date string) error {
+	ret := _m.Called(ctx, pvzID, date)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteScheduleException")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *uuid.UUID, string) error); ok {
+		r0 = rf(ctx, pvzID, date)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteServiceAccount provides a mock function with given fields: ctx, id
+func (_m *Storage) DeleteServiceAccount(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteServiceAccount")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteUser provides a mock function with given fields: ctx, id
+func (_m *Storage) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EndScheduleOverride provides a mock function with given fields: ctx, pvzID
+func (_m *Storage) EndScheduleOverride(ctx context.Context, pvzID uuid.UUID) error {
+	ret := _m.Called(ctx, pvzID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EndScheduleOverride")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, pvzID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindNearbyPVZs provides a mock function with given fields: ctx, filter
+func (_m *Storage) FindNearbyPVZs(ctx context.Context, filter storage.NearbyFilter) ([]storage.NearbyPVZ, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindNearbyPVZs")
+	}
+
+	var r0 []storage.NearbyPVZ
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.NearbyFilter) ([]storage.NearbyPVZ, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.NearbyFilter) []storage.NearbyPVZ); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.NearbyPVZ)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.NearbyFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAPIKey provides a mock function with given fields: ctx, keyID
+func (_m *Storage) GetAPIKey(ctx context.Context, keyID string) (storage.APIKey, error) {
+	ret := _m.Called(ctx, keyID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAPIKey")
+	}
+
+	var r0 storage.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.APIKey, error)); ok {
+		return rf(ctx, keyID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) storage.APIKey); ok {
+		r0 = rf(ctx, keyID)
+	} else {
+		r0 = ret.Get(0).(storage.APIKey)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, keyID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCity provides a mock function with given fields: ctx, name
+func (_m *Storage) GetCity(ctx context.Context, name string) (storage.City, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCity")
+	}
+
+	var r0 storage.City
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.City, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) storage.City); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Get(0).(storage.City)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLastProduct provides a mock function with given fields: ctx, receptionID
+func (_m *Storage) GetLastProduct(ctx context.Context, receptionID uuid.UUID) (storage.Product, error) {
+	ret := _m.Called(ctx, receptionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLastProduct")
+	}
+
+	var r0 storage.Product
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (storage.Product, error)); ok {
+		return rf(ctx, receptionID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) storage.Product); ok {
+		r0 = rf(ctx, receptionID)
+	} else {
+		r0 = ret.Get(0).(storage.Product)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, receptionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLoginLock provides a mock function with given fields: ctx, scope, key
+func (_m *Storage) GetLoginLock(ctx context.Context, scope string, key string) (time.Duration, error) {
+	ret := _m.Called(ctx, scope, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLoginLock")
+	}
+
+	var r0 time.Duration
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (time.Duration, error)); ok {
+		return rf(ctx, scope, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) time.Duration); ok {
+		r0 = rf(ctx, scope, key)
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, scope, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMFA provides a mock function with given fields: ctx, userID
+func (_m *Storage) GetMFA(ctx context.Context, userID uuid.UUID) (storage.MFA, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMFA")
+	}
+
+	var r0 storage.MFA
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (storage.MFA, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) storage.MFA); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(storage.MFA)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOpenReception provides a mock function with given fields: ctx, pvzID
+func (_m *Storage) GetOpenReception(ctx context.Context, pvzID uuid.UUID) (storage.Reception, error) {
+	ret := _m.Called(ctx, pvzID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOpenReception")
+	}
+
+	var r0 storage.Reception
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (storage.Reception, error)); ok {
+		return rf(ctx, pvzID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) storage.Reception); ok {
+		r0 = rf(ctx, pvzID)
+	} else {
+		r0 = ret.Get(0).(storage.Reception)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, pvzID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPVZ provides a mock function with given fields: ctx, id
+func (_m *Storage) GetPVZ(ctx context.Context, id uuid.UUID) (storage.PVZ, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPVZ")
+	}
+
+	var r0 storage.PVZ
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (storage.PVZ, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) storage.PVZ); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(storage.PVZ)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPVZsWithReceptions provides a mock function with given fields: ctx, filter
+func (_m *Storage) GetPVZsWithReceptions(ctx context.Context, filter storage.PVZFilter) ([]storage.PVZWithReceptions, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPVZsWithReceptions")
+	}
+
+	var r0 []storage.PVZWithReceptions
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.PVZFilter) ([]storage.PVZWithReceptions, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.PVZFilter) []storage.PVZWithReceptions); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.PVZWithReceptions)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.PVZFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReception provides a mock function with given fields: ctx, id, includeDeleted
+func (_m *Storage) GetReception(ctx context.Context, id uuid.UUID, includeDeleted bool) (storage.ReceptionWithProducts, error) {
+	ret := _m.Called(ctx, id, includeDeleted)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReception")
+	}
+
+	var r0 storage.ReceptionWithProducts
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, bool) (storage.ReceptionWithProducts, error)); ok {
+		return rf(ctx, id, includeDeleted)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, bool) storage.ReceptionWithProducts); ok {
+		r0 = rf(ctx, id, includeDeleted)
+	} else {
+		r0 = ret.Get(0).(storage.ReceptionWithProducts)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, bool) error); ok {
+		r1 = rf(ctx, id, includeDeleted)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRefreshToken provides a mock function with given fields: ctx, tokenHash
+func (_m *Storage) GetRefreshToken(ctx context.Context, tokenHash string) (storage.RefreshToken, error) {
+	ret := _m.Called(ctx, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRefreshToken")
+	}
+
+	var r0 storage.RefreshToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.RefreshToken, error)); ok {
+		return rf(ctx, tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) storage.RefreshToken); ok {
+		r0 = rf(ctx, tokenHash)
+	} else {
+		r0 = ret.Get(0).(storage.RefreshToken)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRolePermissions provides a mock function with given fields: ctx, role
+func (_m *Storage) GetRolePermissions(ctx context.Context, role string) ([]string, error) {
+	ret := _m.Called(ctx, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRolePermissions")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]string, error)); ok {
+		return rf(ctx, role)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []string); ok {
+		r0 = rf(ctx, role)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetServiceAccount provides a mock function with given fields: ctx, id
+func (_m *Storage) GetServiceAccount(ctx context.Context, id uuid.UUID) (storage.ServiceAccount, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetServiceAccount")
+	}
+
+	var r0 storage.ServiceAccount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (storage.ServiceAccount, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) storage.ServiceAccount); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(storage.ServiceAccount)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserByEmail provides a mock function with given fields: ctx, email
+func (_m *Storage) GetUserByEmail(ctx context.Context, email string) (storage.User, error) {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByEmail")
+	}
+
+	var r0 storage.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.User, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) storage.User); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Get(0).(storage.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserByID provides a mock function with given fields: ctx, id
+func (_m *Storage) GetUserByID(ctx context.Context, id uuid.UUID) (storage.User, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByID")
+	}
+
+	var r0 storage.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (storage.User, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) storage.User); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(storage.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserByIdentity provides a mock function with given fields: ctx, issuer, subject
+func (_m *Storage) GetUserByIdentity(ctx context.Context, issuer string, subject string) (storage.LinkedUser, error) {
+	ret := _m.Called(ctx, issuer, subject)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByIdentity")
+	}
+
+	var r0 storage.LinkedUser
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (storage.LinkedUser, error)); ok {
+		return rf(ctx, issuer, subject)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) storage.LinkedUser); ok {
+		r0 = rf(ctx, issuer, subject)
+	} else {
+		r0 = ret.Get(0).(storage.LinkedUser)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, issuer, subject)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserPVZIDs provides a mock function with given fields: ctx, userID
+func (_m *Storage) GetUserPVZIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserPVZIDs")
+	}
+
+	var r0 []uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]uuid.UUID, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []uuid.UUID); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ImportPVZs provides a mock function with given fields: ctx, rows, opts
+func (_m *Storage) ImportPVZs(ctx context.Context, rows []storage.ImportRow, opts storage.ImportOptions) (storage.ImportReport, error) {
+	ret := _m.Called(ctx, rows, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportPVZs")
+	}
+
+	var r0 storage.ImportReport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []storage.ImportRow, storage.ImportOptions) (storage.ImportReport, error)); ok {
+		return rf(ctx, rows, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []storage.ImportRow, storage.ImportOptions) storage.ImportReport); ok {
+		r0 = rf(ctx, rows, opts)
+	} else {
+		r0 = ret.Get(0).(storage.ImportReport)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []storage.ImportRow, storage.ImportOptions) error); ok {
+		r1 = rf(ctx, rows, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsMFARequired provides a mock function with given fields: ctx, role
+func (_m *Storage) IsMFARequired(ctx context.Context, role string) (bool, error) {
+	ret := _m.Called(ctx, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsMFARequired")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, role)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, role)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsTokenRevoked provides a mock function with given fields: ctx, jti
+func (_m *Storage) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	ret := _m.Called(ctx, jti)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsTokenRevoked")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, jti)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, jti)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, jti)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LinkIdentity provides a mock function with given fields: ctx, issuer, subject, userID, syncRole
+func (_m *Storage) LinkIdentity(ctx context.Context, issuer string, subject string, userID uuid.UUID, syncRole bool) error {
+	ret := _m.Called(ctx, issuer, subject, userID, syncRole)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LinkIdentity")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, uuid.UUID, bool) error); ok {
+		r0 = rf(ctx, issuer, subject, userID, syncRole)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListAPIKeys provides a mock function with given fields: ctx, serviceAccountID
+func (_m *Storage) ListAPIKeys(ctx context.Context, serviceAccountID uuid.UUID) ([]storage.APIKey, error) {
+	ret := _m.Called(ctx, serviceAccountID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAPIKeys")
+	}
+
+	var r0 []storage.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]storage.APIKey, error)); ok {
+		return rf(ctx, serviceAccountID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []storage.APIKey); ok {
+		r0 = rf(ctx, serviceAccountID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, serviceAccountID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListAuditEntries provides a mock function with given fields: ctx, filter
+func (_m *Storage) ListAuditEntries(ctx context.Context, filter storage.AuditFilter) ([]storage.AuditEntry, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAuditEntries")
+	}
+
+	var r0 []storage.AuditEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.AuditFilter) ([]storage.AuditEntry, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.AuditFilter) []storage.AuditEntry); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.AuditEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.AuditFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListCities provides a mock function with given fields: ctx, includeInactive
+func (_m *Storage) ListCities(ctx context.Context, includeInactive bool) ([]storage.City, error) {
+	ret := _m.Called(ctx, includeInactive)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListCities")
+	}
+
+	var r0 []storage.City
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, bool) ([]storage.City, error)); ok {
+		return rf(ctx, includeInactive)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, bool) []storage.City); ok {
+		r0 = rf(ctx, includeInactive)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.City)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, bool) error); ok {
+		r1 = rf(ctx, includeInactive)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPVZSessions provides a mock function with given fields: ctx, pvzID
+func (_m *Storage) ListPVZSessions(ctx context.Context, pvzID uuid.UUID) ([]storage.Session, error) {
+	ret := _m.Called(ctx, pvzID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListPVZSessions")
+	}
+
+	var r0 []storage.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]storage.Session, error)); ok {
+		return rf(ctx, pvzID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []storage.Session); ok {
+		r0 = rf(ctx, pvzID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, pvzID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPVZStatusChanges provides a mock function with given fields: ctx, pvzID
+func (_m *Storage) ListPVZStatusChanges(ctx context.Context, pvzID uuid.UUID) ([]storage.PVZStatusChange, error) {
+	ret := _m.Called(ctx, pvzID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListPVZStatusChanges")
+	}
+
+	var r0 []storage.PVZStatusChange
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]storage.PVZStatusChange, error)); ok {
+		return rf(ctx, pvzID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []storage.PVZStatusChange); ok {
+		r0 = rf(ctx, pvzID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.PVZStatusChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, pvzID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListReceptions provides a mock function with given fields: ctx, filter
+func (_m *Storage) ListReceptions(ctx context.Context, filter storage.ReceptionFilter) ([]storage.Reception, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListReceptions")
+	}
+
+	var r0 []storage.Reception
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.ReceptionFilter) ([]storage.Reception, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.ReceptionFilter) []storage.Reception); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Reception)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.ReceptionFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListScheduleExceptions provides a mock function with given fields: ctx, filter
+func (_m *Storage) ListScheduleExceptions(ctx context.Context, filter storage.ScheduleExceptionFilter) ([]storage.ScheduleException, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListScheduleExceptions")
+	}
+
+	var r0 []storage.ScheduleException
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.ScheduleExceptionFilter) ([]storage.ScheduleException, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.ScheduleExceptionFilter) []storage.ScheduleException); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.ScheduleException)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.ScheduleExceptionFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListServiceAccounts provides a mock function with given fields: ctx
+func (_m *Storage) ListServiceAccounts(ctx context.Context) ([]storage.ServiceAccount, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListServiceAccounts")
+	}
+
+	var r0 []storage.ServiceAccount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]storage.ServiceAccount, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []storage.ServiceAccount); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.ServiceAccount)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListUserSessions provides a mock function with given fields: ctx, userID
+func (_m *Storage) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]storage.Session, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUserSessions")
+	}
+
+	var r0 []storage.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]storage.Session, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []storage.Session); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListUsers provides a mock function with given fields: ctx, filter
+func (_m *Storage) ListUsers(ctx context.Context, filter storage.UserFilter) ([]storage.User, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUsers")
+	}
+
+	var r0 []storage.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.UserFilter) ([]storage.User, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.UserFilter) []storage.User); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.UserFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LockLogin provides a mock function with given fields: ctx, scope, key, duration
+func (_m *Storage) LockLogin(ctx context.Context, scope string, key string, duration time.Duration) error {
+	ret := _m.Called(ctx, scope, key, duration)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LockLogin")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Duration) error); ok {
+		r0 = rf(ctx, scope, key, duration)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MarkRefreshTokenUsed provides a mock function with given fields: ctx, id
+func (_m *Storage) MarkRefreshTokenUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkRefreshTokenUsed")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (bool, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) bool); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordLoginFailure provides a mock function with given fields: ctx, scope, key, window
+func (_m *Storage) RecordLoginFailure(ctx context.Context, scope string, key string, window time.Duration) (int, error) {
+	ret := _m.Called(ctx, scope, key, window)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordLoginFailure")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Duration) (int, error)); ok {
+		return rf(ctx, scope, key, window)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Duration) int); ok {
+		r0 = rf(ctx, scope, key, window)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Duration) error); ok {
+		r1 = rf(ctx, scope, key, window)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReplaceRecoveryCodes provides a mock function with given fields: ctx, userID, codeHashes
+func (_m *Storage) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	ret := _m.Called(ctx, userID, codeHashes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReplaceRecoveryCodes")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, []string) error); ok {
+		r0 = rf(ctx, userID, codeHashes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResetPassword provides a mock function with given fields: ctx, tokenHash, passwordHash
+func (_m *Storage) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (uuid.UUID, error) {
+	ret := _m.Called(ctx, tokenHash, passwordHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetPassword")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (uuid.UUID, error)); ok {
+		return rf(ctx, tokenHash, passwordHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) uuid.UUID); ok {
+		r0 = rf(ctx, tokenHash, passwordHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, tokenHash, passwordHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevokeAPIKey provides a mock function with given fields: ctx, serviceAccountID, id
+func (_m *Storage) RevokeAPIKey(ctx context.Context, serviceAccountID uuid.UUID, id uuid.UUID) error {
+	ret := _m.Called(ctx, serviceAccountID, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r0 = rf(ctx, serviceAccountID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeRefreshTokenFamily provides a mock function with given fields: ctx, familyID
+func (_m *Storage) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	ret := _m.Called(ctx, familyID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeRefreshTokenFamily")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, familyID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeSession provides a mock function with given fields: ctx, userID, id
+func (_m *Storage) RevokeSession(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	ret := _m.Called(ctx, userID, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeSession")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r0 = rf(ctx, userID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeToken provides a mock function with given fields: ctx, jti, expiresAt
+func (_m *Storage) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ret := _m.Called(ctx, jti, expiresAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, jti, expiresAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeUserSessions provides a mock function with given fields: ctx, userID
+func (_m *Storage) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeUserSessions")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetCityActive provides a mock function with given fields: ctx, name, active
+func (_m *Storage) SetCityActive(ctx context.Context, name string, active bool) (storage.City, error) {
+	ret := _m.Called(ctx, name, active)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetCityActive")
+	}
+
+	var r0 storage.City
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) (storage.City, error)); ok {
+		return rf(ctx, name, active)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) storage.City); ok {
+		r0 = rf(ctx, name, active)
+	} else {
+		r0 = ret.Get(0).(storage.City)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = rf(ctx, name, active)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetPasswordResetRequired provides a mock function with given fields: ctx, id, required
+func (_m *Storage) SetPasswordResetRequired(ctx context.Context, id uuid.UUID, required bool) error {
+	ret := _m.Called(ctx, id, required)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetPasswordResetRequired")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, bool) error); ok {
+		r0 = rf(ctx, id, required)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetRoleMFARequired provides a mock function with given fields: ctx, role, required
+func (_m *Storage) SetRoleMFARequired(ctx context.Context, role string, required bool) error {
+	ret := _m.Called(ctx, role, required)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetRoleMFARequired")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) error); ok {
+		r0 = rf(ctx, role, required)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetScheduleException provides a mock function with given fields: ctx, e
+func (_m *Storage) SetScheduleException(ctx context.Context, e storage.ScheduleException) (storage.ScheduleException, error) {
+	ret := _m.Called(ctx, e)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetScheduleException")
+	}
+
+	var r0 storage.ScheduleException
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.ScheduleException) (storage.ScheduleException, error)); ok {
+		return rf(ctx, e)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.ScheduleException) storage.ScheduleException); ok {
+		r0 = rf(ctx, e)
+	} else {
+		r0 = ret.Get(0).(storage.ScheduleException)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.ScheduleException) error); ok {
+		r1 = rf(ctx, e)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetUserDisabled provides a mock function with given fields: ctx, id, disabled
+func (_m *Storage) SetUserDisabled(ctx context.Context, id uuid.UUID, disabled bool) error {
+	ret := _m.Called(ctx, id, disabled)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserDisabled")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, bool) error); ok {
+		r0 = rf(ctx, id, disabled)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StartMFAEnrollment provides a mock function with given fields: ctx, userID, secret
+func (_m *Storage) StartMFAEnrollment(ctx context.Context, userID uuid.UUID, secret string) error {
+	ret := _m.Called(ctx, userID, secret)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StartMFAEnrollment")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) error); ok {
+		r0 = rf(ctx, userID, secret)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TouchAPIKey provides a mock function with given fields: ctx, id
+func (_m *Storage) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TouchAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TouchSession provides a mock function with given fields: ctx, id
+func (_m *Storage) TouchSession(ctx context.Context, id uuid.UUID) (bool, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TouchSession")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (bool, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) bool); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UnassignUserFromPVZ provides a mock function with given fields: ctx, userID, pvzID
+func (_m *Storage) UnassignUserFromPVZ(ctx context.Context, userID uuid.UUID, pvzID uuid.UUID) error {
+	ret := _m.Called(ctx, userID, pvzID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UnassignUserFromPVZ")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r0 = rf(ctx, userID, pvzID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateLastLogin provides a mock function with given fields: ctx, id
+func (_m *Storage) UpdateLastLogin(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateLastLogin")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePVZ provides a mock function with given fields: ctx, pvz
+func (_m *Storage) UpdatePVZ(ctx context.Context, pvz storage.PVZ) (storage.PVZ, error) {
+	ret := _m.Called(ctx, pvz)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePVZ")
+	}
+
+	var r0 storage.PVZ
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.PVZ) (storage.PVZ, error)); ok {
+		return rf(ctx, pvz)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.PVZ) storage.PVZ); ok {
+		r0 = rf(ctx, pvz)
+	} else {
+		r0 = ret.Get(0).(storage.PVZ)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.PVZ) error); ok {
+		r1 = rf(ctx, pvz)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdatePasswordHash provides a mock function with given fields: ctx, id, oldHash, newHash
+func (_m *Storage) UpdatePasswordHash(ctx context.Context, id uuid.UUID, oldHash string, newHash string) error {
+	ret := _m.Called(ctx, id, oldHash, newHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePasswordHash")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, string) error); ok {
+		r0 = rf(ctx, id, oldHash, newHash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateUserRole provides a mock function with given fields: ctx, id, role
+func (_m *Storage) UpdateUserRole(ctx context.Context, id uuid.UUID, role string) error {
+	ret := _m.Called(ctx, id, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateUserRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) error); ok {
+		r0 = rf(ctx, id, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseMFAStep provides a mock function with given fields: ctx, userID, step
+func (_m *Storage) UseMFAStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	ret := _m.Called(ctx, userID, step)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UseMFAStep")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, int64) (bool, error)); ok {
+		return rf(ctx, userID, step)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, int64) bool); ok {
+		r0 = rf(ctx, userID, step)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, int64) error); ok {
+		r1 = rf(ctx, userID, step)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UseRecoveryCode provides a mock function with given fields: ctx, userID, codeHash
+func (_m *Storage) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	ret := _m.Called(ctx, userID, codeHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UseRecoveryCode")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) (bool, error)); ok {
+		return rf(ctx, userID, codeHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) bool); ok {
+		r0 = rf(ctx, userID, codeHash)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, string) error); ok {
+		r1 = rf(ctx, userID, codeHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifyAuditLog provides a mock function with given fields: ctx
+func (_m *Storage) VerifyAuditLog(ctx context.Context) (storage.AuditVerification, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyAuditLog")
+	}
+
+	var r0 storage.AuditVerification
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (storage.AuditVerification, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) storage.AuditVerification); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(storage.AuditVerification)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
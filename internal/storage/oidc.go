@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OIDCState is what the callback needs to finish a sign-in started by
+// the login redirect.
+type OIDCState struct {
+	Nonce        string
+	CodeVerifier string
+}
+
+func (s *PostgresStorage) CreateOIDCState(ctx context.Context, stateHash, nonce, codeVerifier string, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO oidc_states (state_hash, nonce, code_verifier, expires_at)
+		VALUES ($1, $2, $3, $4)`,
+		stateHash, nonce, codeVerifier, expiresAt,
+	)
+	return err
+}
+
+// ConsumeOIDCState deletes the state and returns it, so every state can
+// be used for exactly one callback.
+func (s *PostgresStorage) ConsumeOIDCState(ctx context.Context, stateHash string) (OIDCState, error) {
+	var state OIDCState
+	err := s.db.QueryRowContext(ctx,
+		`DELETE FROM oidc_states
+		WHERE state_hash = $1 AND expires_at > NOW()
+		RETURNING nonce, code_verifier`,
+		stateHash,
+	).Scan(&state.Nonce, &state.CodeVerifier)
+	if errors.Is(err, sql.ErrNoRows) {
+		return OIDCState{}, ErrNotFound
+	}
+	return state, err
+}
+
+// PurgeOIDCStates drops sign-ins that were started but never finished.
+func (s *PostgresStorage) PurgeOIDCStates(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM oidc_states WHERE expires_at < NOW()`)
+	return err
+}
+
+// LinkedUser is the user an identity is linked to. SyncRole is set when
+// the identity created the account, so its role follows the provider.
+type LinkedUser struct {
+	User
+	SyncRole bool
+}
+
+func (s *PostgresStorage) GetUserByIdentity(ctx context.Context, issuer, subject string) (LinkedUser, error) {
+	var linked LinkedUser
+	user := &linked.User
+	err := s.db.QueryRowContext(ctx,
+		`SELECT `+userColumns+`, i.sync_role
+		FROM users, (SELECT user_id, sync_role FROM user_identities WHERE issuer = $1 AND subject = $2) i
+		WHERE id = i.user_id`,
+		issuer, subject,
+	).Scan(&user.ID, &user.Email, &user.PasswordHash, &user.Role,
+		&user.CreatedAt, &user.DisabledAt, &user.LastLoginAt, &user.PasswordResetRequired, &linked.SyncRole)
+	if errors.Is(err, sql.ErrNoRows) {
+		return LinkedUser{}, ErrNotFound
+	}
+	return linked, err
+}
+
+// LinkIdentity ties an account at the identity provider to a user. Linking
+// the same identity again is a no-op. With syncRole the user's role is
+// kept in line with the provider's groups.
+func (s *PostgresStorage) LinkIdentity(ctx context.Context, issuer, subject string, userID uuid.UUID, syncRole bool) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO user_identities (issuer, subject, user_id, sync_role)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (issuer, subject) DO NOTHING`,
+		issuer, subject, userID, syncRole,
+	)
+	if isForeignKeyViolation(err) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,102 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOIDC(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+
+	t.Run("create state", func(t *testing.T) {
+		expiresAt := time.Now().Add(10 * time.Minute)
+		mock.ExpectExec(`INSERT INTO oidc_states \(state_hash, nonce, code_verifier, expires_at\)`).
+			WithArgs("statehash", "nonce", "verifier", expiresAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.CreateOIDCState(context.Background(), "statehash", "nonce", "verifier", expiresAt)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("consume state", func(t *testing.T) {
+		mock.ExpectQuery(`DELETE FROM oidc_states WHERE state_hash = \$1 AND expires_at > NOW\(\) RETURNING nonce, code_verifier`).
+			WithArgs("statehash").
+			WillReturnRows(sqlmock.NewRows([]string{"nonce", "code_verifier"}).AddRow("nonce", "verifier"))
+
+		state, err := store.ConsumeOIDCState(context.Background(), "statehash")
+
+		assert.NoError(t, err)
+		assert.Equal(t, storage.OIDCState{Nonce: "nonce", CodeVerifier: "verifier"}, state)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("consume used or expired state", func(t *testing.T) {
+		mock.ExpectQuery(`DELETE FROM oidc_states`).
+			WithArgs("statehash").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := store.ConsumeOIDCState(context.Background(), "statehash")
+
+		assert.Equal(t, storage.ErrNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get user by identity", func(t *testing.T) {
+		userID := uuid.New()
+		mock.ExpectQuery(`FROM users, \(SELECT user_id, sync_role FROM user_identities WHERE issuer = \$1 AND subject = \$2\) i WHERE id = i.user_id`).
+			WithArgs("https://idp.test", "staff-1").
+			WillReturnRows(sqlmock.NewRows(append(userColumns, "sync_role")).
+				AddRow(userID, "staff@example.com", "", "employee", time.Now(), nil, nil, false, true))
+
+		user, err := store.GetUserByIdentity(context.Background(), "https://idp.test", "staff-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, userID, user.ID)
+		assert.True(t, user.SyncRole)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown identity", func(t *testing.T) {
+		mock.ExpectQuery(`FROM user_identities`).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := store.GetUserByIdentity(context.Background(), "https://idp.test", "nobody")
+
+		assert.Equal(t, storage.ErrNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("link identity", func(t *testing.T) {
+		userID := uuid.New()
+		mock.ExpectExec(`INSERT INTO user_identities \(issuer, subject, user_id, sync_role\) VALUES \(\$1, \$2, \$3, \$4\) ON CONFLICT \(issuer, subject\) DO NOTHING`).
+			WithArgs("https://idp.test", "staff-1", userID, false).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, store.LinkIdentity(context.Background(), "https://idp.test", "staff-1", userID, false))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("link identity to deleted user", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO user_identities`).
+			WillReturnError(&pq.Error{Code: "23503"})
+
+		assert.Equal(t, storage.ErrNotFound, store.LinkIdentity(context.Background(), "https://idp.test", "staff-1", uuid.New(), true))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidResetToken = errors.New("invalid password reset token")
+
+// CreatePasswordReset stores a reset token for the user. Earlier unused
+// tokens stop working, so only the latest link can be used.
+func (s *PostgresStorage) CreatePasswordReset(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		`WITH superseded AS (
+			UPDATE password_resets
+			SET used_at = NOW()
+			WHERE user_id = $1 AND used_at IS NULL
+		)
+		INSERT INTO password_resets (token_hash, user_id, expires_at)
+		VALUES ($2, $1, $3)`,
+		userID, tokenHash, expiresAt,
+	)
+	if isForeignKeyViolation(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// ResetPassword consumes the token and sets the new password hash in one
+// statement. It also clears a reset required by an admin and returns the
+// user whose password was changed.
+func (s *PostgresStorage) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := s.db.QueryRowContext(ctx,
+		`WITH reset AS (
+			UPDATE password_resets
+			SET used_at = NOW()
+			WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+			RETURNING user_id
+		)
+		UPDATE users
+		SET password_hash = $2, password_reset_required = FALSE
+		FROM reset
+		WHERE users.id = reset.user_id
+		RETURNING users.id`,
+		tokenHash, passwordHash,
+	).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, ErrInvalidResetToken
+	}
+	return userID, err
+}
+
+// PurgePasswordResets drops used and expired reset tokens.
+func (s *PostgresStorage) PurgePasswordResets(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM password_resets WHERE used_at IS NOT NULL OR expires_at < NOW()`,
+	)
+	return err
+}
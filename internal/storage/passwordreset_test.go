@@ -0,0 +1,68 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordResets(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+	userID := uuid.New()
+
+	t.Run("create reset", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour)
+		mock.ExpectExec(`WITH superseded AS \( UPDATE password_resets SET used_at = NOW\(\) WHERE user_id = \$1 AND used_at IS NULL \) INSERT INTO password_resets \(token_hash, user_id, expires_at\)`).
+			WithArgs(userID, "hash", expiresAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, store.CreatePasswordReset(context.Background(), userID, "hash", expiresAt))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("create reset for deleted user", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO password_resets`).
+			WillReturnError(&pq.Error{Code: "23503"})
+
+		err := store.CreatePasswordReset(context.Background(), userID, "hash", time.Now())
+
+		assert.Equal(t, storage.ErrNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("reset password", func(t *testing.T) {
+		mock.ExpectQuery(`WITH reset AS \( UPDATE password_resets SET used_at = NOW\(\) WHERE token_hash = \$1 AND used_at IS NULL AND expires_at > NOW\(\) RETURNING user_id \) UPDATE users SET password_hash = \$2, password_reset_required = FALSE`).
+			WithArgs("hash", "new-password-hash").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
+
+		id, err := store.ResetPassword(context.Background(), "hash", "new-password-hash")
+
+		assert.NoError(t, err)
+		assert.Equal(t, userID, id)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("used or expired token", func(t *testing.T) {
+		mock.ExpectQuery(`WITH reset AS`).
+			WithArgs("hash", "new-password-hash").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := store.ResetPassword(context.Background(), "hash", "new-password-hash")
+
+		assert.Equal(t, storage.ErrInvalidResetToken, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
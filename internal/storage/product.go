@@ -9,21 +9,29 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
+// ProductTypes are the types of products a PVZ accepts.
+var ProductTypes = []string{"электроника", "одежда", "обувь"}
+
 type Product struct {
 	ID          uuid.UUID `json:"id"`
 	CreatedAt   time.Time `json:"createdAt"`
 	Type        string    `json:"type"`
 	ReceptionID uuid.UUID `json:"receptionId"`
+	// CreatedBy and DeletedBy are nil for dummy tokens and API keys
+	CreatedBy *uuid.UUID `json:"createdBy,omitempty"`
+	// DeletedAt is only set on products listed with IncludeDeleted
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	DeletedBy *uuid.UUID `json:"deletedBy,omitempty"`
 }
 
-func (s *PostgresStorage) AddProduct(ctx context.Context, receptionID uuid.UUID, productType string) (Product, error) {
+func (s *PostgresStorage) AddProduct(ctx context.Context, receptionID uuid.UUID, productType string, createdBy *uuid.UUID) (Product, error) {
 	var product Product
 	err := s.db.QueryRowContext(ctx,
-		`INSERT INTO products (type, reception_id)
-		VALUES ($1, $2)
-		RETURNING id, created_at, type, reception_id`,
-		productType, receptionID,
-	).Scan(&product.ID, &product.CreatedAt, &product.Type, &product.ReceptionID)
+		`INSERT INTO products (type, reception_id, created_by)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, type, reception_id, created_by`,
+		productType, receptionID, createdBy,
+	).Scan(&product.ID, &product.CreatedAt, &product.Type, &product.ReceptionID, &product.CreatedBy)
 
 	return product, err
 }
@@ -31,13 +39,13 @@ func (s *PostgresStorage) AddProduct(ctx context.Context, receptionID uuid.UUID,
 func (s *PostgresStorage) GetLastProduct(ctx context.Context, receptionID uuid.UUID) (Product, error) {
 	var product Product
 	err := s.db.QueryRowContext(ctx,
-		`SELECT id, created_at, type, reception_id 
+		`SELECT id, created_at, type, reception_id, created_by
 		FROM products 
-		WHERE reception_id = $1 
+		WHERE reception_id = $1 AND deleted_at IS NULL
 		ORDER BY created_at DESC 
 		LIMIT 1`,
 		receptionID,
-	).Scan(&product.ID, &product.CreatedAt, &product.Type, &product.ReceptionID)
+	).Scan(&product.ID, &product.CreatedAt, &product.Type, &product.ReceptionID, &product.CreatedBy)
 
 	if errors.Is(err, pgx.ErrNoRows) {
 		return Product{}, ErrNotFound
@@ -45,11 +53,14 @@ func (s *PostgresStorage) GetLastProduct(ctx context.Context, receptionID uuid.U
 	return product, err
 }
 
-func (s *PostgresStorage) DeleteProduct(ctx context.Context, productID uuid.UUID) error {
+// DeleteProduct marks the product as deleted. It stays in the table so
+// the deletion can be attributed.
+func (s *PostgresStorage) DeleteProduct(ctx context.Context, productID uuid.UUID, deletedBy *uuid.UUID) error {
 	_, err := s.db.ExecContext(ctx,
-		`DELETE FROM products 
-		WHERE id = $1`,
-		productID,
+		`UPDATE products
+		SET deleted_at = NOW(), deleted_by = $2
+		WHERE id = $1 AND deleted_at IS NULL`,
+		productID, deletedBy,
 	)
 	return err
 }
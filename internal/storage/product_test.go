@@ -26,16 +26,18 @@ func TestAddProduct(t *testing.T) {
 		receptionID := uuid.New()
 		productID := uuid.New()
 		productType := "electronics"
+		employeeID := uuid.New()
 		now := time.Now()
 
-		mock.ExpectQuery(`INSERT INTO products \(type, reception_id\) VALUES \(\$1, \$2\) RETURNING id, created_at, type, reception_id`).
-			WithArgs(productType, receptionID).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "type", "reception_id"}).
-				AddRow(productID, now, productType, receptionID))
+		mock.ExpectQuery(`INSERT INTO products \(type, reception_id, created_by\) VALUES \(\$1, \$2, \$3\) RETURNING id, created_at, type, reception_id, created_by`).
+			WithArgs(productType, receptionID, &employeeID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "type", "reception_id", "created_by"}).
+				AddRow(productID, now, productType, receptionID, employeeID))
 
-		product, err := store.AddProduct(context.Background(), receptionID, productType)
+		product, err := store.AddProduct(context.Background(), receptionID, productType, &employeeID)
 
 		assert.NoError(t, err)
+		assert.Equal(t, &employeeID, product.CreatedBy)
 		assert.Equal(t, productID, product.ID)
 		assert.Equal(t, productType, product.Type)
 		assert.Equal(t, receptionID, product.ReceptionID)
@@ -47,11 +49,11 @@ func TestAddProduct(t *testing.T) {
 		receptionID := uuid.New()
 		productType := "electronics"
 
-		mock.ExpectQuery(`INSERT INTO products \(type, reception_id\) VALUES \(\$1, \$2\) RETURNING id, created_at, type, reception_id`).
-			WithArgs(productType, receptionID).
+		mock.ExpectQuery(`INSERT INTO products \(type, reception_id, created_by\)`).
+			WithArgs(productType, receptionID, nil).
 			WillReturnError(sql.ErrConnDone)
 
-		product, err := store.AddProduct(context.Background(), receptionID, productType)
+		product, err := store.AddProduct(context.Background(), receptionID, productType, nil)
 
 		assert.Error(t, err)
 		assert.Empty(t, product)
@@ -74,10 +76,10 @@ func TestGetLastProduct(t *testing.T) {
 		productType := "electronics"
 		now := time.Now()
 
-		mock.ExpectQuery(`SELECT id, created_at, type, reception_id FROM products WHERE reception_id = \$1 ORDER BY created_at DESC LIMIT 1`).
+		mock.ExpectQuery(`SELECT id, created_at, type, reception_id, created_by FROM products WHERE reception_id = \$1 AND deleted_at IS NULL ORDER BY created_at DESC LIMIT 1`).
 			WithArgs(receptionID).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "type", "reception_id"}).
-				AddRow(productID, now, productType, receptionID))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "type", "reception_id", "created_by"}).
+				AddRow(productID, now, productType, receptionID, nil))
 
 		product, err := store.GetLastProduct(context.Background(), receptionID)
 
@@ -92,7 +94,7 @@ func TestGetLastProduct(t *testing.T) {
 	t.Run("not found", func(t *testing.T) {
 		receptionID := uuid.New()
 
-		mock.ExpectQuery(`SELECT id, created_at, type, reception_id FROM products WHERE reception_id = \$1 ORDER BY created_at DESC LIMIT 1`).
+		mock.ExpectQuery(`SELECT id, created_at, type, reception_id, created_by FROM products WHERE reception_id = \$1 AND deleted_at IS NULL ORDER BY created_at DESC LIMIT 1`).
 			WithArgs(receptionID).
 			WillReturnError(pgx.ErrNoRows)
 
@@ -107,7 +109,7 @@ func TestGetLastProduct(t *testing.T) {
 	t.Run("database error", func(t *testing.T) {
 		receptionID := uuid.New()
 
-		mock.ExpectQuery(`SELECT id, created_at, type, reception_id FROM products WHERE reception_id = \$1 ORDER BY created_at DESC LIMIT 1`).
+		mock.ExpectQuery(`SELECT id, created_at, type, reception_id, created_by FROM products WHERE reception_id = \$1 AND deleted_at IS NULL ORDER BY created_at DESC LIMIT 1`).
 			WithArgs(receptionID).
 			WillReturnError(sql.ErrConnDone)
 
@@ -131,11 +133,13 @@ func TestDeleteProduct(t *testing.T) {
 	t.Run("success delete product", func(t *testing.T) {
 		productID := uuid.New()
 
-		mock.ExpectExec(`DELETE FROM products WHERE id = \$1`).
-			WithArgs(productID).
+		employeeID := uuid.New()
+
+		mock.ExpectExec(`UPDATE products SET deleted_at = NOW\(\), deleted_by = \$2 WHERE id = \$1 AND deleted_at IS NULL`).
+			WithArgs(productID, &employeeID).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 
-		err := store.DeleteProduct(context.Background(), productID)
+		err := store.DeleteProduct(context.Background(), productID, &employeeID)
 
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -144,11 +148,11 @@ func TestDeleteProduct(t *testing.T) {
 	t.Run("database error", func(t *testing.T) {
 		productID := uuid.New()
 
-		mock.ExpectExec(`DELETE FROM products WHERE id = \$1`).
-			WithArgs(productID).
+		mock.ExpectExec(`UPDATE products SET deleted_at = NOW\(\), deleted_by = \$2`).
+			WithArgs(productID, nil).
 			WillReturnError(sql.ErrConnDone)
 
-		err := store.DeleteProduct(context.Background(), productID)
+		err := store.DeleteProduct(context.Background(), productID, nil)
 
 		assert.Error(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -2,21 +2,176 @@ package storage
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
+	"slices"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 var (
-	ErrInvalidCity = errors.New("city must be Москва, Санкт-Петербург or Казань")
+	ErrInvalidCity   = errors.New("city is not in the list of active cities")
+	ErrInvalidPVZ    = errors.New("invalid pvz")
+	ErrPVZCodeExists = errors.New("pvz code already exists")
 )
 
+// DefaultTimezone is used for PVZs created without a timezone.
+const DefaultTimezone = "Europe/Moscow"
+
+// Weekdays are the keys of WorkingHours, Monday first.
+var Weekdays = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+// DayHours is when a PVZ is open on a day, as "HH:MM" in its timezone.
+type DayHours struct {
+	Open  string `json:"open"`
+	Close string `json:"close"`
+}
+
+// validate checks that both times are HH:MM and the PVZ opens before it
+// closes.
+func (h DayHours) validate() error {
+	open, err := time.Parse("15:04", h.Open)
+	if err != nil {
+		return errors.New("open must be HH:MM")
+	}
+	closing, err := time.Parse("15:04", h.Close)
+	if err != nil {
+		return errors.New("close must be HH:MM")
+	}
+	if !open.Before(closing) {
+		return errors.New("open must be before close")
+	}
+	return nil
+}
+
+// WorkingHours maps weekdays to opening hours. Missing days are days off,
+// but a PVZ with no working hours at all is open around the clock.
+type WorkingHours map[string]DayHours
+
+func (h WorkingHours) Value() (driver.Value, error) {
+	if h == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(h)
+}
+
+func (h *WorkingHours) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, h)
+	case string:
+		return json.Unmarshal([]byte(v), h)
+	case nil:
+		*h = nil
+		return nil
+	}
+	return fmt.Errorf("unsupported working hours type %T", src)
+}
+
 type PVZ struct {
-	ID               uuid.UUID `json:"id"`
-	RegistrationDate time.Time `json:"registrationDate"`
-	City             string    `json:"city"`
+	ID               uuid.UUID    `json:"id"`
+	RegistrationDate time.Time    `json:"registrationDate"`
+	City             string       `json:"city"`
+	Code             *string      `json:"code,omitempty"`
+	Address          string       `json:"address,omitempty"`
+	Latitude         *float64     `json:"latitude,omitempty"`
+	Longitude        *float64     `json:"longitude,omitempty"`
+	Timezone         string       `json:"timezone"`
+	Phone            string       `json:"phone,omitempty"`
+	WorkingHours     WorkingHours `json:"workingHours"`
+	Status           string       `json:"status"`
+	StatusReason     string       `json:"statusReason,omitempty"`
+	// StatusSince is when the current status took effect, nil for a PVZ
+	// that has been active since registration
+	StatusSince *time.Time `json:"statusSince,omitempty"`
+	// IsOpenNow is whether the PVZ is active and open at the time of the
+	// query, see ScheduleException and ScheduleOverride
+	IsOpenNow bool `json:"isOpenNow"`
+}
+
+var (
+	pvzCodePattern = regexp.MustCompile(`^[A-Za-z0-9-]{1,20}$`)
+	// E.164
+	phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+)
+
+const maxAddressLength = 255
+
+// Validate checks the profile fields of the PVZ. The city is checked
+// against the directory by CreatePVZ.
+func (p PVZ) Validate() error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: "+format, append([]any{ErrInvalidPVZ}, args...)...)
+	}
+
+	if p.Code != nil && !pvzCodePattern.MatchString(*p.Code) {
+		return invalid("code must be up to 20 letters, digits or dashes")
+	}
+	if utf8.RuneCountInString(p.Address) > maxAddressLength {
+		return invalid("address is longer than %d characters", maxAddressLength)
+	}
+	if (p.Latitude == nil) != (p.Longitude == nil) {
+		return invalid("latitude and longitude must be set together")
+	}
+	if p.Latitude != nil && (*p.Latitude < -90 || *p.Latitude > 90) {
+		return invalid("latitude must be between -90 and 90")
+	}
+	if p.Longitude != nil && (*p.Longitude < -180 || *p.Longitude > 180) {
+		return invalid("longitude must be between -180 and 180")
+	}
+	if p.Timezone != "" {
+		if _, err := time.LoadLocation(p.Timezone); err != nil || p.Timezone == "Local" {
+			return invalid("unknown timezone %q", p.Timezone)
+		}
+	}
+	if p.Phone != "" && !phonePattern.MatchString(p.Phone) {
+		return invalid("phone must be in international format, e.g. +74951234567")
+	}
+	for day, hours := range p.WorkingHours {
+		if !slices.Contains(Weekdays, day) {
+			return invalid("unknown weekday %q", day)
+		}
+		if err := hours.validate(); err != nil {
+			return invalid("%s: %v", day, err)
+		}
+	}
+	return nil
+}
+
+// PVZFilter selects a page of PVZs for GetPVZsWithReceptions.
+// Receptions are filtered by StartDate/EndDate, a zero date means no
+// bound. A nil PVZIDs means no restriction, an empty one matches nothing.
+type PVZFilter struct {
+	StartDate time.Time
+	EndDate   time.Time
+	Page      int
+	Limit     int
+	PVZIDs    []uuid.UUID
+	// Status limits the list to PVZs with this current status, empty
+	// means any
+	Status string
+	// City limits the list to PVZs of the city, empty means any
+	City string
+	// CreatedBy limits products to the ones the user added and receptions
+	// to the ones they opened or added listed products to
+	CreatedBy *uuid.UUID
+	// ReceptionStatus limits receptions to the ones in this status
+	ReceptionStatus string
+	// ProductType limits products to this type and receptions to the ones
+	// with such products
+	ProductType string
+	// IncludeDeleted also lists deleted products
+	IncludeDeleted bool
+	// WithReceptions lists only the PVZs that have receptions matching
+	// the filter, the page is taken after filtering
+	WithReceptions bool
 }
 
 type PVZWithReceptions struct {
@@ -29,35 +184,126 @@ type ReceptionWithProducts struct {
 	Products  []Product
 }
 
-func (s *PostgresStorage) CreatePVZ(ctx context.Context, city string) (PVZ, error) {
-	validCities := map[string]bool{
-		"Москва":          true,
-		"Санкт-Петербург": true,
-		"Казань":          true,
+// pvzColumns are selected from pvzFrom, the status columns come from the
+// pvz_current_status view. Whether the PVZ is open is computed by the
+// pvz_is_open function from its schedule, exceptions and overrides.
+const (
+	pvzColumns = `id, registration_date, city, code, address, latitude, longitude, timezone, phone, working_hours,
+		COALESCE(status, 'active'), COALESCE(reason, ''), effective_from,
+		COALESCE(status, 'active') = 'active' AND pvz_is_open(id, timezone, working_hours, NOW())`
+	pvzFrom = `pvz LEFT JOIN pvz_current_status ON pvz_current_status.pvz_id = pvz.id`
+)
+
+// scanPVZ scans pvzColumns followed by the extra columns of the query.
+func scanPVZ(row rowScanner, extra ...any) (PVZ, error) {
+	var pvz PVZ
+	dest := []any{&pvz.ID, &pvz.RegistrationDate, &pvz.City, &pvz.Code, &pvz.Address,
+		&pvz.Latitude, &pvz.Longitude, &pvz.Timezone, &pvz.Phone, &pvz.WorkingHours,
+		&pvz.Status, &pvz.StatusReason, &pvz.StatusSince, &pvz.IsOpenNow}
+	err := row.Scan(append(dest, extra...)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return PVZ{}, ErrNotFound
 	}
-	if !validCities[city] {
+	return pvz, err
+}
+
+// CreatePVZ creates a PVZ in an active city of the directory, otherwise
+// it returns ErrInvalidCity. ID and RegistrationDate are assigned by the
+// database.
+func (s *PostgresStorage) CreatePVZ(ctx context.Context, pvz PVZ) (PVZ, error) {
+	return insertPVZ(ctx, s.db, pvz)
+}
+
+// queryRower is implemented by both *sql.DB and *sql.Tx.
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func insertPVZ(ctx context.Context, q queryRower, pvz PVZ) (PVZ, error) {
+	if pvz.Timezone == "" {
+		pvz.Timezone = DefaultTimezone
+	}
+
+	// The CTE shadows the pvz table, so pvzFrom reads the inserted row
+	created, err := scanPVZ(q.QueryRowContext(ctx,
+		`WITH pvz AS (
+			INSERT INTO pvz (city, code, address, latitude, longitude, timezone, phone, working_hours)
+			SELECT name, $2, $3, $4, $5, $6, $7, $8 FROM cities WHERE name = $1 AND active
+			RETURNING *
+		)
+		SELECT `+pvzColumns+` FROM `+pvzFrom,
+		pvz.City, pvz.Code, pvz.Address, pvz.Latitude, pvz.Longitude, pvz.Timezone, pvz.Phone, pvz.WorkingHours,
+	))
+	switch {
+	case errors.Is(err, ErrNotFound):
 		return PVZ{}, ErrInvalidCity
+	case isUniqueViolation(err):
+		return PVZ{}, ErrPVZCodeExists
 	}
+	return created, err
+}
 
-	var pvz PVZ
-	err := s.db.QueryRowContext(ctx,
-		`INSERT INTO pvz (city) 
-		VALUES ($1)
-		RETURNING id, registration_date, city`,
-		city,
-	).Scan(&pvz.ID, &pvz.RegistrationDate, &pvz.City)
+func (s *PostgresStorage) GetPVZ(ctx context.Context, id uuid.UUID) (PVZ, error) {
+	return scanPVZ(s.db.QueryRowContext(ctx,
+		`SELECT `+pvzColumns+` FROM `+pvzFrom+` WHERE id = $1`,
+		id,
+	))
+}
 
-	return pvz, err
+// UpdatePVZ replaces the profile of the PVZ. The city and registration
+// date can't be changed.
+func (s *PostgresStorage) UpdatePVZ(ctx context.Context, pvz PVZ) (PVZ, error) {
+	// Same as in CreatePVZ, pvzFrom reads the updated row
+	updated, err := scanPVZ(s.db.QueryRowContext(ctx,
+		`WITH pvz AS (
+			UPDATE pvz
+			SET code = $2, address = $3, latitude = $4, longitude = $5, timezone = $6, phone = $7, working_hours = $8
+			WHERE id = $1
+			RETURNING *
+		)
+		SELECT `+pvzColumns+` FROM `+pvzFrom,
+		pvz.ID, pvz.Code, pvz.Address, pvz.Latitude, pvz.Longitude, pvz.Timezone, pvz.Phone, pvz.WorkingHours,
+	))
+	if isUniqueViolation(err) {
+		return PVZ{}, ErrPVZCodeExists
+	}
+	return updated, err
+}
+
+// receptionMatches is the reception filter of PVZFilter applied to
+// receptions r. It takes the parameters $2 to $7 from receptionArgs.
+const receptionMatches = `($2::timestamp IS NULL OR r.created_at >= $2)
+	AND ($3::timestamp IS NULL OR r.created_at <= $3)
+	AND ($4::uuid IS NULL OR r.created_by = $4 OR EXISTS (
+		SELECT 1 FROM products p WHERE p.reception_id = r.id AND p.created_by = $4 AND ($7::boolean OR p.deleted_at IS NULL)
+	))
+	AND ($5 = '' OR r.status = $5)
+	AND ($6 = '' OR EXISTS (
+		SELECT 1 FROM products p WHERE p.reception_id = r.id AND p.type = $6 AND ($7::boolean OR p.deleted_at IS NULL)
+	))`
+
+func receptionArgs(filter PVZFilter) []any {
+	return []any{nullTime(filter.StartDate), nullTime(filter.EndDate), filter.CreatedBy,
+		filter.ReceptionStatus, filter.ProductType, filter.IncludeDeleted}
 }
 
-func (s *PostgresStorage) GetPVZsWithReceptions(ctx context.Context, startDate, endDate time.Time, page, limit int) ([]PVZWithReceptions, error) {
-	// Get PVZs with pagination
+func (s *PostgresStorage) GetPVZsWithReceptions(ctx context.Context, filter PVZFilter) ([]PVZWithReceptions, error) {
+	// Get PVZs with pagination. With WithReceptions the PVZs are filtered
+	// before LIMIT, so pages aren't padded with PVZs without receptions
+	args := append([]any{filter.Limit}, receptionArgs(filter)...)
+	args = append(args, (filter.Page-1)*filter.Limit, uuidArray(filter.PVZIDs), filter.Status, filter.City, filter.WithReceptions)
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT id, registration_date, city 
-		FROM pvz 
+		`SELECT `+pvzColumns+`
+		FROM `+pvzFrom+`
+		WHERE ($9::uuid[] IS NULL OR id = ANY($9::uuid[]))
+		AND ($10 = '' OR COALESCE(status, 'active') = $10)
+		AND ($11 = '' OR city = $11)
+		AND (NOT $12::boolean OR EXISTS (
+			SELECT 1 FROM receptions r WHERE r.pvz_id = pvz.id AND `+receptionMatches+`
+		))
 		ORDER BY registration_date DESC
-		LIMIT $1 OFFSET $2`,
-		limit, (page-1)*limit,
+		LIMIT $1 OFFSET $8`,
+		args...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pvzs: %w", err)
@@ -66,8 +312,8 @@ func (s *PostgresStorage) GetPVZsWithReceptions(ctx context.Context, startDate,
 
 	var pvzs []PVZ
 	for rows.Next() {
-		var pvz PVZ
-		if err := rows.Scan(&pvz.ID, &pvz.RegistrationDate, &pvz.City); err != nil {
+		pvz, err := scanPVZ(rows)
+		if err != nil {
 			return nil, err
 		}
 		pvzs = append(pvzs, pvz)
@@ -77,7 +323,7 @@ func (s *PostgresStorage) GetPVZsWithReceptions(ctx context.Context, startDate,
 	result := make([]PVZWithReceptions, 0, len(pvzs))
 	for _, pvz := range pvzs {
 		// Get receptions with date filter
-		receptions, err := s.getReceptionsForPVZ(ctx, pvz.ID, startDate, endDate)
+		receptions, err := s.getReceptionsForPVZ(ctx, pvz.ID, filter)
 		if err != nil {
 			return nil, err
 		}
@@ -90,16 +336,15 @@ func (s *PostgresStorage) GetPVZsWithReceptions(ctx context.Context, startDate,
 	return result, nil
 }
 
-func (s *PostgresStorage) getReceptionsForPVZ(ctx context.Context, pvzID uuid.UUID, startDate, endDate time.Time) ([]ReceptionWithProducts, error) {
-	// Get receptions with date filter
+func (s *PostgresStorage) getReceptionsForPVZ(ctx context.Context, pvzID uuid.UUID, filter PVZFilter) ([]ReceptionWithProducts, error) {
+	// Get receptions with date, employee, status and product type filters
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT r.id, r.created_at, r.pvz_id, r.status 
+		`SELECT r.id, r.created_at, r.pvz_id, r.status, r.created_by, r.closed_by
 		FROM receptions r
-		WHERE r.pvz_id = $1 
-		AND ($2::timestamp IS NULL OR r.created_at >= $2)
-		AND ($3::timestamp IS NULL OR r.created_at <= $3)
+		WHERE r.pvz_id = $1
+		AND `+receptionMatches+`
 		ORDER BY r.created_at DESC`,
-		pvzID, startDate, endDate,
+		append([]any{pvzID}, receptionArgs(filter)...)...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get receptions: %w", err)
@@ -109,12 +354,12 @@ func (s *PostgresStorage) getReceptionsForPVZ(ctx context.Context, pvzID uuid.UU
 	var receptions []ReceptionWithProducts
 	for rows.Next() {
 		var r Reception
-		if err := rows.Scan(&r.ID, &r.CreatedAt, &r.PVZID, &r.Status); err != nil {
+		if err := rows.Scan(&r.ID, &r.CreatedAt, &r.PVZID, &r.Status, &r.CreatedBy, &r.ClosedBy); err != nil {
 			return nil, err
 		}
 
 		// Get products for reception
-		products, err := s.getProductsForReception(ctx, r.ID)
+		products, err := s.getProductsForReception(ctx, r.ID, filter)
 		if err != nil {
 			return nil, err
 		}
@@ -128,13 +373,16 @@ func (s *PostgresStorage) getReceptionsForPVZ(ctx context.Context, pvzID uuid.UU
 	return receptions, nil
 }
 
-func (s *PostgresStorage) getProductsForReception(ctx context.Context, receptionID uuid.UUID) ([]Product, error) {
+func (s *PostgresStorage) getProductsForReception(ctx context.Context, receptionID uuid.UUID, filter PVZFilter) ([]Product, error) {
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT id, created_at, type, reception_id 
+		`SELECT id, created_at, type, reception_id, created_by, deleted_at, deleted_by
 		FROM products 
 		WHERE reception_id = $1
+		AND ($2::uuid IS NULL OR created_by = $2)
+		AND ($3::boolean OR deleted_at IS NULL)
+		AND ($4 = '' OR type = $4)
 		ORDER BY created_at DESC`,
-		receptionID,
+		receptionID, filter.CreatedBy, filter.IncludeDeleted, filter.ProductType,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get products: %w", err)
@@ -144,7 +392,7 @@ func (s *PostgresStorage) getProductsForReception(ctx context.Context, reception
 	var products []Product
 	for rows.Next() {
 		var p Product
-		if err := rows.Scan(&p.ID, &p.CreatedAt, &p.Type, &p.ReceptionID); err != nil {
+		if err := rows.Scan(&p.ID, &p.CreatedAt, &p.Type, &p.ReceptionID, &p.CreatedBy, &p.DeletedAt, &p.DeletedBy); err != nil {
 			return nil, err
 		}
 		products = append(products, p)
@@ -152,3 +400,24 @@ func (s *PostgresStorage) getProductsForReception(ctx context.Context, reception
 
 	return products, nil
 }
+
+// uuidArray passes ids as a uuid[] parameter. A nil slice stays NULL, so
+// queries can tell no restriction from an empty list.
+func uuidArray(ids []uuid.UUID) pq.StringArray {
+	if ids == nil {
+		return nil
+	}
+	arr := make(pq.StringArray, 0, len(ids))
+	for _, id := range ids {
+		arr = append(arr, id.String())
+	}
+	return arr
+}
+
+// nullTime passes a zero time as NULL, for optional bounds.
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
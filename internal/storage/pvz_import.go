@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ImportRow is a PVZ read from an import file. Err is set when the row
+// couldn't be read and is reported without touching the database.
+type ImportRow struct {
+	PVZ PVZ
+	Err error
+}
+
+type ImportOptions struct {
+	// DryRun checks every row, database constraints included, and rolls
+	// the import back
+	DryRun bool
+	// Partial keeps the rows that could be imported when others fail.
+	// Otherwise a single failed row rolls back the whole import
+	Partial bool
+}
+
+// ImportResult is the outcome of a row, numbered from 1. PVZ is the
+// created PVZ, it's only stored if the import was committed.
+type ImportResult struct {
+	Row   int    `json:"row"`
+	PVZ   *PVZ   `json:"pvz,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type ImportReport struct {
+	Imported  int            `json:"imported"`
+	Failed    int            `json:"failed"`
+	Committed bool           `json:"committed"`
+	Rows      []ImportResult `json:"rows"`
+}
+
+// ImportPVZs creates the PVZs of the rows in one transaction. Each row is
+// validated and inserted under a savepoint, so a failed row doesn't stop
+// the others from being checked. Rows that fail validation, name a city
+// that isn't active in the directory or repeat a code are reported in
+// the ImportReport; other errors abort the import.
+func (s *PostgresStorage) ImportPVZs(ctx context.Context, rows []ImportRow, opts ImportOptions) (ImportReport, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	defer tx.Rollback()
+
+	report := ImportReport{Rows: make([]ImportResult, 0, len(rows))}
+	for i, row := range rows {
+		result := ImportResult{Row: i + 1}
+		err := row.Err
+		if err == nil {
+			err = row.PVZ.Validate()
+		}
+		if err == nil {
+			var created PVZ
+			created, err = importPVZ(ctx, tx, row.PVZ)
+			if err == nil {
+				result.PVZ = &created
+			}
+		}
+
+		switch {
+		case err == nil:
+			report.Imported++
+		case row.Err != nil || errors.Is(err, ErrInvalidPVZ) || errors.Is(err, ErrInvalidCity) || errors.Is(err, ErrPVZCodeExists):
+			result.Error = err.Error()
+			report.Failed++
+		default:
+			return ImportReport{}, fmt.Errorf("failed to import row %d: %w", result.Row, err)
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	if opts.DryRun || (report.Failed > 0 && !opts.Partial) {
+		return report, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return ImportReport{}, err
+	}
+	report.Committed = true
+	return report, nil
+}
+
+// importPVZ inserts the PVZ under a savepoint and rolls back to it on
+// failure, since a failed statement aborts the whole transaction.
+func importPVZ(ctx context.Context, tx *sql.Tx, pvz PVZ) (PVZ, error) {
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT pvz_import`); err != nil {
+		return PVZ{}, err
+	}
+	created, err := insertPVZ(ctx, tx, pvz)
+	if err != nil {
+		if _, rbErr := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT pvz_import`); rbErr != nil {
+			return PVZ{}, rbErr
+		}
+		return PVZ{}, err
+	}
+	_, err = tx.ExecContext(ctx, `RELEASE SAVEPOINT pvz_import`)
+	return created, err
+}
@@ -0,0 +1,108 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportPVZs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+	now := time.Now()
+	code := "KZN-7"
+	badCode := "KZN 8"
+
+	rows := []storage.ImportRow{
+		{PVZ: storage.PVZ{City: "Казань", Code: &code}},
+		{PVZ: storage.PVZ{City: "Казань", Code: &badCode}},
+		{Err: errors.New("invalid pvz: latitude must be a number")},
+		{PVZ: storage.PVZ{City: "Казань", Code: &code}},
+		{PVZ: storage.PVZ{City: "Урюпинск"}},
+	}
+
+	// Rows 2 and 3 never reach the database
+	expectRows := func() {
+		mock.ExpectBegin()
+		mock.ExpectExec(`SAVEPOINT pvz_import`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`INSERT INTO pvz`).
+			WithArgs("Казань", &code, "", nil, nil, storage.DefaultTimezone, "", storage.WorkingHours(nil)).
+			WillReturnRows(pvzRow(uuid.New(), now, "Казань"))
+		mock.ExpectExec(`RELEASE SAVEPOINT pvz_import`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SAVEPOINT pvz_import`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`INSERT INTO pvz`).WillReturnError(&pq.Error{Code: "23505"})
+		mock.ExpectExec(`ROLLBACK TO SAVEPOINT pvz_import`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SAVEPOINT pvz_import`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`INSERT INTO pvz`).WillReturnRows(sqlmock.NewRows(pvzColumns))
+		mock.ExpectExec(`ROLLBACK TO SAVEPOINT pvz_import`).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	t.Run("failed rows roll back the import", func(t *testing.T) {
+		expectRows()
+		mock.ExpectRollback()
+
+		report, err := store.ImportPVZs(context.Background(), rows, storage.ImportOptions{})
+
+		require.NoError(t, err)
+		assert.False(t, report.Committed)
+		assert.Equal(t, 1, report.Imported)
+		assert.Equal(t, 4, report.Failed)
+		require.Len(t, report.Rows, 5)
+		assert.NotNil(t, report.Rows[0].PVZ)
+		assert.Contains(t, report.Rows[1].Error, "code")
+		assert.Contains(t, report.Rows[2].Error, "latitude")
+		assert.Equal(t, storage.ErrPVZCodeExists.Error(), report.Rows[3].Error)
+		assert.Equal(t, storage.ErrInvalidCity.Error(), report.Rows[4].Error)
+		assert.Equal(t, 5, report.Rows[4].Row)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("partial import keeps valid rows", func(t *testing.T) {
+		expectRows()
+		mock.ExpectCommit()
+
+		report, err := store.ImportPVZs(context.Background(), rows, storage.ImportOptions{Partial: true})
+
+		require.NoError(t, err)
+		assert.True(t, report.Committed)
+		assert.Equal(t, 1, report.Imported)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("dry run stores nothing", func(t *testing.T) {
+		expectRows()
+		mock.ExpectRollback()
+
+		report, err := store.ImportPVZs(context.Background(), rows, storage.ImportOptions{DryRun: true, Partial: true})
+
+		require.NoError(t, err)
+		assert.False(t, report.Committed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("database error aborts the import", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`SAVEPOINT pvz_import`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`INSERT INTO pvz`).WillReturnError(errors.New("connection reset"))
+		mock.ExpectExec(`ROLLBACK TO SAVEPOINT pvz_import`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		_, err := store.ImportPVZs(context.Background(), rows[:1], storage.ImportOptions{Partial: true})
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
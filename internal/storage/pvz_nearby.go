@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// Limits of FindNearbyPVZs, distances are in meters.
+const (
+	DefaultNearbyRadius = 10_000
+	MaxNearbyRadius     = 500_000
+	DefaultNearbyLimit  = 10
+	MaxNearbyLimit      = 50
+)
+
+// metersPerDegree is the length of a degree of latitude on the mean
+// Earth sphere used for distances.
+const metersPerDegree = 111_195
+
+var ErrInvalidNearbyFilter = errors.New("invalid nearby search")
+
+// NearbyFilter selects PVZs within Radius meters of a point. PVZs without
+// coordinates are never found. A nil PVZIDs means no restriction, an
+// empty one matches nothing.
+type NearbyFilter struct {
+	Latitude  float64
+	Longitude float64
+	Radius    float64
+	Limit     int
+	City      string
+	Status    string
+	PVZIDs    []uuid.UUID
+}
+
+// Validate checks the point, radius, limit and status of the search.
+// The ranges are written so that NaN fails them too.
+func (f NearbyFilter) Validate() error {
+	switch {
+	case !(f.Latitude >= -90 && f.Latitude <= 90):
+		return fmt.Errorf("%w: latitude must be between -90 and 90", ErrInvalidNearbyFilter)
+	case !(f.Longitude >= -180 && f.Longitude <= 180):
+		return fmt.Errorf("%w: longitude must be between -180 and 180", ErrInvalidNearbyFilter)
+	case !(f.Radius > 0 && f.Radius <= MaxNearbyRadius):
+		return fmt.Errorf("%w: radius must be between 0 and %d meters", ErrInvalidNearbyFilter, MaxNearbyRadius)
+	case f.Limit < 1 || f.Limit > MaxNearbyLimit:
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidNearbyFilter, MaxNearbyLimit)
+	case f.Status != "" && !slices.Contains(PVZStatuses, f.Status):
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidNearbyFilter, f.Status)
+	}
+	return nil
+}
+
+// NearbyPVZ is a PVZ with its great-circle distance to the searched point
+// in meters.
+type NearbyPVZ struct {
+	PVZ
+	Distance float64 `json:"distance"`
+}
+
+// FindNearbyPVZs returns the PVZs closest to the point, nearest first.
+func (s *PostgresStorage) FindNearbyPVZs(ctx context.Context, filter NearbyFilter) ([]NearbyPVZ, error) {
+	// Haversine formula. The latitude band cuts off PVZs that are
+	// certainly too far before the distance is computed; LEAST guards
+	// ASIN against rounding just above 1
+	rows, err := s.db.QueryContext(ctx,
+		`WITH pvz AS (
+			SELECT pvz.*, 2 * 6371008.8 * ASIN(LEAST(1, SQRT(
+				POWER(SIN(RADIANS(latitude - $1) / 2), 2) +
+				COS(RADIANS($1)) * COS(RADIANS(latitude)) * POWER(SIN(RADIANS(longitude - $2) / 2), 2)
+			))) AS distance
+			FROM pvz
+			WHERE latitude BETWEEN $1 - $4 AND $1 + $4
+		)
+		SELECT `+pvzColumns+`, distance
+		FROM `+pvzFrom+`
+		WHERE distance <= $3
+		AND ($5 = '' OR city = $5)
+		AND ($6 = '' OR COALESCE(status, 'active') = $6)
+		AND ($7::uuid[] IS NULL OR id = ANY($7::uuid[]))
+		ORDER BY distance
+		LIMIT $8`,
+		filter.Latitude, filter.Longitude, filter.Radius, filter.Radius/metersPerDegree,
+		filter.City, filter.Status, uuidArray(filter.PVZIDs), filter.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find nearby pvzs: %w", err)
+	}
+	defer rows.Close()
+
+	result := []NearbyPVZ{}
+	for rows.Next() {
+		var distance float64
+		pvz, err := scanPVZ(rows, &distance)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, NearbyPVZ{PVZ: pvz, Distance: distance})
+	}
+	return result, rows.Err()
+}
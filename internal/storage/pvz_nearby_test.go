@@ -0,0 +1,103 @@
+package storage_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNearbyFilterValidate(t *testing.T) {
+	valid := storage.NearbyFilter{Latitude: 55.75, Longitude: 37.61, Radius: 5000, Limit: 10}
+
+	tests := []struct {
+		name   string
+		modify func(f *storage.NearbyFilter)
+		valid  bool
+	}{
+		{"valid", func(f *storage.NearbyFilter) {}, true},
+		{"with status", func(f *storage.NearbyFilter) { f.Status = storage.PVZActive }, true},
+		{"latitude out of range", func(f *storage.NearbyFilter) { f.Latitude = 91 }, false},
+		{"latitude is NaN", func(f *storage.NearbyFilter) { f.Latitude = math.NaN() }, false},
+		{"longitude out of range", func(f *storage.NearbyFilter) { f.Longitude = -181 }, false},
+		{"zero radius", func(f *storage.NearbyFilter) { f.Radius = 0 }, false},
+		{"radius too large", func(f *storage.NearbyFilter) { f.Radius = storage.MaxNearbyRadius + 1 }, false},
+		{"limit too large", func(f *storage.NearbyFilter) { f.Limit = storage.MaxNearbyLimit + 1 }, false},
+		{"unknown status", func(f *storage.NearbyFilter) { f.Status = "paused" }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := valid
+			tt.modify(&f)
+			err := f.Validate()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, storage.ErrInvalidNearbyFilter)
+			}
+		})
+	}
+}
+
+func TestFindNearbyPVZs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+
+	t.Run("nearest first with filters", func(t *testing.T) {
+		near, far := uuid.New(), uuid.New()
+		assigned := []uuid.UUID{near, far}
+		now := time.Now()
+
+		mock.ExpectQuery(`WHERE latitude BETWEEN \$1 - \$4 AND \$1 \+ \$4 \) SELECT id, .* pvz_is_open\(id, timezone, working_hours, NOW\(\)\), distance FROM pvz LEFT JOIN pvz_current_status .* WHERE distance <= \$3 .* ORDER BY distance LIMIT \$8`).
+			WithArgs(55.75, 37.61, 11119.5, 0.1, "Москва", storage.PVZActive,
+				pq.StringArray{near.String(), far.String()}, 5).
+			WillReturnRows(sqlmock.NewRows(append(pvzColumns, "distance")).
+				AddRow(near, now, "Москва", nil, "", 55.76, 37.62, storage.DefaultTimezone, "", []byte("{}"),
+					storage.PVZActive, "", nil, true, 1300.2).
+				AddRow(far, now, "Москва", nil, "", 55.8, 37.7, storage.DefaultTimezone, "", []byte("{}"),
+					storage.PVZActive, "", nil, false, 8100.7))
+
+		pvzs, err := store.FindNearbyPVZs(context.Background(), storage.NearbyFilter{
+			Latitude:  55.75,
+			Longitude: 37.61,
+			Radius:    11119.5,
+			Limit:     5,
+			City:      "Москва",
+			Status:    storage.PVZActive,
+			PVZIDs:    assigned,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, pvzs, 2)
+		assert.Equal(t, near, pvzs[0].ID)
+		assert.Equal(t, 1300.2, pvzs[0].Distance)
+		assert.Equal(t, 55.8, *pvzs[1].Latitude)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("nothing in radius", func(t *testing.T) {
+		mock.ExpectQuery(`ORDER BY distance`).
+			WillReturnRows(sqlmock.NewRows(append(pvzColumns, "distance")))
+
+		pvzs, err := store.FindNearbyPVZs(context.Background(), storage.NearbyFilter{
+			Latitude: 43.1, Longitude: 131.9, Radius: 1000, Limit: 10,
+		})
+
+		require.NoError(t, err)
+		assert.Empty(t, pvzs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidScheduleException = errors.New("invalid schedule exception")
+
+const maxScheduleReasonLength = 255
+
+// ScheduleException replaces the working hours of a PVZ on a date in its
+// timezone. A holiday has no PVZID and applies to all PVZs; an exception
+// of the PVZ itself wins over a holiday on the same date.
+type ScheduleException struct {
+	PVZID *uuid.UUID `json:"pvzId,omitempty"`
+	// Date is YYYY-MM-DD
+	Date string `json:"date"`
+	// Hours are nil when the PVZ is closed all day
+	Hours  *DayHours `json:"hours"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+func (e ScheduleException) Validate() error {
+	if _, err := time.Parse(time.DateOnly, e.Date); err != nil {
+		return fmt.Errorf("%w: date must be YYYY-MM-DD", ErrInvalidScheduleException)
+	}
+	if e.Hours != nil {
+		if err := e.Hours.validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidScheduleException, err)
+		}
+	}
+	if utf8.RuneCountInString(e.Reason) > maxScheduleReasonLength {
+		return fmt.Errorf("%w: reason is longer than %d characters", ErrInvalidScheduleException, maxScheduleReasonLength)
+	}
+	return nil
+}
+
+// ScheduleExceptionFilter selects exceptions between From and To
+// inclusive, both YYYY-MM-DD or empty for no bound. With PVZID the
+// exceptions of the PVZ are listed along with holidays, without it only
+// holidays.
+type ScheduleExceptionFilter struct {
+	PVZID *uuid.UUID
+	From  string
+	To    string
+}
+
+// ScheduleOverride lets a PVZ accept receptions and products outside its
+// schedule until Until.
+type ScheduleOverride struct {
+	ID        uuid.UUID  `json:"id"`
+	PVZID     uuid.UUID  `json:"pvzId"`
+	Until     time.Time  `json:"until"`
+	Reason    string     `json:"reason,omitempty"`
+	CreatedBy *uuid.UUID `json:"createdBy,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+func (s *PostgresStorage) ListScheduleExceptions(ctx context.Context, filter ScheduleExceptionFilter) ([]ScheduleException, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT pvz_id, to_char(date, 'YYYY-MM-DD'), to_char(open_time, 'HH24:MI'), to_char(close_time, 'HH24:MI'), reason
+		FROM pvz_schedule_exceptions
+		WHERE (pvz_id IS NULL OR pvz_id = $1)
+		AND date >= COALESCE(NULLIF($2, '')::date, '-infinity')
+		AND date <= COALESCE(NULLIF($3, '')::date, 'infinity')
+		ORDER BY date, pvz_id NULLS FIRST`,
+		filter.PVZID, filter.From, filter.To,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedule exceptions: %w", err)
+	}
+	defer rows.Close()
+
+	exceptions := []ScheduleException{}
+	for rows.Next() {
+		var e ScheduleException
+		var open, closing *string
+		if err := rows.Scan(&e.PVZID, &e.Date, &open, &closing, &e.Reason); err != nil {
+			return nil, err
+		}
+		if open != nil && closing != nil {
+			e.Hours = &DayHours{Open: *open, Close: *closing}
+		}
+		exceptions = append(exceptions, e)
+	}
+	return exceptions, rows.Err()
+}
+
+// SetScheduleException creates the exception or replaces the one of the
+// same PVZ, or the holiday, on that date. ErrNotFound means the PVZ
+// doesn't exist.
+func (s *PostgresStorage) SetScheduleException(ctx context.Context, e ScheduleException) (ScheduleException, error) {
+	// The unique indexes are partial, so the conflict target has to name
+	// the one the row falls into
+	target := `(date) WHERE pvz_id IS NULL`
+	if e.PVZID != nil {
+		target = `(pvz_id, date) WHERE pvz_id IS NOT NULL`
+	}
+
+	var open, closing *string
+	if e.Hours != nil {
+		open, closing = &e.Hours.Open, &e.Hours.Close
+	}
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO pvz_schedule_exceptions (pvz_id, date, open_time, close_time, reason)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT `+target+`
+		DO UPDATE SET open_time = EXCLUDED.open_time, close_time = EXCLUDED.close_time, reason = EXCLUDED.reason`,
+		e.PVZID, e.Date, open, closing, e.Reason,
+	)
+	if isForeignKeyViolation(err) {
+		return ScheduleException{}, ErrNotFound
+	}
+	if err != nil {
+		return ScheduleException{}, fmt.Errorf("failed to set schedule exception: %w", err)
+	}
+	return e, nil
+}
+
+// DeleteScheduleException removes the exception of the PVZ on the date,
+// or the holiday with a nil pvzID.
+func (s *PostgresStorage) DeleteScheduleException(ctx context.Context, pvzID *uuid.UUID, date string) error {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM pvz_schedule_exceptions
+		WHERE pvz_id IS NOT DISTINCT FROM $1::uuid AND date = $2`,
+		pvzID, date,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// CreateScheduleOverride opens the PVZ from now until o.Until regardless
+// of its schedule. ErrNotFound means the PVZ doesn't exist.
+func (s *PostgresStorage) CreateScheduleOverride(ctx context.Context, o ScheduleOverride) (ScheduleOverride, error) {
+	err := s.db.QueryRowContext(ctx,
+		`INSERT INTO pvz_schedule_overrides (pvz_id, until, reason, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`,
+		o.PVZID, o.Until, o.Reason, o.CreatedBy,
+	).Scan(&o.ID, &o.CreatedAt)
+	if isForeignKeyViolation(err) {
+		return ScheduleOverride{}, ErrNotFound
+	}
+	if err != nil {
+		return ScheduleOverride{}, fmt.Errorf("failed to create schedule override: %w", err)
+	}
+	return o, nil
+}
+
+// EndScheduleOverride ends the overrides of the PVZ that are still in
+// effect. ErrNotFound means there were none.
+func (s *PostgresStorage) EndScheduleOverride(ctx context.Context, pvzID uuid.UUID) error {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE pvz_schedule_overrides SET until = NOW() AT TIME ZONE 'UTC'
+		WHERE pvz_id = $1 AND until > NOW() AT TIME ZONE 'UTC'`,
+		pvzID,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleExceptionValidate(t *testing.T) {
+	assert.NoError(t, storage.ScheduleException{Date: "2025-05-09"}.Validate())
+	assert.NoError(t, storage.ScheduleException{Date: "2025-12-31", Hours: &storage.DayHours{Open: "09:00", Close: "15:00"}}.Validate())
+	assert.ErrorIs(t, storage.ScheduleException{Date: "09.05.2025"}.Validate(), storage.ErrInvalidScheduleException)
+	assert.ErrorIs(t, storage.ScheduleException{Date: "2025-12-31", Hours: &storage.DayHours{Open: "15:00", Close: "09:00"}}.Validate(), storage.ErrInvalidScheduleException)
+}
+
+func TestScheduleExceptions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+	pvzID := uuid.New()
+
+	t.Run("list pvz exceptions with holidays", func(t *testing.T) {
+		mock.ExpectQuery(`FROM pvz_schedule_exceptions WHERE \(pvz_id IS NULL OR pvz_id = \$1\) .* ORDER BY date, pvz_id NULLS FIRST`).
+			WithArgs(&pvzID, "2025-05-01", "").
+			WillReturnRows(sqlmock.NewRows([]string{"pvz_id", "date", "open_time", "close_time", "reason"}).
+				AddRow(nil, "2025-05-09", nil, nil, "День Победы").
+				AddRow(pvzID, "2025-05-10", "10:00", "16:00", "инвентаризация"))
+
+		exceptions, err := store.ListScheduleExceptions(context.Background(), storage.ScheduleExceptionFilter{
+			PVZID: &pvzID,
+			From:  "2025-05-01",
+		})
+
+		require.NoError(t, err)
+		require.Len(t, exceptions, 2)
+		assert.Nil(t, exceptions[0].PVZID)
+		assert.Nil(t, exceptions[0].Hours)
+		assert.Equal(t, &storage.DayHours{Open: "10:00", Close: "16:00"}, exceptions[1].Hours)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("set holiday", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO pvz_schedule_exceptions .* ON CONFLICT \(date\) WHERE pvz_id IS NULL DO UPDATE`).
+			WithArgs((*uuid.UUID)(nil), "2025-05-09", (*string)(nil), (*string)(nil), "День Победы").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		_, err := store.SetScheduleException(context.Background(), storage.ScheduleException{Date: "2025-05-09", Reason: "День Победы"})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("set exception of unknown pvz", func(t *testing.T) {
+		mock.ExpectExec(`ON CONFLICT \(pvz_id, date\) WHERE pvz_id IS NOT NULL`).
+			WillReturnError(&pq.Error{Code: "23503"})
+
+		_, err := store.SetScheduleException(context.Background(), storage.ScheduleException{
+			PVZID: &pvzID,
+			Date:  "2025-12-31",
+			Hours: &storage.DayHours{Open: "09:00", Close: "15:00"},
+		})
+
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("delete missing exception", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM pvz_schedule_exceptions WHERE pvz_id IS NOT DISTINCT FROM \$1::uuid AND date = \$2`).
+			WithArgs(&pvzID, "2025-12-31").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.DeleteScheduleException(context.Background(), &pvzID, "2025-12-31")
+
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestScheduleOverrides(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+	pvzID := uuid.New()
+	moderatorID := uuid.New()
+	until := time.Now().Add(3 * time.Hour)
+
+	t.Run("create", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO pvz_schedule_overrides \(pvz_id, until, reason, created_by\)`).
+			WithArgs(pvzID, until, "задержка поставки", &moderatorID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), time.Now()))
+
+		override, err := store.CreateScheduleOverride(context.Background(), storage.ScheduleOverride{
+			PVZID:     pvzID,
+			Until:     until,
+			Reason:    "задержка поставки",
+			CreatedBy: &moderatorID,
+		})
+
+		require.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, override.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("end without override in effect", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE pvz_schedule_overrides SET until = NOW\(\) AT TIME ZONE 'UTC' WHERE pvz_id = \$1 AND until > NOW\(\) AT TIME ZONE 'UTC'`).
+			WithArgs(pvzID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.EndScheduleOverride(context.Background(), pvzID)
+
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PVZ statuses. Receptions can only be opened and filled at an active PVZ.
+const (
+	PVZActive            = "active"
+	PVZTemporarilyClosed = "temporarily_closed"
+	PVZDecommissioned    = "decommissioned"
+)
+
+var PVZStatuses = []string{PVZActive, PVZTemporarilyClosed, PVZDecommissioned}
+
+var ErrInvalidTransition = errors.New("invalid pvz status transition")
+
+// pvzTransitions lists the statuses a PVZ can move to. A decommissioned
+// PVZ stays decommissioned.
+var pvzTransitions = map[string][]string{
+	PVZActive:            {PVZTemporarilyClosed, PVZDecommissioned},
+	PVZTemporarilyClosed: {PVZActive, PVZDecommissioned},
+}
+
+// CanTransition reports whether a PVZ in status from can be moved to to.
+func CanTransition(from, to string) bool {
+	return slices.Contains(pvzTransitions[from], to)
+}
+
+// PVZStatusChange moves a PVZ to Status from EffectiveFrom on. Changes
+// with EffectiveFrom in the future are planned and don't affect the
+// current status until then.
+type PVZStatusChange struct {
+	ID            uuid.UUID  `json:"id"`
+	PVZID         uuid.UUID  `json:"pvzId"`
+	Status        string     `json:"status"`
+	Reason        string     `json:"reason,omitempty"`
+	EffectiveFrom time.Time  `json:"effectiveFrom"`
+	ChangedBy     *uuid.UUID `json:"changedBy,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// ChangePVZStatus stores the changes in order. Each one has to be a valid
+// transition from the last change of the PVZ, planned ones included, and
+// can't take effect before it. Otherwise nothing is stored and
+// ErrInvalidTransition is returned.
+func (s *PostgresStorage) ChangePVZStatus(ctx context.Context, pvzID uuid.UUID, changes []PVZStatusChange) ([]PVZStatusChange, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Locking the PVZ serializes status changes, so two moderators can't
+	// both move it from the same status
+	var since time.Time
+	err = tx.QueryRowContext(ctx, `SELECT registration_date FROM pvz WHERE id = $1 FOR UPDATE`, pvzID).Scan(&since)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock pvz: %w", err)
+	}
+
+	status := PVZActive
+	err = tx.QueryRowContext(ctx,
+		`SELECT status, effective_from
+		FROM pvz_status_changes
+		WHERE pvz_id = $1
+		ORDER BY effective_from DESC, created_at DESC
+		LIMIT 1`,
+		pvzID,
+	).Scan(&status, &since)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get last pvz status: %w", err)
+	}
+
+	stored := make([]PVZStatusChange, 0, len(changes))
+	for _, change := range changes {
+		if !CanTransition(status, change.Status) {
+			return nil, fmt.Errorf("%w from %s to %s", ErrInvalidTransition, status, change.Status)
+		}
+		if change.EffectiveFrom.Before(since) {
+			return nil, fmt.Errorf("%w: effective date is before the previous change", ErrInvalidTransition)
+		}
+
+		change.PVZID = pvzID
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO pvz_status_changes (pvz_id, status, reason, effective_from, changed_by)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, created_at`,
+			change.PVZID, change.Status, change.Reason, change.EffectiveFrom, change.ChangedBy,
+		).Scan(&change.ID, &change.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to change pvz status: %w", err)
+		}
+		stored = append(stored, change)
+		status, since = change.Status, change.EffectiveFrom
+	}
+
+	return stored, tx.Commit()
+}
+
+// ListPVZStatusChanges returns the status history of the PVZ including
+// planned changes, oldest first.
+func (s *PostgresStorage) ListPVZStatusChanges(ctx context.Context, pvzID uuid.UUID) ([]PVZStatusChange, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, pvz_id, status, reason, effective_from, changed_by, created_at
+		FROM pvz_status_changes
+		WHERE pvz_id = $1
+		ORDER BY effective_from, created_at`,
+		pvzID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pvz status changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []PVZStatusChange{}
+	for rows.Next() {
+		var c PVZStatusChange
+		if err := rows.Scan(&c.ID, &c.PVZID, &c.Status, &c.Reason, &c.EffectiveFrom, &c.ChangedBy, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
@@ -0,0 +1,130 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanTransition(t *testing.T) {
+	assert.True(t, storage.CanTransition(storage.PVZActive, storage.PVZTemporarilyClosed))
+	assert.True(t, storage.CanTransition(storage.PVZTemporarilyClosed, storage.PVZActive))
+	assert.True(t, storage.CanTransition(storage.PVZTemporarilyClosed, storage.PVZDecommissioned))
+	assert.False(t, storage.CanTransition(storage.PVZActive, storage.PVZActive))
+	assert.False(t, storage.CanTransition(storage.PVZDecommissioned, storage.PVZActive))
+	assert.False(t, storage.CanTransition(storage.PVZActive, "paused"))
+}
+
+func TestChangePVZStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+	pvzID := uuid.New()
+	moderatorID := uuid.New()
+	registered := time.Now().Add(-30 * 24 * time.Hour)
+	now := time.Now()
+
+	expectLock := func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT registration_date FROM pvz WHERE id = \$1 FOR UPDATE`).
+			WithArgs(pvzID).
+			WillReturnRows(sqlmock.NewRows([]string{"registration_date"}).AddRow(registered))
+	}
+
+	t.Run("close with planned reopening", func(t *testing.T) {
+		until := now.Add(7 * 24 * time.Hour)
+		expectLock()
+		mock.ExpectQuery(`SELECT status, effective_from FROM pvz_status_changes WHERE pvz_id = \$1 ORDER BY effective_from DESC, created_at DESC LIMIT 1`).
+			WithArgs(pvzID).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(`INSERT INTO pvz_status_changes`).
+			WithArgs(pvzID, storage.PVZTemporarilyClosed, "ремонт", now, &moderatorID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now))
+		mock.ExpectQuery(`INSERT INTO pvz_status_changes`).
+			WithArgs(pvzID, storage.PVZActive, "", until, &moderatorID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now))
+		mock.ExpectCommit()
+
+		changes, err := store.ChangePVZStatus(context.Background(), pvzID, []storage.PVZStatusChange{
+			{Status: storage.PVZTemporarilyClosed, Reason: "ремонт", EffectiveFrom: now, ChangedBy: &moderatorID},
+			{Status: storage.PVZActive, EffectiveFrom: until, ChangedBy: &moderatorID},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+		assert.Equal(t, pvzID, changes[0].PVZID)
+		assert.NotEqual(t, uuid.Nil, changes[1].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("decommissioned pvz can't be reopened", func(t *testing.T) {
+		expectLock()
+		mock.ExpectQuery(`SELECT status, effective_from FROM pvz_status_changes`).
+			WithArgs(pvzID).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "effective_from"}).AddRow(storage.PVZDecommissioned, now))
+		mock.ExpectRollback()
+
+		_, err := store.ChangePVZStatus(context.Background(), pvzID, []storage.PVZStatusChange{
+			{Status: storage.PVZActive, EffectiveFrom: now.Add(time.Hour)},
+		})
+
+		assert.ErrorIs(t, err, storage.ErrInvalidTransition)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("change before the planned one", func(t *testing.T) {
+		expectLock()
+		mock.ExpectQuery(`SELECT status, effective_from FROM pvz_status_changes`).
+			WithArgs(pvzID).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "effective_from"}).
+				AddRow(storage.PVZTemporarilyClosed, now.Add(24*time.Hour)))
+		mock.ExpectRollback()
+
+		_, err := store.ChangePVZStatus(context.Background(), pvzID, []storage.PVZStatusChange{
+			{Status: storage.PVZDecommissioned, Reason: "аренда", EffectiveFrom: now},
+		})
+
+		assert.ErrorIs(t, err, storage.ErrInvalidTransition)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown pvz", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT registration_date FROM pvz`).WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		_, err := store.ChangePVZStatus(context.Background(), uuid.New(), []storage.PVZStatusChange{
+			{Status: storage.PVZTemporarilyClosed, EffectiveFrom: now},
+		})
+
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("list history", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, pvz_id, status, reason, effective_from, changed_by, created_at FROM pvz_status_changes WHERE pvz_id = \$1 ORDER BY effective_from, created_at`).
+			WithArgs(pvzID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "pvz_id", "status", "reason", "effective_from", "changed_by", "created_at"}).
+				AddRow(uuid.New(), pvzID, storage.PVZTemporarilyClosed, "ремонт", now, moderatorID, now).
+				AddRow(uuid.New(), pvzID, storage.PVZActive, "", now.Add(time.Hour), nil, now))
+
+		changes, err := store.ListPVZStatusChanges(context.Background(), pvzID)
+
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+		assert.Equal(t, &moderatorID, changes[0].ChangedBy)
+		assert.Nil(t, changes[1].ChangedBy)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
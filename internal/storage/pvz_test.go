@@ -8,10 +8,20 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/mi4r/avito-pvz/internal/storage"
 	"github.com/stretchr/testify/assert"
 )
 
+var pvzColumns = []string{"id", "registration_date", "city", "code", "address", "latitude", "longitude",
+	"timezone", "phone", "working_hours", "status", "status_reason", "status_since", "is_open_now"}
+
+func pvzRow(id uuid.UUID, registrationDate time.Time, city string) *sqlmock.Rows {
+	return sqlmock.NewRows(pvzColumns).
+		AddRow(id, registrationDate, city, nil, "", nil, nil, storage.DefaultTimezone, "", []byte("{}"),
+			storage.PVZActive, "", nil, true)
+}
+
 func TestCreatePVZ(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -26,24 +36,28 @@ func TestCreatePVZ(t *testing.T) {
 		city := "Москва"
 		registrationDate := time.Now()
 
-		mock.ExpectQuery(`INSERT INTO pvz \(city\) VALUES \(\$1\) RETURNING id, registration_date, city`).
-			WithArgs(city).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "registration_date", "city"}).
-				AddRow(pvzID, registrationDate, city))
+		mock.ExpectQuery(`INSERT INTO pvz \(city, code, address, latitude, longitude, timezone, phone, working_hours\) SELECT name, \$2, \$3, \$4, \$5, \$6, \$7, \$8 FROM cities WHERE name = \$1 AND active RETURNING \* \) SELECT id, registration_date, city, code, .* FROM pvz LEFT JOIN pvz_current_status`).
+			WithArgs(city, nil, "", nil, nil, storage.DefaultTimezone, "", storage.WorkingHours(nil)).
+			WillReturnRows(pvzRow(pvzID, registrationDate, city))
 
-		pvz, err := store.CreatePVZ(context.Background(), city)
+		pvz, err := store.CreatePVZ(context.Background(), storage.PVZ{City: city})
 
 		assert.NoError(t, err)
 		assert.Equal(t, pvzID, pvz.ID)
 		assert.Equal(t, city, pvz.City)
+		assert.Equal(t, storage.DefaultTimezone, pvz.Timezone)
+		assert.Empty(t, pvz.WorkingHours)
 		assert.WithinDuration(t, registrationDate, pvz.RegistrationDate, time.Second)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("invalid city", func(t *testing.T) {
+	t.Run("unknown or inactive city", func(t *testing.T) {
 		city := "Invalid City"
 
-		pvz, err := store.CreatePVZ(context.Background(), city)
+		mock.ExpectQuery(`INSERT INTO pvz .* FROM cities`).
+			WillReturnRows(sqlmock.NewRows(pvzColumns))
+
+		pvz, err := store.CreatePVZ(context.Background(), storage.PVZ{City: city})
 
 		assert.Error(t, err)
 		assert.Equal(t, storage.ErrInvalidCity, err)
@@ -54,16 +68,146 @@ func TestCreatePVZ(t *testing.T) {
 	t.Run("database error", func(t *testing.T) {
 		city := "Москва"
 
-		mock.ExpectQuery(`INSERT INTO pvz \(city\) VALUES \(\$1\) RETURNING id, registration_date, city`).
-			WithArgs(city).
+		mock.ExpectQuery(`INSERT INTO pvz .* FROM cities WHERE name = \$1 AND active`).
 			WillReturnError(sql.ErrConnDone)
 
-		pvz, err := store.CreatePVZ(context.Background(), city)
+		pvz, err := store.CreatePVZ(context.Background(), storage.PVZ{City: city})
 
 		assert.Error(t, err)
 		assert.Empty(t, pvz)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
+
+	t.Run("duplicate code", func(t *testing.T) {
+		code := "MSK-1"
+
+		mock.ExpectQuery(`INSERT INTO pvz .* FROM cities`).
+			WillReturnError(&pq.Error{Code: "23505"})
+
+		_, err := store.CreatePVZ(context.Background(), storage.PVZ{City: "Москва", Code: &code})
+
+		assert.ErrorIs(t, err, storage.ErrPVZCodeExists)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetAndUpdatePVZ(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+	pvzID := uuid.New()
+	now := time.Now()
+
+	t.Run("get pvz with profile", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, registration_date, city, code, .* FROM pvz LEFT JOIN pvz_current_status .* WHERE id = \$1`).
+			WithArgs(pvzID).
+			WillReturnRows(sqlmock.NewRows(pvzColumns).
+				AddRow(pvzID, now, "Москва", "MSK-1", "ул. Тверская, 1", 55.757, 37.615,
+					"Europe/Moscow", "+74951234567", []byte(`{"mon":{"open":"09:00","close":"21:00"}}`),
+					storage.PVZActive, "", nil, true))
+
+		pvz, err := store.GetPVZ(context.Background(), pvzID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "MSK-1", *pvz.Code)
+		assert.Equal(t, 55.757, *pvz.Latitude)
+		assert.Equal(t, storage.DayHours{Open: "09:00", Close: "21:00"}, pvz.WorkingHours["mon"])
+		assert.True(t, pvz.IsOpenNow)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get unknown pvz", func(t *testing.T) {
+		mock.ExpectQuery(`FROM pvz LEFT JOIN pvz_current_status .* WHERE id = \$1`).
+			WithArgs(pvzID).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := store.GetPVZ(context.Background(), pvzID)
+
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("update profile", func(t *testing.T) {
+		hours := storage.WorkingHours{"sat": {Open: "10:00", Close: "18:00"}}
+		mock.ExpectQuery(`UPDATE pvz SET code = \$2, address = \$3, latitude = \$4, longitude = \$5, timezone = \$6, phone = \$7, working_hours = \$8 WHERE id = \$1`).
+			WithArgs(pvzID, nil, "ул. Ленина, 5", nil, nil, "Asia/Yekaterinburg", "", hours).
+			WillReturnRows(sqlmock.NewRows(pvzColumns).
+				AddRow(pvzID, now, "Екатеринбург", nil, "ул. Ленина, 5", nil, nil,
+					"Asia/Yekaterinburg", "", []byte(`{"sat":{"open":"10:00","close":"18:00"}}`),
+					storage.PVZTemporarilyClosed, "ремонт", now, false))
+
+		pvz, err := store.UpdatePVZ(context.Background(), storage.PVZ{
+			ID:           pvzID,
+			Address:      "ул. Ленина, 5",
+			Timezone:     "Asia/Yekaterinburg",
+			WorkingHours: hours,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, hours, pvz.WorkingHours)
+		assert.Equal(t, storage.PVZTemporarilyClosed, pvz.Status)
+		assert.Equal(t, "ремонт", pvz.StatusReason)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("update unknown pvz", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE pvz`).WillReturnError(sql.ErrNoRows)
+
+		_, err := store.UpdatePVZ(context.Background(), storage.PVZ{ID: pvzID, Timezone: storage.DefaultTimezone})
+
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPVZValidate(t *testing.T) {
+	code := "MSK-1"
+	badCode := "MSK 1"
+	lat, lon, far := 55.75, 37.61, 200.0
+
+	tests := []struct {
+		name  string
+		pvz   storage.PVZ
+		valid bool
+	}{
+		{"minimal", storage.PVZ{City: "Москва"}, true},
+		{"full profile", storage.PVZ{
+			Code:      &code,
+			Address:   "ул. Тверская, 1",
+			Latitude:  &lat,
+			Longitude: &lon,
+			Timezone:  "Europe/Moscow",
+			Phone:     "+74951234567",
+			WorkingHours: storage.WorkingHours{
+				"mon": {Open: "09:00", Close: "21:00"},
+				"sun": {Open: "10:00", Close: "16:00"},
+			},
+		}, true},
+		{"bad code", storage.PVZ{Code: &badCode}, false},
+		{"latitude without longitude", storage.PVZ{Latitude: &lat}, false},
+		{"longitude out of range", storage.PVZ{Latitude: &lat, Longitude: &far}, false},
+		{"unknown timezone", storage.PVZ{Timezone: "Mars/Olympus"}, false},
+		{"local timezone", storage.PVZ{Timezone: "Local"}, false},
+		{"phone without country code", storage.PVZ{Phone: "84951234567"}, false},
+		{"unknown weekday", storage.PVZ{WorkingHours: storage.WorkingHours{"monday": {Open: "09:00", Close: "18:00"}}}, false},
+		{"bad time", storage.PVZ{WorkingHours: storage.WorkingHours{"mon": {Open: "9am", Close: "18:00"}}}, false},
+		{"closes before opening", storage.PVZ{WorkingHours: storage.WorkingHours{"mon": {Open: "18:00", Close: "09:00"}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pvz.Validate()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, storage.ErrInvalidPVZ)
+			}
+		})
+	}
 }
 
 func TestGetPVZsWithReceptions(t *testing.T) {
@@ -86,23 +230,27 @@ func TestGetPVZsWithReceptions(t *testing.T) {
 		limit := 10
 
 		// Mock PVZ query
-		mock.ExpectQuery(`SELECT id, registration_date, city FROM pvz`).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "registration_date", "city"}).
-				AddRow(pvzID, now, "Москва"))
+		mock.ExpectQuery(`SELECT id, registration_date, city, .* FROM pvz`).
+			WillReturnRows(pvzRow(pvzID, now, "Москва"))
 
 		// Mock receptions query
-		mock.ExpectQuery(`SELECT r.id, r.created_at, r.pvz_id, r.status FROM receptions`).
-			WithArgs(pvzID, startDate, endDate).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "pvz_id", "status"}).
-				AddRow(receptionID, now, pvzID, "open"))
+		mock.ExpectQuery(`SELECT r.id, r.created_at, r.pvz_id, r.status, r.created_by, r.closed_by FROM receptions`).
+			WithArgs(pvzID, startDate, endDate, nil, "", "", false).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "pvz_id", "status", "created_by", "closed_by"}).
+				AddRow(receptionID, now, pvzID, "open", nil, nil))
 
 		// Mock products query
-		mock.ExpectQuery(`SELECT id, created_at, type, reception_id FROM products`).
-			WithArgs(receptionID).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "type", "reception_id"}).
-				AddRow(productID, now, "electronics", receptionID))
-
-		pvzs, err := store.GetPVZsWithReceptions(context.Background(), startDate, endDate, page, limit)
+		mock.ExpectQuery(`SELECT id, created_at, type, reception_id, created_by, deleted_at, deleted_by FROM products`).
+			WithArgs(receptionID, nil, false, "").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "type", "reception_id", "created_by", "deleted_at", "deleted_by"}).
+				AddRow(productID, now, "electronics", receptionID, nil, nil, nil))
+
+		pvzs, err := store.GetPVZsWithReceptions(context.Background(), storage.PVZFilter{
+			StartDate: startDate,
+			EndDate:   endDate,
+			Page:      page,
+			Limit:     limit,
+		})
 
 		assert.NoError(t, err)
 		assert.Len(t, pvzs, 1)
@@ -121,10 +269,15 @@ func TestGetPVZsWithReceptions(t *testing.T) {
 		page := 1
 		limit := 10
 
-		mock.ExpectQuery(`SELECT id, registration_date, city FROM pvz`).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "registration_date", "city"}))
+		mock.ExpectQuery(`SELECT id, registration_date, city, .* FROM pvz`).
+			WillReturnRows(sqlmock.NewRows(pvzColumns))
 
-		pvzs, err := store.GetPVZsWithReceptions(context.Background(), startDate, endDate, page, limit)
+		pvzs, err := store.GetPVZsWithReceptions(context.Background(), storage.PVZFilter{
+			StartDate: startDate,
+			EndDate:   endDate,
+			Page:      page,
+			Limit:     limit,
+		})
 
 		assert.NoError(t, err)
 		assert.Empty(t, pvzs)
@@ -137,10 +290,15 @@ func TestGetPVZsWithReceptions(t *testing.T) {
 		page := 1
 		limit := 10
 
-		mock.ExpectQuery(`SELECT id, registration_date, city FROM pvz`).
+		mock.ExpectQuery(`SELECT id, registration_date, city, .* FROM pvz`).
 			WillReturnError(sql.ErrConnDone)
 
-		pvzs, err := store.GetPVZsWithReceptions(context.Background(), startDate, endDate, page, limit)
+		pvzs, err := store.GetPVZsWithReceptions(context.Background(), storage.PVZFilter{
+			StartDate: startDate,
+			EndDate:   endDate,
+			Page:      page,
+			Limit:     limit,
+		})
 
 		assert.Error(t, err)
 		assert.Nil(t, pvzs)
@@ -155,18 +313,130 @@ func TestGetPVZsWithReceptions(t *testing.T) {
 		page := 1
 		limit := 10
 
-		mock.ExpectQuery(`SELECT id, registration_date, city FROM pvz`).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "registration_date", "city"}).
-				AddRow(pvzID, now, "Москва"))
+		mock.ExpectQuery(`SELECT id, registration_date, city, .* FROM pvz`).
+			WillReturnRows(pvzRow(pvzID, now, "Москва"))
 
-		mock.ExpectQuery(`SELECT r.id, r.created_at, r.pvz_id, r.status FROM receptions`).
-			WithArgs(pvzID, startDate, endDate).
+		mock.ExpectQuery(`SELECT r.id, r.created_at, r.pvz_id, r.status, r.created_by, r.closed_by FROM receptions`).
+			WithArgs(pvzID, startDate, endDate, nil, "", "", false).
 			WillReturnError(sql.ErrConnDone)
 
-		pvzs, err := store.GetPVZsWithReceptions(context.Background(), startDate, endDate, page, limit)
+		pvzs, err := store.GetPVZsWithReceptions(context.Background(), storage.PVZFilter{
+			StartDate: startDate,
+			EndDate:   endDate,
+			Page:      page,
+			Limit:     limit,
+		})
 
 		assert.Error(t, err)
 		assert.Nil(t, pvzs)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
+
+	t.Run("restricted to assigned pvzs", func(t *testing.T) {
+		pvzID := uuid.New()
+		startDate := time.Now().Add(-24 * time.Hour)
+		endDate := time.Now()
+
+		mock.ExpectQuery(`SELECT id, registration_date, city, .* FROM pvz LEFT JOIN pvz_current_status .* WHERE \(\$9::uuid\[\] IS NULL OR id = ANY\(\$9::uuid\[\]\)\)`).
+			WithArgs(10, startDate, endDate, nil, "", "", false, 0, pq.StringArray{pvzID.String()}, "", "", false).
+			WillReturnRows(sqlmock.NewRows(pvzColumns))
+
+		pvzs, err := store.GetPVZsWithReceptions(context.Background(), storage.PVZFilter{
+			StartDate: startDate,
+			EndDate:   endDate,
+			Page:      1,
+			Limit:     10,
+			PVZIDs:    []uuid.UUID{pvzID},
+		})
+
+		assert.NoError(t, err)
+		assert.Empty(t, pvzs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("filtered by status", func(t *testing.T) {
+		mock.ExpectQuery(`AND \(\$10 = '' OR COALESCE\(status, 'active'\) = \$10\)`).
+			WithArgs(10, nil, nil, nil, "", "", false, 0, nil, storage.PVZDecommissioned, "", false).
+			WillReturnRows(sqlmock.NewRows(pvzColumns))
+
+		pvzs, err := store.GetPVZsWithReceptions(context.Background(), storage.PVZFilter{
+			Page:   1,
+			Limit:  10,
+			Status: storage.PVZDecommissioned,
+		})
+
+		assert.NoError(t, err)
+		assert.Empty(t, pvzs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("filtered by employee with deleted products", func(t *testing.T) {
+		pvzID := uuid.New()
+		receptionID := uuid.New()
+		employeeID := uuid.New()
+		now := time.Now()
+		startDate := now.Add(-24 * time.Hour)
+
+		mock.ExpectQuery(`SELECT id, registration_date, city, .* FROM pvz`).
+			WillReturnRows(pvzRow(pvzID, now, "Москва"))
+		mock.ExpectQuery(`AND \(\$4::uuid IS NULL OR r.created_by = \$4 OR EXISTS \( SELECT 1 FROM products p WHERE p.reception_id = r.id AND p.created_by = \$4 AND \(\$7::boolean OR p.deleted_at IS NULL\) \)\)`).
+			WithArgs(pvzID, startDate, now, &employeeID, "", "", true).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "pvz_id", "status", "created_by", "closed_by"}).
+				AddRow(receptionID, now, pvzID, "closed", nil, employeeID))
+		mock.ExpectQuery(`AND \(\$2::uuid IS NULL OR created_by = \$2\) AND \(\$3::boolean OR deleted_at IS NULL\)`).
+			WithArgs(receptionID, &employeeID, true, "").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "type", "reception_id", "created_by", "deleted_at", "deleted_by"}).
+				AddRow(uuid.New(), now, "обувь", receptionID, employeeID, now, employeeID))
+
+		pvzs, err := store.GetPVZsWithReceptions(context.Background(), storage.PVZFilter{
+			StartDate:      startDate,
+			EndDate:        now,
+			Page:           1,
+			Limit:          10,
+			CreatedBy:      &employeeID,
+			IncludeDeleted: true,
+		})
+
+		assert.NoError(t, err)
+		reception := pvzs[0].Receptions[0]
+		assert.Nil(t, reception.Reception.CreatedBy)
+		assert.Equal(t, &employeeID, reception.Reception.ClosedBy)
+		assert.Equal(t, &employeeID, reception.Products[0].CreatedBy)
+		assert.Equal(t, &employeeID, reception.Products[0].DeletedBy)
+		assert.NotNil(t, reception.Products[0].DeletedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("only pvzs with matching receptions", func(t *testing.T) {
+		pvzID := uuid.New()
+		receptionID := uuid.New()
+		now := time.Now()
+		startDate := now.Add(-24 * time.Hour)
+
+		mock.ExpectQuery(`AND \(\$11 = '' OR city = \$11\) AND \(NOT \$12::boolean OR EXISTS \( SELECT 1 FROM receptions r WHERE r.pvz_id = pvz.id AND .* AND \(\$5 = '' OR r.status = \$5\) .* p.type = \$6 .* ORDER BY registration_date DESC LIMIT \$1 OFFSET \$8`).
+			WithArgs(10, startDate, nil, nil, storage.ReceptionClosed, "обувь", false, 10, nil, "", "Казань", true).
+			WillReturnRows(pvzRow(pvzID, now, "Казань"))
+		mock.ExpectQuery(`FROM receptions r WHERE r.pvz_id = \$1`).
+			WithArgs(pvzID, startDate, nil, nil, storage.ReceptionClosed, "обувь", false).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "pvz_id", "status", "created_by", "closed_by"}).
+				AddRow(receptionID, now, pvzID, storage.ReceptionClosed, nil, nil))
+		mock.ExpectQuery(`AND \(\$4 = '' OR type = \$4\)`).
+			WithArgs(receptionID, nil, false, "обувь").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "type", "reception_id", "created_by", "deleted_at", "deleted_by"}).
+				AddRow(uuid.New(), now, "обувь", receptionID, nil, nil, nil))
+
+		pvzs, err := store.GetPVZsWithReceptions(context.Background(), storage.PVZFilter{
+			StartDate:       startDate,
+			Page:            2,
+			Limit:           10,
+			City:            "Казань",
+			ReceptionStatus: storage.ReceptionClosed,
+			ProductType:     "обувь",
+			WithReceptions:  true,
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, pvzs, 1)
+		assert.Len(t, pvzs[0].Receptions, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"time"
@@ -10,21 +11,32 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
+// Reception statuses. A PVZ has at most one reception in progress.
+const (
+	ReceptionInProgress = "in_progress"
+	ReceptionClosed     = "closed"
+)
+
+var ReceptionStatuses = []string{ReceptionInProgress, ReceptionClosed}
+
 type Reception struct {
 	ID        uuid.UUID `json:"id"`
 	CreatedAt time.Time `json:"createdAt"`
 	PVZID     uuid.UUID `json:"pvzId"`
 	Status    string    `json:"status"` // 'in_progress' or 'closed'
+	// CreatedBy and ClosedBy are nil for dummy tokens and API keys
+	CreatedBy *uuid.UUID `json:"createdBy,omitempty"`
+	ClosedBy  *uuid.UUID `json:"closedBy,omitempty"`
 }
 
-func (s *PostgresStorage) CreateReception(ctx context.Context, pvzID uuid.UUID) (Reception, error) {
+func (s *PostgresStorage) CreateReception(ctx context.Context, pvzID uuid.UUID, createdBy *uuid.UUID) (Reception, error) {
 	var reception Reception
 	err := s.db.QueryRowContext(ctx,
-		`INSERT INTO receptions (pvz_id)
-		VALUES ($1)
-		RETURNING id, created_at, pvz_id, status`,
-		pvzID,
-	).Scan(&reception.ID, &reception.CreatedAt, &reception.PVZID, &reception.Status)
+		`INSERT INTO receptions (pvz_id, created_by)
+		VALUES ($1, $2)
+		RETURNING id, created_at, pvz_id, status, created_by`,
+		pvzID, createdBy,
+	).Scan(&reception.ID, &reception.CreatedAt, &reception.PVZID, &reception.Status, &reception.CreatedBy)
 
 	if err != nil {
 		return Reception{}, fmt.Errorf("failed to create reception: %w", err)
@@ -35,11 +47,11 @@ func (s *PostgresStorage) CreateReception(ctx context.Context, pvzID uuid.UUID)
 func (s *PostgresStorage) GetOpenReception(ctx context.Context, pvzID uuid.UUID) (Reception, error) {
 	var reception Reception
 	err := s.db.QueryRowContext(ctx,
-		`SELECT id, created_at, pvz_id, status 
+		`SELECT id, created_at, pvz_id, status, created_by
 		FROM receptions 
 		WHERE pvz_id = $1 AND status = 'in_progress'`,
 		pvzID,
-	).Scan(&reception.ID, &reception.CreatedAt, &reception.PVZID, &reception.Status)
+	).Scan(&reception.ID, &reception.CreatedAt, &reception.PVZID, &reception.Status, &reception.CreatedBy)
 
 	if errors.Is(err, pgx.ErrNoRows) {
 		return Reception{}, ErrNotFound
@@ -47,12 +59,99 @@ func (s *PostgresStorage) GetOpenReception(ctx context.Context, pvzID uuid.UUID)
 	return reception, err
 }
 
-func (s *PostgresStorage) CloseReception(ctx context.Context, receptionID uuid.UUID) error {
+func (s *PostgresStorage) CloseReception(ctx context.Context, receptionID uuid.UUID, closedBy *uuid.UUID) error {
 	_, err := s.db.ExecContext(ctx,
 		`UPDATE receptions 
-		SET status = 'closed' 
+		SET status = 'closed', closed_by = $2, updated_at = NOW()
 		WHERE id = $1`,
-		receptionID,
+		receptionID, closedBy,
 	)
 	return err
 }
+
+// ReceptionFilter selects a page of receptions of a PVZ, newest first.
+// Status and the From/To creation dates are optional.
+type ReceptionFilter struct {
+	PVZID  uuid.UUID
+	Status string
+	From   *time.Time
+	To     *time.Time
+	// Cursor is the last reception of the previous page, nil for the
+	// first one
+	Cursor *ReceptionCursor
+	Limit  int
+}
+
+// ReceptionCursor is a position in the list of receptions. The ID breaks
+// ties between receptions created at the same time.
+type ReceptionCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+const receptionColumns = `id, created_at, pvz_id, status, created_by, closed_by`
+
+func scanReception(row rowScanner) (Reception, error) {
+	var r Reception
+	err := row.Scan(&r.ID, &r.CreatedAt, &r.PVZID, &r.Status, &r.CreatedBy, &r.ClosedBy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Reception{}, ErrNotFound
+	}
+	return r, err
+}
+
+func (s *PostgresStorage) ListReceptions(ctx context.Context, filter ReceptionFilter) ([]Reception, error) {
+	var cursorAt *time.Time
+	var cursorID uuid.UUID
+	if filter.Cursor != nil {
+		cursorAt, cursorID = &filter.Cursor.CreatedAt, filter.Cursor.ID
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+receptionColumns+`
+		FROM receptions
+		WHERE pvz_id = $1
+		AND ($2 = '' OR status = $2)
+		AND ($3::timestamp IS NULL OR created_at >= $3)
+		AND ($4::timestamp IS NULL OR created_at <= $4)
+		AND ($5::timestamp IS NULL OR (created_at, id) < ($5, $6::uuid))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $7`,
+		filter.PVZID, filter.Status, filter.From, filter.To, cursorAt, cursorID, filter.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list receptions: %w", err)
+	}
+	defer rows.Close()
+
+	receptions := []Reception{}
+	for rows.Next() {
+		reception, err := scanReception(rows)
+		if err != nil {
+			return nil, err
+		}
+		receptions = append(receptions, reception)
+	}
+	return receptions, rows.Err()
+}
+
+// GetReception returns the reception with its products, newest first.
+// Deleted products are only listed with includeDeleted.
+func (s *PostgresStorage) GetReception(ctx context.Context, id uuid.UUID, includeDeleted bool) (ReceptionWithProducts, error) {
+	reception, err := scanReception(s.db.QueryRowContext(ctx,
+		`SELECT `+receptionColumns+` FROM receptions WHERE id = $1`,
+		id,
+	))
+	if err != nil {
+		return ReceptionWithProducts{}, err
+	}
+
+	products, err := s.getProductsForReception(ctx, id, PVZFilter{IncludeDeleted: includeDeleted})
+	if err != nil {
+		return ReceptionWithProducts{}, err
+	}
+	if products == nil {
+		products = []Product{}
+	}
+	return ReceptionWithProducts{Reception: reception, Products: products}, nil
+}
@@ -25,19 +25,21 @@ func TestCreateReception(t *testing.T) {
 	t.Run("success create reception", func(t *testing.T) {
 		pvzID := uuid.New()
 		receptionID := uuid.New()
+		employeeID := uuid.New()
 		now := time.Now()
 
-		mock.ExpectQuery(`INSERT INTO receptions \(pvz_id\) VALUES \(\$1\) RETURNING id, created_at, pvz_id, status`).
-			WithArgs(pvzID).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "pvz_id", "status"}).
-				AddRow(receptionID, now, pvzID, "in_progress"))
+		mock.ExpectQuery(`INSERT INTO receptions \(pvz_id, created_by\) VALUES \(\$1, \$2\) RETURNING id, created_at, pvz_id, status, created_by`).
+			WithArgs(pvzID, &employeeID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "pvz_id", "status", "created_by"}).
+				AddRow(receptionID, now, pvzID, "in_progress", employeeID))
 
-		reception, err := store.CreateReception(context.Background(), pvzID)
+		reception, err := store.CreateReception(context.Background(), pvzID, &employeeID)
 
 		assert.NoError(t, err)
 		assert.Equal(t, receptionID, reception.ID)
 		assert.Equal(t, pvzID, reception.PVZID)
 		assert.Equal(t, "in_progress", reception.Status)
+		assert.Equal(t, &employeeID, reception.CreatedBy)
 		assert.WithinDuration(t, now, reception.CreatedAt, time.Second)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -45,11 +47,11 @@ func TestCreateReception(t *testing.T) {
 	t.Run("database error", func(t *testing.T) {
 		pvzID := uuid.New()
 
-		mock.ExpectQuery(`INSERT INTO receptions \(pvz_id\) VALUES \(\$1\) RETURNING id, created_at, pvz_id, status`).
-			WithArgs(pvzID).
+		mock.ExpectQuery(`INSERT INTO receptions \(pvz_id, created_by\) VALUES \(\$1, \$2\) RETURNING id, created_at, pvz_id, status, created_by`).
+			WithArgs(pvzID, nil).
 			WillReturnError(sql.ErrConnDone)
 
-		reception, err := store.CreateReception(context.Background(), pvzID)
+		reception, err := store.CreateReception(context.Background(), pvzID, nil)
 
 		assert.Error(t, err)
 		assert.Empty(t, reception)
@@ -71,10 +73,10 @@ func TestGetOpenReception(t *testing.T) {
 		receptionID := uuid.New()
 		now := time.Now()
 
-		mock.ExpectQuery(`SELECT id, created_at, pvz_id, status FROM receptions WHERE pvz_id = \$1 AND status = 'in_progress'`).
+		mock.ExpectQuery(`SELECT id, created_at, pvz_id, status, created_by FROM receptions WHERE pvz_id = \$1 AND status = 'in_progress'`).
 			WithArgs(pvzID).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "pvz_id", "status"}).
-				AddRow(receptionID, now, pvzID, "in_progress"))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "pvz_id", "status", "created_by"}).
+				AddRow(receptionID, now, pvzID, "in_progress", nil))
 
 		reception, err := store.GetOpenReception(context.Background(), pvzID)
 
@@ -89,7 +91,7 @@ func TestGetOpenReception(t *testing.T) {
 	t.Run("not found", func(t *testing.T) {
 		pvzID := uuid.New()
 
-		mock.ExpectQuery(`SELECT id, created_at, pvz_id, status FROM receptions WHERE pvz_id = \$1 AND status = 'in_progress'`).
+		mock.ExpectQuery(`SELECT id, created_at, pvz_id, status, created_by FROM receptions WHERE pvz_id = \$1 AND status = 'in_progress'`).
 			WithArgs(pvzID).
 			WillReturnError(pgx.ErrNoRows)
 
@@ -104,7 +106,7 @@ func TestGetOpenReception(t *testing.T) {
 	t.Run("database error", func(t *testing.T) {
 		pvzID := uuid.New()
 
-		mock.ExpectQuery(`SELECT id, created_at, pvz_id, status FROM receptions WHERE pvz_id = \$1 AND status = 'in_progress'`).
+		mock.ExpectQuery(`SELECT id, created_at, pvz_id, status, created_by FROM receptions WHERE pvz_id = \$1 AND status = 'in_progress'`).
 			WithArgs(pvzID).
 			WillReturnError(sql.ErrConnDone)
 
@@ -127,12 +129,13 @@ func TestCloseReception(t *testing.T) {
 
 	t.Run("success close reception", func(t *testing.T) {
 		receptionID := uuid.New()
+		employeeID := uuid.New()
 
-		mock.ExpectExec(`UPDATE receptions SET status = 'closed' WHERE id = \$1`).
-			WithArgs(receptionID).
+		mock.ExpectExec(`UPDATE receptions SET status = 'closed', closed_by = \$2, updated_at = NOW\(\) WHERE id = \$1`).
+			WithArgs(receptionID, &employeeID).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 
-		err := store.CloseReception(context.Background(), receptionID)
+		err := store.CloseReception(context.Background(), receptionID, &employeeID)
 
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -141,13 +144,105 @@ func TestCloseReception(t *testing.T) {
 	t.Run("database error", func(t *testing.T) {
 		receptionID := uuid.New()
 
-		mock.ExpectExec(`UPDATE receptions SET status = 'closed' WHERE id = \$1`).
-			WithArgs(receptionID).
+		mock.ExpectExec(`UPDATE receptions SET status = 'closed', closed_by = \$2`).
+			WithArgs(receptionID, nil).
 			WillReturnError(sql.ErrConnDone)
 
-		err := store.CloseReception(context.Background(), receptionID)
+		err := store.CloseReception(context.Background(), receptionID, nil)
 
 		assert.Error(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
+
+func TestListReceptions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+	pvzID := uuid.New()
+	now := time.Now()
+	columns := []string{"id", "created_at", "pvz_id", "status", "created_by", "closed_by"}
+
+	t.Run("first page", func(t *testing.T) {
+		from := now.Add(-24 * time.Hour)
+		mock.ExpectQuery(`FROM receptions WHERE pvz_id = \$1 .* ORDER BY created_at DESC, id DESC LIMIT \$7`).
+			WithArgs(pvzID, storage.ReceptionClosed, &from, nil, nil, uuid.Nil, 2).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(uuid.New(), now, pvzID, storage.ReceptionClosed, nil, nil).
+				AddRow(uuid.New(), now.Add(-time.Hour), pvzID, storage.ReceptionClosed, nil, nil))
+
+		receptions, err := store.ListReceptions(context.Background(), storage.ReceptionFilter{
+			PVZID:  pvzID,
+			Status: storage.ReceptionClosed,
+			From:   &from,
+			Limit:  2,
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, receptions, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("next page", func(t *testing.T) {
+		cursor := storage.ReceptionCursor{CreatedAt: now, ID: uuid.New()}
+		mock.ExpectQuery(`AND \(\$5::timestamp IS NULL OR \(created_at, id\) < \(\$5, \$6::uuid\)\)`).
+			WithArgs(pvzID, "", nil, nil, &cursor.CreatedAt, cursor.ID, 20).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		receptions, err := store.ListReceptions(context.Background(), storage.ReceptionFilter{
+			PVZID:  pvzID,
+			Cursor: &cursor,
+			Limit:  20,
+		})
+
+		assert.NoError(t, err)
+		assert.Empty(t, receptions)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetReception(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+	receptionID := uuid.New()
+	pvzID := uuid.New()
+	now := time.Now()
+
+	t.Run("with products", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, created_at, pvz_id, status, created_by, closed_by FROM receptions WHERE id = \$1`).
+			WithArgs(receptionID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "pvz_id", "status", "created_by", "closed_by"}).
+				AddRow(receptionID, now, pvzID, storage.ReceptionInProgress, nil, nil))
+		mock.ExpectQuery(`FROM products WHERE reception_id = \$1`).
+			WithArgs(receptionID, nil, true, "").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "type", "reception_id", "created_by", "deleted_at", "deleted_by"}).
+				AddRow(uuid.New(), now, "обувь", receptionID, nil, now, nil))
+
+		reception, err := store.GetReception(context.Background(), receptionID, true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, pvzID, reception.Reception.PVZID)
+		assert.Len(t, reception.Products, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mock.ExpectQuery(`FROM receptions WHERE id = \$1`).
+			WithArgs(receptionID).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := store.GetReception(context.Background(), receptionID, false)
+
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+)
+
+func (s *PostgresStorage) GetRolePermissions(ctx context.Context, role string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT permission 
+		FROM role_permissions 
+		WHERE role = $1
+		ORDER BY permission`,
+		role,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := []string{}
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+	return permissions, rows.Err()
+}
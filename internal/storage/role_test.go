@@ -0,0 +1,58 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRolePermissions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT permission FROM role_permissions WHERE role = \$1`).
+			WithArgs("employee").
+			WillReturnRows(sqlmock.NewRows([]string{"permission"}).
+				AddRow("pvz:read").
+				AddRow("reception:create"))
+
+		permissions, err := store.GetRolePermissions(context.Background(), "employee")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"pvz:read", "reception:create"}, permissions)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown role has no permissions", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT permission FROM role_permissions`).
+			WithArgs("client").
+			WillReturnRows(sqlmock.NewRows([]string{"permission"}))
+
+		permissions, err := store.GetRolePermissions(context.Background(), "client")
+
+		assert.NoError(t, err)
+		assert.Empty(t, permissions)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT permission FROM role_permissions`).
+			WithArgs("employee").
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := store.GetRolePermissions(context.Background(), "employee")
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
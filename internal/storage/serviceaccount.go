@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrServiceAccountExists = errors.New("service account already exists")
+	ErrInvalidPermission    = errors.New("invalid permission")
+)
+
+type ServiceAccount struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Permissions []string   `json:"permissions"`
+	CreatedBy   *uuid.UUID `json:"createdBy,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// APIKey is a credential of a service account. KeyID is the public part
+// of the key used for lookup; the key itself is only stored as a hash.
+type APIKey struct {
+	ID               uuid.UUID  `json:"id"`
+	ServiceAccountID uuid.UUID  `json:"serviceAccountId"`
+	KeyID            string     `json:"keyId"`
+	KeyHash          string     `json:"-"`
+	ExpiresAt        *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	LastUsedAt       *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt        *time.Time `json:"revokedAt,omitempty"`
+}
+
+const serviceAccountQuery = `SELECT sa.id, sa.name, sa.description, sa.created_by, sa.created_at,
+		COALESCE(array_agg(p.permission ORDER BY p.permission) FILTER (WHERE p.permission IS NOT NULL), '{}')
+	FROM service_accounts sa
+	LEFT JOIN service_account_permissions p ON p.service_account_id = sa.id`
+
+func scanServiceAccount(row rowScanner) (ServiceAccount, error) {
+	var account ServiceAccount
+	var permissions pq.StringArray
+	err := row.Scan(&account.ID, &account.Name, &account.Description, &account.CreatedBy, &account.CreatedAt, &permissions)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ServiceAccount{}, ErrNotFound
+	}
+	account.Permissions = []string(permissions)
+	return account, err
+}
+
+// CreateServiceAccount creates the account together with its permissions.
+func (s *PostgresStorage) CreateServiceAccount(ctx context.Context, name, description string, permissions []string, createdBy *uuid.UUID) (ServiceAccount, error) {
+	account := ServiceAccount{Permissions: permissions}
+	err := s.db.QueryRowContext(ctx,
+		`WITH account AS (
+			INSERT INTO service_accounts (name, description, created_by)
+			VALUES ($1, $2, $3)
+			RETURNING id, name, description, created_by, created_at
+		), granted AS (
+			INSERT INTO service_account_permissions (service_account_id, permission)
+			SELECT account.id, unnest($4::text[]) FROM account
+		)
+		SELECT id, name, description, created_by, created_at FROM account`,
+		name, description, createdBy, pq.StringArray(permissions),
+	).Scan(&account.ID, &account.Name, &account.Description, &account.CreatedBy, &account.CreatedAt)
+	switch {
+	case isUniqueViolation(err):
+		return ServiceAccount{}, ErrServiceAccountExists
+	case isForeignKeyViolation(err):
+		return ServiceAccount{}, ErrInvalidPermission
+	}
+	return account, err
+}
+
+func (s *PostgresStorage) ListServiceAccounts(ctx context.Context) ([]ServiceAccount, error) {
+	rows, err := s.db.QueryContext(ctx,
+		serviceAccountQuery+`
+		GROUP BY sa.id
+		ORDER BY sa.name`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []ServiceAccount{}
+	for rows.Next() {
+		account, err := scanServiceAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
+
+func (s *PostgresStorage) GetServiceAccount(ctx context.Context, id uuid.UUID) (ServiceAccount, error) {
+	return scanServiceAccount(s.db.QueryRowContext(ctx,
+		serviceAccountQuery+`
+		WHERE sa.id = $1
+		GROUP BY sa.id`,
+		id,
+	))
+}
+
+func (s *PostgresStorage) DeleteServiceAccount(ctx context.Context, id uuid.UUID) error {
+	return s.execOne(ctx,
+		`DELETE FROM service_accounts WHERE id = $1`,
+		id,
+	)
+}
+
+// CreateAPIKey stores a new key. A nil expiresAt means the key never expires.
+func (s *PostgresStorage) CreateAPIKey(ctx context.Context, serviceAccountID uuid.UUID, keyID, keyHash string, expiresAt *time.Time) (APIKey, error) {
+	key := APIKey{ServiceAccountID: serviceAccountID, KeyID: keyID}
+	err := s.db.QueryRowContext(ctx,
+		`INSERT INTO api_keys (service_account_id, key_id, key_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, expires_at, created_at`,
+		serviceAccountID, keyID, keyHash, expiresAt,
+	).Scan(&key.ID, &key.ExpiresAt, &key.CreatedAt)
+	if isForeignKeyViolation(err) {
+		return APIKey{}, ErrNotFound
+	}
+	return key, err
+}
+
+func (s *PostgresStorage) ListAPIKeys(ctx context.Context, serviceAccountID uuid.UUID) ([]APIKey, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, service_account_id, key_id, key_hash, expires_at, created_at, last_used_at, revoked_at
+		FROM api_keys
+		WHERE service_account_id = $1
+		ORDER BY created_at DESC`,
+		serviceAccountID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (s *PostgresStorage) GetAPIKey(ctx context.Context, keyID string) (APIKey, error) {
+	return scanAPIKey(s.db.QueryRowContext(ctx,
+		`SELECT id, service_account_id, key_id, key_hash, expires_at, created_at, last_used_at, revoked_at
+		FROM api_keys
+		WHERE key_id = $1`,
+		keyID,
+	))
+}
+
+func scanAPIKey(row rowScanner) (APIKey, error) {
+	var key APIKey
+	err := row.Scan(&key.ID, &key.ServiceAccountID, &key.KeyID, &key.KeyHash,
+		&key.ExpiresAt, &key.CreatedAt, &key.LastUsedAt, &key.RevokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, ErrNotFound
+	}
+	return key, err
+}
+
+func (s *PostgresStorage) RevokeAPIKey(ctx context.Context, serviceAccountID, id uuid.UUID) error {
+	return s.execOne(ctx,
+		`UPDATE api_keys
+		SET revoked_at = NOW()
+		WHERE id = $1 AND service_account_id = $2 AND revoked_at IS NULL`,
+		id, serviceAccountID,
+	)
+}
+
+// TouchAPIKey records that the key was used. The timestamp is only moved
+// once a minute so busy integrations don't write on every request.
+func (s *PostgresStorage) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE api_keys
+		SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`,
+		id,
+	)
+	return err
+}
@@ -0,0 +1,191 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceAccounts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+	accountID := uuid.New()
+	accountColumns := []string{"id", "name", "description", "created_by", "created_at", "permissions"}
+
+	t.Run("create service account", func(t *testing.T) {
+		createdBy := uuid.New()
+		permissions := []string{"pvz:read", "reception:create"}
+		mock.ExpectQuery(`WITH account AS \( INSERT INTO service_accounts \(name, description, created_by\)`).
+			WithArgs("warehouse", "WMS sync", createdBy, pq.StringArray(permissions)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "created_by", "created_at"}).
+				AddRow(accountID, "warehouse", "WMS sync", createdBy, time.Now()))
+
+		account, err := store.CreateServiceAccount(context.Background(), "warehouse", "WMS sync", permissions, &createdBy)
+
+		assert.NoError(t, err)
+		assert.Equal(t, accountID, account.ID)
+		assert.Equal(t, permissions, account.Permissions)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("create service account with taken name", func(t *testing.T) {
+		mock.ExpectQuery(`WITH account AS`).
+			WillReturnError(&pq.Error{Code: "23505"})
+
+		_, err := store.CreateServiceAccount(context.Background(), "warehouse", "", []string{"pvz:read"}, nil)
+
+		assert.Equal(t, storage.ErrServiceAccountExists, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("create service account with unknown permission", func(t *testing.T) {
+		mock.ExpectQuery(`WITH account AS`).
+			WillReturnError(&pq.Error{Code: "23503"})
+
+		_, err := store.CreateServiceAccount(context.Background(), "warehouse", "", []string{"root"}, nil)
+
+		assert.Equal(t, storage.ErrInvalidPermission, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("list service accounts", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT sa.id, sa.name, sa.description, sa.created_by, sa.created_at, .+ FROM service_accounts sa LEFT JOIN service_account_permissions p .+ GROUP BY sa.id ORDER BY sa.name`).
+			WillReturnRows(sqlmock.NewRows(accountColumns).
+				AddRow(accountID, "warehouse", "", nil, time.Now(), "{pvz:read,reception:create}").
+				AddRow(uuid.New(), "reports", "", nil, time.Now(), "{}"))
+
+		accounts, err := store.ListServiceAccounts(context.Background())
+
+		assert.NoError(t, err)
+		assert.Len(t, accounts, 2)
+		assert.Equal(t, []string{"pvz:read", "reception:create"}, accounts[0].Permissions)
+		assert.Empty(t, accounts[1].Permissions)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get missing service account", func(t *testing.T) {
+		mock.ExpectQuery(`WHERE sa.id = \$1 GROUP BY sa.id`).
+			WithArgs(accountID).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := store.GetServiceAccount(context.Background(), accountID)
+
+		assert.Equal(t, storage.ErrNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("delete missing service account", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM service_accounts WHERE id = \$1`).
+			WithArgs(accountID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.DeleteServiceAccount(context.Background(), accountID)
+
+		assert.Equal(t, storage.ErrNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAPIKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+	accountID := uuid.New()
+	keyID := uuid.New()
+	keyColumns := []string{"id", "service_account_id", "key_id", "key_hash", "expires_at", "created_at", "last_used_at", "revoked_at"}
+
+	t.Run("create api key", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour)
+		mock.ExpectQuery(`INSERT INTO api_keys \(service_account_id, key_id, key_hash, expires_at\)`).
+			WithArgs(accountID, "0123456789ab", "hash", &expiresAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "expires_at", "created_at"}).
+				AddRow(keyID, expiresAt, time.Now()))
+
+		key, err := store.CreateAPIKey(context.Background(), accountID, "0123456789ab", "hash", &expiresAt)
+
+		assert.NoError(t, err)
+		assert.Equal(t, keyID, key.ID)
+		assert.Equal(t, "0123456789ab", key.KeyID)
+		assert.NotNil(t, key.ExpiresAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("create api key for missing account", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO api_keys`).
+			WillReturnError(&pq.Error{Code: "23503"})
+
+		_, err := store.CreateAPIKey(context.Background(), accountID, "0123456789ab", "hash", nil)
+
+		assert.Equal(t, storage.ErrNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get api key", func(t *testing.T) {
+		mock.ExpectQuery(`FROM api_keys WHERE key_id = \$1`).
+			WithArgs("0123456789ab").
+			WillReturnRows(sqlmock.NewRows(keyColumns).
+				AddRow(keyID, accountID, "0123456789ab", "hash", nil, time.Now(), nil, nil))
+
+		key, err := store.GetAPIKey(context.Background(), "0123456789ab")
+
+		assert.NoError(t, err)
+		assert.Equal(t, accountID, key.ServiceAccountID)
+		assert.Equal(t, "hash", key.KeyHash)
+		assert.Nil(t, key.ExpiresAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get unknown api key", func(t *testing.T) {
+		mock.ExpectQuery(`FROM api_keys WHERE key_id = \$1`).
+			WithArgs("0123456789ab").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := store.GetAPIKey(context.Background(), "0123456789ab")
+
+		assert.Equal(t, storage.ErrNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("revoke api key", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE api_keys SET revoked_at = NOW\(\) WHERE id = \$1 AND service_account_id = \$2 AND revoked_at IS NULL`).
+			WithArgs(keyID, accountID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, store.RevokeAPIKey(context.Background(), accountID, keyID))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("revoke already revoked api key", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE api_keys SET revoked_at`).
+			WithArgs(keyID, accountID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Equal(t, storage.ErrNotFound, store.RevokeAPIKey(context.Background(), accountID, keyID))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("touch api key", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE api_keys SET last_used_at = NOW\(\) WHERE id = \$1 AND \(last_used_at IS NULL OR last_used_at < NOW\(\) - INTERVAL '1 minute'\)`).
+			WithArgs(keyID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.NoError(t, store.TouchAPIKey(context.Background(), keyID))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is one login of a user. Its ID is the family of the refresh
+// tokens issued for it and the sid claim of its access tokens.
+type Session struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"userId"`
+	// Email is only filled in by ListPVZSessions
+	Email      string    `json:"email,omitempty"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+}
+
+// activeSession matches sessions that aren't revoked and can still be
+// refreshed.
+const activeSession = `s.revoked_at IS NULL AND EXISTS (
+	SELECT 1 FROM refresh_tokens rt
+	WHERE rt.family_id = s.id AND rt.used_at IS NULL AND rt.revoked_at IS NULL AND rt.expires_at > NOW()
+)`
+
+func (s *PostgresStorage) CreateSession(ctx context.Context, userID uuid.UUID, device, userAgent, ip string) (Session, error) {
+	session := Session{ID: uuid.New()}
+	err := s.db.QueryRowContext(ctx,
+		`INSERT INTO sessions (id, user_id, device, user_agent, ip)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, user_id, device, user_agent, ip, created_at, last_seen_at`,
+		session.ID, userID, device, userAgent, ip,
+	).Scan(&session.ID, &session.UserID, &session.Device, &session.UserAgent, &session.IP,
+		&session.CreatedAt, &session.LastSeenAt)
+	return session, err
+}
+
+// TouchSession records that the session was used and reports whether it
+// is still valid. Like TouchAPIKey it moves the timestamp at most once a
+// minute.
+func (s *PostgresStorage) TouchSession(ctx context.Context, id uuid.UUID) (bool, error) {
+	var active bool
+	err := s.db.QueryRowContext(ctx,
+		`WITH touched AS (
+			UPDATE sessions
+			SET last_seen_at = NOW()
+			WHERE id = $1 AND revoked_at IS NULL AND last_seen_at < NOW() - INTERVAL '1 minute'
+		)
+		SELECT revoked_at IS NULL FROM sessions WHERE id = $1`,
+		id,
+	).Scan(&active)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return active, err
+}
+
+func (s *PostgresStorage) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]Session, error) {
+	return s.listSessions(ctx,
+		`SELECT s.id, s.user_id, '', s.device, s.user_agent, s.ip, s.created_at, s.last_seen_at
+		FROM sessions s
+		WHERE s.user_id = $1 AND `+activeSession+`
+		ORDER BY s.last_seen_at DESC`,
+		userID,
+	)
+}
+
+// ListPVZSessions returns the active sessions of the users assigned to
+// the PVZ.
+func (s *PostgresStorage) ListPVZSessions(ctx context.Context, pvzID uuid.UUID) ([]Session, error) {
+	return s.listSessions(ctx,
+		`SELECT s.id, s.user_id, u.email, s.device, s.user_agent, s.ip, s.created_at, s.last_seen_at
+		FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		JOIN user_pvz up ON up.user_id = s.user_id
+		WHERE up.pvz_id = $1 AND `+activeSession+`
+		ORDER BY s.last_seen_at DESC`,
+		pvzID,
+	)
+}
+
+func (s *PostgresStorage) listSessions(ctx context.Context, query string, args ...any) ([]Session, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		var session Session
+		if err := rows.Scan(&session.ID, &session.UserID, &session.Email, &session.Device, &session.UserAgent,
+			&session.IP, &session.CreatedAt, &session.LastSeenAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeSession ends one session of the user together with its refresh
+// tokens. Revoking an already revoked session is a no-op.
+func (s *PostgresStorage) RevokeSession(ctx context.Context, userID, id uuid.UUID) error {
+	return s.execOne(ctx,
+		`WITH tokens AS (
+			UPDATE refresh_tokens
+			SET revoked_at = NOW()
+			WHERE family_id IN (SELECT id FROM sessions WHERE id = $2 AND user_id = $1)
+			AND revoked_at IS NULL
+		)
+		UPDATE sessions
+		SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $2 AND user_id = $1`,
+		userID, id,
+	)
+}
+
+const revokeUserSessionsQuery = `WITH tokens AS (
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	)
+	UPDATE sessions
+	SET revoked_at = NOW()
+	WHERE user_id = $1 AND revoked_at IS NULL`
+
+// RevokeUserSessions ends every session of the user.
+func (s *PostgresStorage) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx, revokeUserSessionsQuery, userID)
+	return err
+}
+
+// PurgeSessions drops sessions whose refresh tokens were all purged. New
+// sessions are kept while their first refresh token is being stored.
+func (s *PostgresStorage) PurgeSessions(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM sessions s
+		WHERE s.created_at < NOW() - INTERVAL '1 hour'
+		AND NOT EXISTS (SELECT 1 FROM refresh_tokens rt WHERE rt.family_id = s.id)`,
+	)
+	return err
+}
@@ -0,0 +1,125 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+	userID := uuid.New()
+	sessionID := uuid.New()
+	columns := []string{"id", "user_id", "email", "device", "user_agent", "ip", "created_at", "last_seen_at"}
+
+	t.Run("create session", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectQuery(`INSERT INTO sessions \(id, user_id, device, user_agent, ip\)`).
+			WithArgs(sqlmock.AnyArg(), userID, "scanner-07", "Zebra TC52", "192.0.2.1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "device", "user_agent", "ip", "created_at", "last_seen_at"}).
+				AddRow(sessionID, userID, "scanner-07", "Zebra TC52", "192.0.2.1", now, now))
+
+		session, err := store.CreateSession(context.Background(), userID, "scanner-07", "Zebra TC52", "192.0.2.1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, sessionID, session.ID)
+		assert.Equal(t, "scanner-07", session.Device)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("touch active session", func(t *testing.T) {
+		mock.ExpectQuery(`WITH touched AS \( UPDATE sessions SET last_seen_at = NOW\(\) WHERE id = \$1 AND revoked_at IS NULL`).
+			WithArgs(sessionID).
+			WillReturnRows(sqlmock.NewRows([]string{"active"}).AddRow(true))
+
+		active, err := store.TouchSession(context.Background(), sessionID)
+
+		assert.NoError(t, err)
+		assert.True(t, active)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("touch unknown session", func(t *testing.T) {
+		mock.ExpectQuery(`WITH touched AS`).
+			WithArgs(sessionID).
+			WillReturnError(sql.ErrNoRows)
+
+		active, err := store.TouchSession(context.Background(), sessionID)
+
+		assert.NoError(t, err)
+		assert.False(t, active)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("list user sessions", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectQuery(`SELECT s.id, s.user_id, '', s.device, s.user_agent, s.ip, s.created_at, s.last_seen_at FROM sessions s WHERE s.user_id = \$1 AND s.revoked_at IS NULL`).
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(sessionID, userID, "", "scanner-07", "Zebra TC52", "192.0.2.1", now, now))
+
+		sessions, err := store.ListUserSessions(context.Background(), userID)
+
+		assert.NoError(t, err)
+		assert.Len(t, sessions, 1)
+		assert.Equal(t, sessionID, sessions[0].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("list pvz sessions", func(t *testing.T) {
+		now := time.Now()
+		pvzID := uuid.New()
+		mock.ExpectQuery(`JOIN user_pvz up ON up.user_id = s.user_id WHERE up.pvz_id = \$1`).
+			WithArgs(pvzID).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(sessionID, userID, "employee@example.com", "scanner-07", "Zebra TC52", "192.0.2.1", now, now))
+
+		sessions, err := store.ListPVZSessions(context.Background(), pvzID)
+
+		assert.NoError(t, err)
+		assert.Len(t, sessions, 1)
+		assert.Equal(t, "employee@example.com", sessions[0].Email)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("revoke session", func(t *testing.T) {
+		mock.ExpectExec(`WITH tokens AS \( UPDATE refresh_tokens SET revoked_at = NOW\(\) WHERE family_id IN \(SELECT id FROM sessions WHERE id = \$2 AND user_id = \$1\)`).
+			WithArgs(userID, sessionID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, store.RevokeSession(context.Background(), userID, sessionID))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("revoke session of another user", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE sessions`).
+			WithArgs(userID, sessionID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.RevokeSession(context.Background(), userID, sessionID)
+
+		assert.Equal(t, storage.ErrNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("revoke user sessions", func(t *testing.T) {
+		mock.ExpectExec(`WITH tokens AS \( UPDATE refresh_tokens SET revoked_at = NOW\(\) WHERE user_id = \$1 AND revoked_at IS NULL \) UPDATE sessions`).
+			WithArgs(userID).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		assert.NoError(t, store.RevokeUserSessions(context.Background(), userID))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -16,16 +16,105 @@ import (
 )
 
 type Storage interface {
-	CreatePVZ(ctx context.Context, city string) (PVZ, error)
-	GetPVZsWithReceptions(ctx context.Context, startDate, endDate time.Time, page, limit int) ([]PVZWithReceptions, error)
-	CreateReception(ctx context.Context, pvzID uuid.UUID) (Reception, error)
+	CreatePVZ(ctx context.Context, pvz PVZ) (PVZ, error)
+	ImportPVZs(ctx context.Context, rows []ImportRow, opts ImportOptions) (ImportReport, error)
+	GetPVZ(ctx context.Context, id uuid.UUID) (PVZ, error)
+	UpdatePVZ(ctx context.Context, pvz PVZ) (PVZ, error)
+	ChangePVZStatus(ctx context.Context, pvzID uuid.UUID, changes []PVZStatusChange) ([]PVZStatusChange, error)
+	ListPVZStatusChanges(ctx context.Context, pvzID uuid.UUID) ([]PVZStatusChange, error)
+	FindNearbyPVZs(ctx context.Context, filter NearbyFilter) ([]NearbyPVZ, error)
+	ListScheduleExceptions(ctx context.Context, filter ScheduleExceptionFilter) ([]ScheduleException, error)
+	SetScheduleException(ctx context.Context, e ScheduleException) (ScheduleException, error)
+	DeleteScheduleException(ctx context.Context, pvzID *uuid.UUID, date string) error
+	CreateScheduleOverride(ctx context.Context, o ScheduleOverride) (ScheduleOverride, error)
+	EndScheduleOverride(ctx context.Context, pvzID uuid.UUID) error
+	GetPVZsWithReceptions(ctx context.Context, filter PVZFilter) ([]PVZWithReceptions, error)
+	CreateReception(ctx context.Context, pvzID uuid.UUID, createdBy *uuid.UUID) (Reception, error)
 	GetOpenReception(ctx context.Context, pvzID uuid.UUID) (Reception, error)
-	CloseReception(ctx context.Context, receptionID uuid.UUID) error
-	AddProduct(ctx context.Context, receptionID uuid.UUID, productType string) (Product, error)
+	ListReceptions(ctx context.Context, filter ReceptionFilter) ([]Reception, error)
+	GetReception(ctx context.Context, id uuid.UUID, includeDeleted bool) (ReceptionWithProducts, error)
+	CloseReception(ctx context.Context, receptionID uuid.UUID, closedBy *uuid.UUID) error
+	AddProduct(ctx context.Context, receptionID uuid.UUID, productType string, createdBy *uuid.UUID) (Product, error)
 	GetLastProduct(ctx context.Context, receptionID uuid.UUID) (Product, error)
-	DeleteProduct(ctx context.Context, productID uuid.UUID) error
+	DeleteProduct(ctx context.Context, productID uuid.UUID, deletedBy *uuid.UUID) error
+
+	ListCities(ctx context.Context, includeInactive bool) ([]City, error)
+	GetCity(ctx context.Context, name string) (City, error)
+	CreateCity(ctx context.Context, name string) (City, error)
+	SetCityActive(ctx context.Context, name string, active bool) (City, error)
+	DeleteCity(ctx context.Context, name string) error
+
 	CreateUser(ctx context.Context, email, passwordHash, role string) (User, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
+	ListUsers(ctx context.Context, filter UserFilter) ([]User, error)
+	UpdateUserRole(ctx context.Context, id uuid.UUID, role string) error
+	SetUserDisabled(ctx context.Context, id uuid.UUID, disabled bool) error
+	SetPasswordResetRequired(ctx context.Context, id uuid.UUID, required bool) error
+	UpdatePasswordHash(ctx context.Context, id uuid.UUID, oldHash, newHash string) error
+	UpdateLastLogin(ctx context.Context, id uuid.UUID) error
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+
+	CreateInvite(ctx context.Context, tokenHash, role string, createdBy *uuid.UUID, expiresAt time.Time) (Invite, error)
+	CreateUserWithInvite(ctx context.Context, email, passwordHash, inviteHash string) (User, error)
+
+	CreatePasswordReset(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) (uuid.UUID, error)
+
+	CreateOIDCState(ctx context.Context, stateHash, nonce, codeVerifier string, expiresAt time.Time) error
+	ConsumeOIDCState(ctx context.Context, stateHash string) (OIDCState, error)
+	GetUserByIdentity(ctx context.Context, issuer, subject string) (LinkedUser, error)
+	LinkIdentity(ctx context.Context, issuer, subject string, userID uuid.UUID, syncRole bool) error
+
+	CreateRefreshToken(ctx context.Context, userID, familyID uuid.UUID, tokenHash string, expiresAt time.Time) (RefreshToken, error)
+	GetRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, id uuid.UUID) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+
+	CreateSession(ctx context.Context, userID uuid.UUID, device, userAgent, ip string) (Session, error)
+	TouchSession(ctx context.Context, id uuid.UUID) (bool, error)
+	ListUserSessions(ctx context.Context, userID uuid.UUID) ([]Session, error)
+	ListPVZSessions(ctx context.Context, pvzID uuid.UUID) ([]Session, error)
+	RevokeSession(ctx context.Context, userID, id uuid.UUID) error
+	RevokeUserSessions(ctx context.Context, userID uuid.UUID) error
+
+	AssignUserToPVZ(ctx context.Context, userID, pvzID uuid.UUID) error
+	UnassignUserFromPVZ(ctx context.Context, userID, pvzID uuid.UUID) error
+	GetUserPVZIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+
+	GetRolePermissions(ctx context.Context, role string) ([]string, error)
+	IsMFARequired(ctx context.Context, role string) (bool, error)
+	SetRoleMFARequired(ctx context.Context, role string, required bool) error
+
+	GetMFA(ctx context.Context, userID uuid.UUID) (MFA, error)
+	StartMFAEnrollment(ctx context.Context, userID uuid.UUID, secret string) error
+	ConfirmMFA(ctx context.Context, userID uuid.UUID, step int64, codeHashes []string) error
+	UseMFAStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	DeleteMFA(ctx context.Context, userID uuid.UUID) error
+
+	CreateServiceAccount(ctx context.Context, name, description string, permissions []string, createdBy *uuid.UUID) (ServiceAccount, error)
+	ListServiceAccounts(ctx context.Context) ([]ServiceAccount, error)
+	GetServiceAccount(ctx context.Context, id uuid.UUID) (ServiceAccount, error)
+	DeleteServiceAccount(ctx context.Context, id uuid.UUID) error
+	CreateAPIKey(ctx context.Context, serviceAccountID uuid.UUID, keyID, keyHash string, expiresAt *time.Time) (APIKey, error)
+	ListAPIKeys(ctx context.Context, serviceAccountID uuid.UUID) ([]APIKey, error)
+	GetAPIKey(ctx context.Context, keyID string) (APIKey, error)
+	RevokeAPIKey(ctx context.Context, serviceAccountID, id uuid.UUID) error
+	TouchAPIKey(ctx context.Context, id uuid.UUID) error
+
+	RecordLoginFailure(ctx context.Context, scope, key string, window time.Duration) (int, error)
+	LockLogin(ctx context.Context, scope, key string, duration time.Duration) error
+	GetLoginLock(ctx context.Context, scope, key string) (time.Duration, error)
+	ClearLoginFailures(ctx context.Context, scope, key string) error
+
+	AppendAudit(ctx context.Context, entry AuditEntry) (AuditEntry, error)
+	AppendAuditBatch(ctx context.Context, entries []AuditEntry) ([]AuditEntry, error)
+	ListAuditEntries(ctx context.Context, filter AuditFilter) ([]AuditEntry, error)
+	VerifyAuditLog(ctx context.Context) (AuditVerification, error)
 }
 
 type PostgresStorage struct {
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RefreshToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	FamilyID  uuid.UUID
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+func (s *PostgresStorage) CreateRefreshToken(ctx context.Context, userID, familyID uuid.UUID, tokenHash string, expiresAt time.Time) (RefreshToken, error) {
+	var token RefreshToken
+	err := s.db.QueryRowContext(ctx,
+		`INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, family_id, expires_at, created_at`,
+		userID, familyID, tokenHash, expiresAt,
+	).Scan(&token.ID, &token.UserID, &token.FamilyID, &token.ExpiresAt, &token.CreatedAt)
+	return token, err
+}
+
+func (s *PostgresStorage) GetRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	var token RefreshToken
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id, user_id, family_id, expires_at, created_at, used_at, revoked_at
+		FROM refresh_tokens WHERE token_hash = $1`,
+		tokenHash,
+	).Scan(&token.ID, &token.UserID, &token.FamilyID, &token.ExpiresAt, &token.CreatedAt, &token.UsedAt, &token.RevokedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return RefreshToken{}, ErrNotFound
+	}
+	return token, err
+}
+
+// MarkRefreshTokenUsed consumes a refresh token. It reports false if the
+// token was already used or revoked, e.g. by a concurrent refresh.
+func (s *PostgresStorage) MarkRefreshTokenUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE refresh_tokens
+		SET used_at = NOW()
+		WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL`,
+		id,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (s *PostgresStorage) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE family_id = $1 AND revoked_at IS NULL`,
+		familyID,
+	)
+	return err
+}
+
+func (s *PostgresStorage) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO revoked_tokens (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING`,
+		jti, expiresAt,
+	)
+	return err
+}
+
+func (s *PostgresStorage) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)`,
+		jti,
+	).Scan(&revoked)
+	return revoked, err
+}
+
+// PurgeExpiredTokens drops denylist entries and refresh tokens that can no
+// longer be used anyway.
+func (s *PostgresStorage) PurgeExpiredTokens(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx,
+		`DELETE FROM revoked_tokens WHERE expires_at < NOW()`,
+	); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM refresh_tokens WHERE expires_at < NOW()`,
+	)
+	return err
+}
@@ -0,0 +1,135 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/mi4r/avito-pvz/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshTokens(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+
+	t.Run("create refresh token", func(t *testing.T) {
+		id, userID, familyID := uuid.New(), uuid.New(), uuid.New()
+		expiresAt := time.Now().Add(time.Hour)
+
+		mock.ExpectQuery(`INSERT INTO refresh_tokens`).
+			WithArgs(userID, familyID, "hash", expiresAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "family_id", "expires_at", "created_at"}).
+				AddRow(id, userID, familyID, expiresAt, time.Now()))
+
+		token, err := store.CreateRefreshToken(context.Background(), userID, familyID, "hash", expiresAt)
+
+		assert.NoError(t, err)
+		assert.Equal(t, id, token.ID)
+		assert.Equal(t, familyID, token.FamilyID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get refresh token not found", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, user_id, family_id, expires_at, created_at, used_at, revoked_at FROM refresh_tokens WHERE token_hash = \$1`).
+			WithArgs("missing").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := store.GetRefreshToken(context.Background(), "missing")
+
+		assert.Equal(t, storage.ErrNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("mark used", func(t *testing.T) {
+		id := uuid.New()
+		mock.ExpectExec(`UPDATE refresh_tokens SET used_at = NOW\(\) WHERE id = \$1 AND used_at IS NULL AND revoked_at IS NULL`).
+			WithArgs(id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		ok, err := store.MarkRefreshTokenUsed(context.Background(), id)
+
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("mark already used", func(t *testing.T) {
+		id := uuid.New()
+		mock.ExpectExec(`UPDATE refresh_tokens SET used_at`).
+			WithArgs(id).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		ok, err := store.MarkRefreshTokenUsed(context.Background(), id)
+
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("revoke family", func(t *testing.T) {
+		familyID := uuid.New()
+		mock.ExpectExec(`UPDATE refresh_tokens SET revoked_at = NOW\(\) WHERE family_id = \$1`).
+			WithArgs(familyID).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+
+		err := store.RevokeRefreshTokenFamily(context.Background(), familyID)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRevokedTokens(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+
+	t.Run("revoke token", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour)
+		mock.ExpectExec(`INSERT INTO revoked_tokens \(jti, expires_at\) VALUES \(\$1, \$2\) ON CONFLICT \(jti\) DO NOTHING`).
+			WithArgs("jti", expiresAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.RevokeToken(context.Background(), "jti", expiresAt)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("is token revoked", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT EXISTS`).
+			WithArgs("jti").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		revoked, err := store.IsTokenRevoked(context.Background(), "jti")
+
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("purge expired", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM revoked_tokens WHERE expires_at < NOW\(\)`).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`DELETE FROM refresh_tokens WHERE expires_at < NOW\(\)`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.PurgeExpiredTokens(context.Background())
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -2,17 +2,56 @@ package storage
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrInvalidRole = errors.New("invalid role")
+	ErrUserExists  = errors.New("user already exists")
 )
 
 type User struct {
-	ID           uuid.UUID `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"`
-	Role         string    `json:"role"`
+	ID                    uuid.UUID  `json:"id"`
+	Email                 string     `json:"email"`
+	PasswordHash          string     `json:"-"`
+	Role                  string     `json:"role"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	DisabledAt            *time.Time `json:"disabledAt,omitempty"`
+	LastLoginAt           *time.Time `json:"lastLoginAt,omitempty"`
+	PasswordResetRequired bool       `json:"passwordResetRequired"`
+}
+
+// UserFilter selects a page of users for ListUsers. Query matches
+// a substring of the email.
+type UserFilter struct {
+	Query    string
+	Role     string
+	Disabled *bool
+	Page     int
+	Limit    int
+}
+
+const userColumns = `id, email, password_hash, role, created_at, disabled_at, last_login_at, password_reset_required`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (User, error) {
+	var user User
+	err := row.Scan(&user.ID, &user.Email, &user.PasswordHash, &user.Role,
+		&user.CreatedAt, &user.DisabledAt, &user.LastLoginAt, &user.PasswordResetRequired)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrNotFound
+	}
+	return user, err
 }
 
 func (s *PostgresStorage) CreateUser(ctx context.Context, email, passwordHash, role string) (User, error) {
@@ -20,24 +59,170 @@ func (s *PostgresStorage) CreateUser(ctx context.Context, email, passwordHash, r
 	err := s.db.QueryRowContext(ctx,
 		`INSERT INTO users (email, password_hash, role)
 		VALUES ($1, $2, $3)
-		RETURNING id, email, role`,
+		RETURNING id, email, role, created_at`,
 		email, passwordHash, role,
-	).Scan(&user.ID, &user.Email, &user.Role)
-	return user, err
+	).Scan(&user.ID, &user.Email, &user.Role, &user.CreatedAt)
+	return user, classifyUserError(err)
+}
+
+// classifyUserError maps constraint violations on users to storage errors.
+func classifyUserError(err error) error {
+	switch {
+	case isUniqueViolation(err):
+		return ErrUserExists
+	case isForeignKeyViolation(err):
+		return ErrInvalidRole
+	}
+	return err
 }
 
 func (s *PostgresStorage) GetUserByEmail(ctx context.Context, email string) (User, error) {
-	var user User
-	err := s.db.QueryRowContext(ctx,
-		`SELECT id, email, password_hash, role 
-		FROM users WHERE email = $1`,
+	return scanUser(s.db.QueryRowContext(ctx,
+		`SELECT `+userColumns+`
+		FROM users WHERE lower(email) = lower($1)`,
 		email,
-	).Scan(&user.ID, &user.Email, &user.PasswordHash, &user.Role)
+	))
+}
 
-	if errors.Is(err, pgx.ErrNoRows) {
-		return User{}, ErrNotFound
+func (s *PostgresStorage) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	return scanUser(s.db.QueryRowContext(ctx,
+		`SELECT `+userColumns+`
+		FROM users WHERE id = $1`,
+		id,
+	))
+}
+
+func (s *PostgresStorage) ListUsers(ctx context.Context, filter UserFilter) ([]User, error) {
+	var disabled sql.NullBool
+	if filter.Disabled != nil {
+		disabled = sql.NullBool{Bool: *filter.Disabled, Valid: true}
 	}
-	return user, err
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+userColumns+`
+		FROM users
+		WHERE ($3 = '' OR email ILIKE '%' || $3 || '%')
+		AND ($4 = '' OR role = $4)
+		AND ($5::boolean IS NULL OR (disabled_at IS NOT NULL) = $5)
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2`,
+		filter.Limit, (filter.Page-1)*filter.Limit, escapeLike(filter.Query), filter.Role, disabled,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
 }
 
-var ErrNotFound = errors.New("not found")
+func (s *PostgresStorage) UpdateUserRole(ctx context.Context, id uuid.UUID, role string) error {
+	err := s.execOne(ctx,
+		`UPDATE users SET role = $2 WHERE id = $1`,
+		id, role,
+	)
+	if isForeignKeyViolation(err) {
+		return ErrInvalidRole
+	}
+	return err
+}
+
+// SetUserDisabled disables or re-enables the user. Disabling also ends the
+// user's sessions in the same transaction.
+func (s *PostgresStorage) SetUserDisabled(ctx context.Context, id uuid.UUID, disabled bool) error {
+	return s.updateUser(ctx, id, disabled,
+		`UPDATE users
+		SET disabled_at = CASE WHEN $2 THEN COALESCE(disabled_at, NOW()) END
+		WHERE id = $1`,
+		id, disabled,
+	)
+}
+
+// SetPasswordResetRequired sets the flag that forces a password reset.
+// Setting it also ends the user's sessions in the same transaction.
+func (s *PostgresStorage) SetPasswordResetRequired(ctx context.Context, id uuid.UUID, required bool) error {
+	return s.updateUser(ctx, id, required,
+		`UPDATE users SET password_reset_required = $2 WHERE id = $1`,
+		id, required,
+	)
+}
+
+// updateUser runs an update that must hit the user and, with revoke, ends
+// the user's sessions, so the change and the revocation apply together.
+func (s *PostgresStorage) updateUser(ctx context.Context, id uuid.UUID, revoke bool, query string, args ...any) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	if revoke {
+		if _, err := tx.ExecContext(ctx, revokeUserSessionsQuery, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UpdatePasswordHash replaces the hash only if it is still oldHash, so a
+// rehash can't undo a password change made in the meantime.
+func (s *PostgresStorage) UpdatePasswordHash(ctx context.Context, id uuid.UUID, oldHash, newHash string) error {
+	return s.execOne(ctx,
+		`UPDATE users SET password_hash = $3 WHERE id = $1 AND password_hash = $2`,
+		id, oldHash, newHash,
+	)
+}
+
+func (s *PostgresStorage) UpdateLastLogin(ctx context.Context, id uuid.UUID) error {
+	return s.execOne(ctx,
+		`UPDATE users SET last_login_at = NOW() WHERE id = $1`,
+		id,
+	)
+}
+
+func (s *PostgresStorage) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return s.execOne(ctx,
+		`DELETE FROM users WHERE id = $1`,
+		id,
+	)
+}
+
+// execOne runs a statement that must affect exactly one row.
+func (s *PostgresStorage) execOne(ctx context.Context, query string, args ...any) error {
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -3,14 +3,19 @@ package storage_test
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/mi4r/avito-pvz/internal/storage"
 	"github.com/stretchr/testify/assert"
 )
 
+var userColumns = []string{"id", "email", "password_hash", "role", "created_at", "disabled_at", "last_login_at", "password_reset_required"}
+
 func TestCreateUser(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -29,8 +34,8 @@ func TestCreateUser(t *testing.T) {
 		// Mock setup
 		mock.ExpectQuery(`INSERT INTO users`).
 			WithArgs(email, passwordHash, role).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role"}).
-				AddRow(userID, email, role))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "role", "created_at"}).
+				AddRow(userID, email, role, time.Now()))
 
 		user, err := store.CreateUser(context.Background(), email, passwordHash, role)
 
@@ -73,6 +78,28 @@ func TestCreateUser(t *testing.T) {
 		assert.Error(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
+
+	t.Run("unique violation", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO users`).
+			WithArgs("dup@example.com", "hash", "employee").
+			WillReturnError(&pq.Error{Code: "23505"})
+
+		_, err := store.CreateUser(context.Background(), "dup@example.com", "hash", "employee")
+
+		assert.Equal(t, storage.ErrUserExists, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO users`).
+			WithArgs("new@example.com", "hash", "root").
+			WillReturnError(&pq.Error{Code: "23503"})
+
+		_, err := store.CreateUser(context.Background(), "new@example.com", "hash", "root")
+
+		assert.Equal(t, storage.ErrInvalidRole, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestGetUserByEmail(t *testing.T) {
@@ -91,10 +118,10 @@ func TestGetUserByEmail(t *testing.T) {
 		role := "employee"
 
 		// Mock setup
-		mock.ExpectQuery(`SELECT id, email, password_hash, role FROM users WHERE email = \$1`).
+		mock.ExpectQuery(`SELECT id, email, password_hash, role, created_at, disabled_at, last_login_at, password_reset_required FROM users WHERE lower\(email\) = lower\(\$1\)`).
 			WithArgs(email).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "password_hash", "role"}).
-				AddRow(userID, email, passwordHash, role))
+			WillReturnRows(sqlmock.NewRows(userColumns).
+				AddRow(userID, email, passwordHash, role, time.Now(), nil, nil, false))
 
 		user, err := store.GetUserByEmail(context.Background(), email)
 
@@ -110,13 +137,13 @@ func TestGetUserByEmail(t *testing.T) {
 		email := "nonexistent@example.com"
 
 		// Mock setup
-		mock.ExpectQuery(`SELECT id, email, password_hash, role FROM users WHERE email = \$1`).
+		mock.ExpectQuery(`SELECT id, email, password_hash, role, created_at, disabled_at, last_login_at, password_reset_required FROM users WHERE lower\(email\) = lower\(\$1\)`).
 			WithArgs(email).
 			WillReturnError(sql.ErrNoRows)
 
 		_, err := store.GetUserByEmail(context.Background(), email)
 
-		assert.Error(t, err)
+		assert.Equal(t, storage.ErrNotFound, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
@@ -124,7 +151,7 @@ func TestGetUserByEmail(t *testing.T) {
 		email := "test@example.com"
 
 		// Mock setup
-		mock.ExpectQuery(`SELECT id, email, password_hash, role FROM users WHERE email = \$1`).
+		mock.ExpectQuery(`SELECT id, email, password_hash, role, created_at, disabled_at, last_login_at, password_reset_required FROM users WHERE lower\(email\) = lower\(\$1\)`).
 			WithArgs(email).
 			WillReturnError(sql.ErrConnDone)
 
@@ -135,3 +162,166 @@ func TestGetUserByEmail(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
+
+func TestUserAdministration(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := storage.NewPostgresStorage(db)
+
+	t.Run("list users", func(t *testing.T) {
+		disabledAt := time.Now()
+		disabled := true
+		mock.ExpectQuery(`SELECT .* FROM users WHERE .* ORDER BY created_at DESC LIMIT \$1 OFFSET \$2`).
+			WithArgs(10, 10, `50\%`, "employee", sql.NullBool{Bool: true, Valid: true}).
+			WillReturnRows(sqlmock.NewRows(userColumns).
+				AddRow(uuid.New(), "a@example.com", "hash", "employee", time.Now(), disabledAt, nil, false))
+
+		users, err := store.ListUsers(context.Background(), storage.UserFilter{
+			Query: "50%", Role: "employee", Disabled: &disabled, Page: 2, Limit: 10,
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.NotNil(t, users[0].DisabledAt)
+		assert.Nil(t, users[0].LastLoginAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("update role of unknown user", func(t *testing.T) {
+		userID := uuid.New()
+		mock.ExpectExec(`UPDATE users SET role = \$2 WHERE id = \$1`).
+			WithArgs(userID, "moderator").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.UpdateUserRole(context.Background(), userID, "moderator")
+
+		assert.Equal(t, storage.ErrNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("update to unknown role", func(t *testing.T) {
+		userID := uuid.New()
+		mock.ExpectExec(`UPDATE users SET role`).
+			WithArgs(userID, "root").
+			WillReturnError(&pq.Error{Code: "23503"})
+
+		err := store.UpdateUserRole(context.Background(), userID, "root")
+
+		assert.Equal(t, storage.ErrInvalidRole, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("disable user", func(t *testing.T) {
+		userID := uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE users SET disabled_at = CASE WHEN \$2 THEN COALESCE\(disabled_at, NOW\(\)\) END WHERE id = \$1`).
+			WithArgs(userID, true).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`WITH tokens AS \( UPDATE refresh_tokens`).
+			WithArgs(userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := store.SetUserDisabled(context.Background(), userID, true)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("disable user rolls back when revoking sessions fails", func(t *testing.T) {
+		userID := uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE users SET disabled_at`).
+			WithArgs(userID, true).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`WITH tokens AS \( UPDATE refresh_tokens`).
+			WithArgs(userID).
+			WillReturnError(errors.New("db down"))
+		mock.ExpectRollback()
+
+		err := store.SetUserDisabled(context.Background(), userID, true)
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("enable user keeps sessions", func(t *testing.T) {
+		userID := uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE users SET disabled_at`).
+			WithArgs(userID, false).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := store.SetUserDisabled(context.Background(), userID, false)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("force password reset of unknown user", func(t *testing.T) {
+		userID := uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE users SET password_reset_required = \$2 WHERE id = \$1`).
+			WithArgs(userID, true).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := store.SetPasswordResetRequired(context.Background(), userID, true)
+
+		assert.Equal(t, storage.ErrNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("update password hash", func(t *testing.T) {
+		userID := uuid.New()
+		mock.ExpectExec(`UPDATE users SET password_hash = \$3 WHERE id = \$1 AND password_hash = \$2`).
+			WithArgs(userID, "old", "new").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.UpdatePasswordHash(context.Background(), userID, "old", "new")
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("update password hash after password change", func(t *testing.T) {
+		userID := uuid.New()
+		mock.ExpectExec(`UPDATE users SET password_hash`).
+			WithArgs(userID, "old", "new").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.UpdatePasswordHash(context.Background(), userID, "old", "new")
+
+		assert.Equal(t, storage.ErrNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("update last login", func(t *testing.T) {
+		userID := uuid.New()
+		mock.ExpectExec(`UPDATE users SET last_login_at = NOW\(\) WHERE id = \$1`).
+			WithArgs(userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.UpdateLastLogin(context.Background(), userID)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("delete user", func(t *testing.T) {
+		userID := uuid.New()
+		mock.ExpectExec(`DELETE FROM users WHERE id = \$1`).
+			WithArgs(userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.DeleteUser(context.Background(), userID)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,45 @@
+package token
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// APIKeyPrefix starts every API key so leaked keys are easy to spot
+// by secret scanners.
+const APIKeyPrefix = "pvz_"
+
+// NewAPIKey returns a key of the form pvz_<id>_<secret>, its public id
+// used for lookup and the hash that is stored instead of the key.
+func NewAPIKey() (plain, id, hash string, err error) {
+	idBuf := make([]byte, 6)
+	if _, err := rand.Read(idBuf); err != nil {
+		return "", "", "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+	id = hex.EncodeToString(idBuf)
+	plain = APIKeyPrefix + id + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return plain, id, HashOpaqueToken(plain), nil
+}
+
+// ParseAPIKey returns the public id of a key, or false if plain is not
+// shaped like a key returned by NewAPIKey.
+func ParseAPIKey(plain string) (string, bool) {
+	rest, ok := strings.CutPrefix(plain, APIKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(id) != 12 || secret == "" {
+		return "", false
+	}
+	if _, err := hex.DecodeString(id); err != nil {
+		return "", false
+	}
+	return id, true
+}
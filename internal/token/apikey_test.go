@@ -0,0 +1,41 @@
+package token_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mi4r/avito-pvz/internal/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKey(t *testing.T) {
+	plain, id, hash, err := token.NewAPIKey()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(plain, "pvz_"+id+"_"))
+	assert.Equal(t, token.HashOpaqueToken(plain), hash)
+
+	parsed, ok := token.ParseAPIKey(plain)
+	assert.True(t, ok)
+	assert.Equal(t, id, parsed)
+
+	other, otherID, _, _ := token.NewAPIKey()
+	assert.NotEqual(t, plain, other)
+	assert.NotEqual(t, id, otherID)
+}
+
+func TestParseAPIKeyInvalid(t *testing.T) {
+	for _, plain := range []string{
+		"",
+		"eyJhbGciOiJIUzI1NiJ9.e30.sig",
+		"pvz_",
+		"pvz_0123456789ab",
+		"pvz_0123456789ab_",
+		"pvz_short_secret",
+		"pvz_zzzzzzzzzzzz_secret",
+	} {
+		_, ok := token.ParseAPIKey(plain)
+		assert.False(t, ok, plain)
+	}
+}
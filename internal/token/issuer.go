@@ -0,0 +1,89 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type Claims struct {
+	Role string `json:"role"`
+	// Dummy marks tokens issued by /dummyLogin without credentials
+	Dummy bool `json:"dummy,omitempty"`
+	// MFAPending marks tokens that only allow finishing a two-step login
+	MFAPending bool `json:"mfa_pending,omitempty"`
+	// SessionID ties the token to a login session that can be revoked
+	SessionID string `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Issuer issues short-lived access tokens signed by the key set.
+// Refresh tokens are opaque and are tracked by storage.
+type Issuer struct {
+	keys       *KeySet
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewIssuer(keys *KeySet, accessTTL, refreshTTL time.Duration) *Issuer {
+	return &Issuer{keys: keys, accessTTL: accessTTL, refreshTTL: refreshTTL}
+}
+
+func (i *Issuer) Keys() *KeySet {
+	return i.keys
+}
+
+func (i *Issuer) RefreshTTL() time.Duration {
+	return i.refreshTTL
+}
+
+// MFATokenTTL is how long a user has to enter the second factor after
+// the password was accepted.
+const MFATokenTTL = 5 * time.Minute
+
+// AccessToken signs a new access token with a unique jti so it can be
+// revoked on its own or together with its session.
+func (i *Issuer) AccessToken(subject, role, sessionID string) (string, Claims, error) {
+	return i.sign(Claims{Role: role, SessionID: sessionID, RegisteredClaims: jwt.RegisteredClaims{Subject: subject}}, i.accessTTL)
+}
+
+// DummyToken signs an access token without a subject for test logins.
+func (i *Issuer) DummyToken(role string) (string, Claims, error) {
+	return i.sign(Claims{Role: role, Dummy: true}, i.accessTTL)
+}
+
+// MFAToken signs a short-lived token proving the password step of a
+// login. It isn't accepted as an access token.
+func (i *Issuer) MFAToken(subject string) (string, Claims, error) {
+	return i.sign(Claims{MFAPending: true, RegisteredClaims: jwt.RegisteredClaims{Subject: subject}}, MFATokenTTL)
+}
+
+func (i *Issuer) sign(claims Claims, ttl time.Duration) (string, Claims, error) {
+	now := time.Now()
+	claims.ID = uuid.NewString()
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+	tokenStr, err := i.keys.Sign(claims)
+	return tokenStr, claims, err
+}
+
+// NewOpaqueToken returns a random URL-safe token and its hash.
+// Only the hash is ever stored.
+func NewOpaqueToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	plain := base64.RawURLEncoding.EncodeToString(buf)
+	return plain, HashOpaqueToken(plain), nil
+}
+
+func HashOpaqueToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,212 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoKeys         = errors.New("no JWT keys configured")
+	ErrUnknownKey     = errors.New("unknown key id")
+	ErrNoSigningKey   = errors.New("signing key is not configured or is verify-only")
+	ErrUnsupportedAlg = errors.New("unsupported signing algorithm")
+)
+
+// Key is a single JWT key identified by its kid.
+// Verify-only keys (public keys of rotated-out pairs) have no signing part.
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+}
+
+func NewHMACKey(id string, secret []byte) Key {
+	return Key{ID: id, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+}
+
+func NewRSAKey(id string, priv *rsa.PrivateKey) Key {
+	return Key{ID: id, Method: jwt.SigningMethodRS256, signKey: priv, verifyKey: &priv.PublicKey}
+}
+
+func NewEdDSAKey(id string, priv ed25519.PrivateKey) Key {
+	return Key{ID: id, Method: jwt.SigningMethodEdDSA, signKey: priv, verifyKey: priv.Public()}
+}
+
+func (k Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+// ParseKey parses a key spec of the form "kid:alg:source".
+// For HS256 the source is the secret itself, for RS256 and EdDSA it is
+// a path to a PEM file with either a private or a public key.
+func ParseKey(spec string) (Key, error) {
+	parts := strings.SplitN(strings.TrimSpace(spec), ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return Key{}, fmt.Errorf("invalid key spec %q: expected kid:alg:source", spec)
+	}
+	id, alg, source := parts[0], parts[1], parts[2]
+
+	switch alg {
+	case jwt.SigningMethodHS256.Alg():
+		return NewHMACKey(id, []byte(source)), nil
+	case jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg():
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return Key{}, fmt.Errorf("failed to read key %s: %w", id, err)
+		}
+		return parsePEMKey(id, alg, data)
+	default:
+		return Key{}, fmt.Errorf("key %s: %w: %s", id, ErrUnsupportedAlg, alg)
+	}
+}
+
+func parsePEMKey(id, alg string, data []byte) (Key, error) {
+	if alg == jwt.SigningMethodRS256.Alg() {
+		if priv, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+			return NewRSAKey(id, priv), nil
+		}
+		pub, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return Key{}, fmt.Errorf("key %s: invalid RSA PEM: %w", id, err)
+		}
+		return Key{ID: id, Method: jwt.SigningMethodRS256, verifyKey: pub}, nil
+	}
+
+	if priv, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+		return NewEdDSAKey(id, priv.(ed25519.PrivateKey)), nil
+	}
+	pub, err := jwt.ParseEdPublicKeyFromPEM(data)
+	if err != nil {
+		return Key{}, fmt.Errorf("key %s: invalid Ed25519 PEM: %w", id, err)
+	}
+	return Key{ID: id, Method: jwt.SigningMethodEdDSA, verifyKey: pub}, nil
+}
+
+// KeySet signs tokens with one active key and verifies tokens signed by
+// any key in the set, which allows rotating keys without logging users out.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+func NewKeySet(signingKID string, keys ...Key) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	ks := &KeySet{keys: make(map[string]*Key, len(keys))}
+	for i := range keys {
+		key := keys[i]
+		if _, ok := ks.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		ks.keys[key.ID] = &key
+	}
+
+	if signingKID == "" && len(keys) == 1 {
+		signingKID = keys[0].ID
+	}
+	signing, ok := ks.keys[signingKID]
+	if !ok || !signing.CanSign() {
+		return nil, ErrNoSigningKey
+	}
+	ks.signing = signing
+	return ks, nil
+}
+
+// LoadKeySet builds a key set from key specs, see ParseKey.
+func LoadKeySet(specs []string, signingKID string) (*KeySet, error) {
+	keys := make([]Key, 0, len(specs))
+	for _, spec := range specs {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		key, err := ParseKey(spec)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return NewKeySet(signingKID, keys...)
+}
+
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.signKey)
+}
+
+// Parse verifies the token signature with the key referenced by its kid
+// header and fills claims.
+func (ks *KeySet) Parse(tokenStr string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenStr, claims, ks.keyFunc)
+}
+
+func (ks *KeySet) keyFunc(token *jwt.Token) (any, error) {
+	key := ks.signing
+	if kid, ok := token.Header["kid"].(string); ok {
+		if key, ok = ks.keys[kid]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+		}
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.verifyKey, nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public part of every asymmetric key in the set.
+// HMAC secrets are never published.
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
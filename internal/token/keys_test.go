@@ -0,0 +1,155 @@
+package token_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mi4r/avito-pvz/internal/token"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	path := filepath.Join(t.TempDir(), "key.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestParseKey(t *testing.T) {
+	t.Run("hmac", func(t *testing.T) {
+		key, err := token.ParseKey("k1:HS256:super-secret")
+		require.NoError(t, err)
+		assert.Equal(t, "k1", key.ID)
+		assert.Equal(t, "HS256", key.Method.Alg())
+		assert.True(t, key.CanSign())
+	})
+
+	t.Run("rsa private key", func(t *testing.T) {
+		priv, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		path := writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(priv))
+
+		key, err := token.ParseKey("rsa:RS256:" + path)
+		require.NoError(t, err)
+		assert.Equal(t, "RS256", key.Method.Alg())
+		assert.True(t, key.CanSign())
+	})
+
+	t.Run("ed25519 public key is verify-only", func(t *testing.T) {
+		pub, _, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		require.NoError(t, err)
+		path := writePEM(t, "PUBLIC KEY", der)
+
+		key, err := token.ParseKey("ed:EdDSA:" + path)
+		require.NoError(t, err)
+		assert.Equal(t, "EdDSA", key.Method.Alg())
+		assert.False(t, key.CanSign())
+	})
+
+	t.Run("invalid specs", func(t *testing.T) {
+		for _, spec := range []string{"", "k1", "k1:HS256:", "k1:PS512:x", "k1:RS256:/does/not/exist"} {
+			_, err := token.ParseKey(spec)
+			assert.Error(t, err, spec)
+		}
+	})
+}
+
+func TestNewKeySet(t *testing.T) {
+	t.Run("no keys", func(t *testing.T) {
+		_, err := token.NewKeySet("")
+		assert.ErrorIs(t, err, token.ErrNoKeys)
+	})
+
+	t.Run("single key is the signing key", func(t *testing.T) {
+		_, err := token.NewKeySet("", token.NewHMACKey("k1", []byte("s")))
+		assert.NoError(t, err)
+	})
+
+	t.Run("unknown signing key", func(t *testing.T) {
+		_, err := token.NewKeySet("k2", token.NewHMACKey("k1", []byte("s")))
+		assert.ErrorIs(t, err, token.ErrNoSigningKey)
+	})
+
+	t.Run("duplicate key id", func(t *testing.T) {
+		_, err := token.NewKeySet("k1", token.NewHMACKey("k1", []byte("a")), token.NewHMACKey("k1", []byte("b")))
+		assert.Error(t, err)
+	})
+}
+
+func TestKeySet_SignAndParse(t *testing.T) {
+	rsaPriv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	oldKeys, err := token.NewKeySet("old", token.NewHMACKey("old", []byte("old-secret")))
+	require.NoError(t, err)
+	keys, err := token.NewKeySet("rsa",
+		token.NewRSAKey("rsa", rsaPriv),
+		token.NewEdDSAKey("ed", edPriv),
+		token.NewHMACKey("old", []byte("old-secret")),
+	)
+	require.NoError(t, err)
+
+	claims := jwt.MapClaims{"role": "employee", "exp": time.Now().Add(time.Hour).Unix()}
+
+	t.Run("current key", func(t *testing.T) {
+		tokenStr, err := keys.Sign(claims)
+		require.NoError(t, err)
+
+		parsed := jwt.MapClaims{}
+		tok, err := keys.Parse(tokenStr, parsed)
+		require.NoError(t, err)
+		assert.Equal(t, "rsa", tok.Header["kid"])
+		assert.Equal(t, "employee", parsed["role"])
+	})
+
+	t.Run("rotated key still verifies", func(t *testing.T) {
+		tokenStr, err := oldKeys.Sign(claims)
+		require.NoError(t, err)
+
+		_, err = keys.Parse(tokenStr, jwt.MapClaims{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("algorithm confusion is rejected", func(t *testing.T) {
+		// HS256 token claiming the kid of the RSA key
+		tok := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		tok.Header["kid"] = "rsa"
+		tokenStr, err := tok.SignedString([]byte("old-secret"))
+		require.NoError(t, err)
+
+		_, err = keys.Parse(tokenStr, jwt.MapClaims{})
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown kid", func(t *testing.T) {
+		tok := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		tok.Header["kid"] = "nope"
+		tokenStr, err := tok.SignedString([]byte("old-secret"))
+		require.NoError(t, err)
+
+		_, err = keys.Parse(tokenStr, jwt.MapClaims{})
+		assert.ErrorIs(t, err, token.ErrUnknownKey)
+	})
+
+	t.Run("jwks exposes only public keys", func(t *testing.T) {
+		set := keys.JWKS()
+		require.Len(t, set.Keys, 2)
+		assert.Equal(t, "ed", set.Keys[0].Kid)
+		assert.Equal(t, "Ed25519", set.Keys[0].Crv)
+		assert.Equal(t, "rsa", set.Keys[1].Kid)
+		assert.Equal(t, "AQAB", set.Keys[1].E)
+	})
+}
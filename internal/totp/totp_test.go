@@ -0,0 +1,94 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/mi4r/avito-pvz/internal/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test vectors from RFC 6238 appendix B (SHA1), truncated to 6 digits
+func TestCodeRFC6238(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		code, err := totp.Code(secret, totp.Step(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, tt.code, code, tt.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	now := time.Now()
+	step := totp.Step(now)
+
+	t.Run("current step", func(t *testing.T) {
+		code, _ := totp.Code(secret, step)
+
+		got, ok := totp.Validate(secret, code, now)
+
+		assert.True(t, ok)
+		assert.Equal(t, step, got)
+	})
+
+	t.Run("previous step within skew", func(t *testing.T) {
+		code, _ := totp.Code(secret, step-1)
+
+		got, ok := totp.Validate(secret, code, now)
+
+		assert.True(t, ok)
+		assert.Equal(t, step-1, got)
+	})
+
+	t.Run("outside skew", func(t *testing.T) {
+		code, _ := totp.Code(secret, step-3)
+
+		_, ok := totp.Validate(secret, code, now)
+
+		assert.False(t, ok)
+	})
+
+	t.Run("spaces are ignored", func(t *testing.T) {
+		code, _ := totp.Code(secret, step)
+
+		_, ok := totp.Validate(secret, code[:3]+" "+code[3:], now)
+
+		assert.True(t, ok)
+	})
+
+	t.Run("malformed code", func(t *testing.T) {
+		for _, code := range []string{"", "12345", "1234567", "abcdef"} {
+			_, ok := totp.Validate(secret, code, now)
+			assert.False(t, ok, code)
+		}
+	})
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(totp.ProvisioningURI("JBSWY3DPEHPK3PXP", "avito-pvz", "mod@example.com"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/avito-pvz:mod@example.com", uri.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", uri.Query().Get("secret"))
+	assert.Equal(t, "avito-pvz", uri.Query().Get("issuer"))
+	assert.Equal(t, "6", uri.Query().Get("digits"))
+}